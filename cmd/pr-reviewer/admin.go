@@ -0,0 +1,194 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/Vimp17/pr-reviewer-service/internal/config"
+	"github.com/Vimp17/pr-reviewer-service/internal/models"
+	"github.com/Vimp17/pr-reviewer-service/internal/tenant"
+)
+
+// users выполняет операции с пользователями
+func users(ctx context.Context, cfg config.Config, args []string) error {
+	if len(args) == 0 || args[0] != "deactivate" {
+		return errors.New("usage: users deactivate [-org id] [-reassign] user_id...")
+	}
+
+	fs := newFlagSet("users deactivate", "users deactivate [-org id] [-reassign] user_id...")
+	org := orgFlag(fs)
+	reassign := fs.Bool("reassign", false, "reassign open reviews of deactivated users")
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return errors.New("expected at least one user_id")
+	}
+
+	a, err := openApp(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer a.Close()
+	ctx = adminContext(ctx, *org)
+
+	for _, userID := range fs.Args() {
+		if _, err := a.users.SetUserActiveStatus(ctx, userID, false); err != nil {
+			return fmt.Errorf("user %s: %w", userID, err)
+		}
+		fmt.Printf("user %s: deactivated\n", userID)
+	}
+	if !*reassign {
+		return nil
+	}
+
+	open, err := a.prs.GetOpenPRsForReviewers(ctx, fs.Args())
+	if err != nil {
+		return err
+	}
+	deactivated := make(map[string]bool, fs.NArg())
+	for _, userID := range fs.Args() {
+		deactivated[userID] = true
+	}
+	for _, pr := range open {
+		for _, reviewer := range pr.AssignedReviewers {
+			if !deactivated[reviewer] {
+				continue
+			}
+			_, replacement, err := a.prs.ReassignReviewer(ctx, pr.PullRequestID, reviewer, "reviewer deactivated")
+			if err != nil {
+				fmt.Printf("pull request %s: %s not reassigned: %v\n", pr.PullRequestID, reviewer, err)
+				continue
+			}
+			fmt.Printf("pull request %s: %s replaced by %s\n", pr.PullRequestID, reviewer, replacement)
+		}
+	}
+	return nil
+}
+
+// organization создает организацию и выводит первый токен ее администратора.
+// Команда работает от имени оператора, поэтому подходит для первой
+// организации, когда в API нет администратора организации по умолчанию.
+func organization(ctx context.Context, cfg config.Config, args []string) error {
+	if len(args) == 0 || args[0] != "create" {
+		return errors.New("usage: org create [-name text] org_id")
+	}
+
+	fs := newFlagSet("org create", "org create [-name text] org_id")
+	name := fs.String("name", "", "organization name (default org_id)")
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errors.New("expected org_id")
+	}
+	if *name == "" {
+		*name = fs.Arg(0)
+	}
+
+	a, err := openApp(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer a.Close()
+	ctx = adminContext(ctx, tenant.DefaultOrgID)
+
+	created, token, err := a.orgs.CreateOrganization(ctx, fs.Arg(0), *name)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("organization %s created\nadmin token (shown once): %s\n", created.OrgID, token)
+	return nil
+}
+
+// pr выполняет операции с PR
+func pr(ctx context.Context, cfg config.Config, args []string) error {
+	if len(args) == 0 || args[0] != "reassign" {
+		return errors.New("usage: pr reassign [-org id] [-reason text] pull_request_id old_user_id")
+	}
+
+	fs := newFlagSet("pr reassign", "pr reassign [-org id] [-reason text] pull_request_id old_user_id")
+	org := orgFlag(fs)
+	reason := fs.String("reason", "", "reason recorded in PR history")
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+	if fs.NArg() != 2 {
+		fs.Usage()
+		return errors.New("expected pull_request_id and old_user_id")
+	}
+
+	a, err := openApp(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer a.Close()
+	ctx = adminContext(ctx, *org)
+
+	updated, replacement, err := a.prs.ReassignReviewer(ctx, fs.Arg(0), fs.Arg(1), *reason)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("pull request %s: %s replaced by %s, reviewers %v\n",
+		updated.PullRequestID, fs.Arg(1), replacement, updated.AssignedReviewers)
+	return nil
+}
+
+// stats выводит статистику назначений или задержек в формате JSON
+func stats(ctx context.Context, cfg config.Config, args []string) error {
+	fs := newFlagSet("stats", "stats [-org id] [-team name] [-from time] [-to time] [-latency]")
+	org := orgFlag(fs)
+	team := fs.String("team", "", "author team")
+	from := fs.String("from", "", "period start, RFC3339")
+	to := fs.String("to", "", "period end, RFC3339 (exclusive)")
+	latency := fs.Bool("latency", false, "print review and merge latency instead of assignment stats")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	filter := models.StatsFilter{TeamName: *team}
+	var err error
+	if filter.From, err = parseTimeFlag("from", *from); err != nil {
+		return err
+	}
+	if filter.To, err = parseTimeFlag("to", *to); err != nil {
+		return err
+	}
+	if filter.From != nil && filter.To != nil && !filter.From.Before(*filter.To) {
+		return errors.New("-from must be before -to")
+	}
+
+	a, err := openApp(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer a.Close()
+	ctx = adminContext(ctx, *org)
+
+	var result any
+	if *latency {
+		result, err = a.prs.GetLatencyStats(ctx, filter)
+	} else {
+		result, err = a.prs.GetStats(ctx, filter)
+	}
+	if err != nil {
+		return err
+	}
+	return writeJSON(os.Stdout, result)
+}
+
+func parseTimeFlag(name, value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, fmt.Errorf("-%s must be RFC3339 timestamp", name)
+	}
+	return &t, nil
+}
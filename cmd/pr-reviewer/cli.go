@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/Vimp17/pr-reviewer-service/internal/auth"
+	"github.com/Vimp17/pr-reviewer-service/internal/config"
+	"github.com/Vimp17/pr-reviewer-service/internal/models"
+	"github.com/Vimp17/pr-reviewer-service/internal/requestid"
+	"github.com/Vimp17/pr-reviewer-service/internal/services"
+	"github.com/Vimp17/pr-reviewer-service/internal/storage/postgres"
+	"github.com/Vimp17/pr-reviewer-service/internal/tenant"
+)
+
+// cliActor имя вызывающего в журнале аудита и истории PR для административных команд
+const cliActor = "cli"
+
+// openStorage подключается к БД с параметрами пула из настроек
+func openStorage(ctx context.Context, cfg config.Config) (*postgres.Storage, error) {
+	storage, err := postgres.NewStorage(ctx, cfg.Database.DSN, postgres.PoolConfig{
+		MaxConns:          cfg.Database.MaxConns,
+		MinConns:          cfg.Database.MinConns,
+		MaxConnLifetime:   time.Duration(cfg.Database.MaxConnLifetime),
+		MaxConnIdleTime:   time.Duration(cfg.Database.MaxConnIdleTime),
+		HealthCheckPeriod: time.Duration(cfg.Database.HealthCheckPeriod),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to DB: %w", err)
+	}
+	return storage, nil
+}
+
+// orgDefaults настройки назначения новых организаций
+func orgDefaults(cfg config.Config) models.OrgSettings {
+	return models.OrgSettings{
+		ReviewersPerPR:     cfg.Assignment.ReviewersPerPR,
+		AssignmentStrategy: cfg.Assignment.Strategy,
+	}
+}
+
+// app сервисы для административных команд. Команды вызывают те же сервисы,
+// что и API, от имени администратора организации: проверки, аудит и история
+// PR работают так же, актор записей — cli.
+type app struct {
+	storage   *postgres.Storage
+	prs       *services.PRService
+	teams     *services.TeamService
+	users     *services.UserService
+	orgs      *services.OrgService
+	snapshots *services.SnapshotService
+}
+
+func openApp(ctx context.Context, cfg config.Config) (*app, error) {
+	storage, err := openStorage(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &app{
+		storage:   storage,
+		prs:       services.NewPRService(storage),
+		teams:     services.NewTeamService(storage),
+		users:     services.NewUserService(storage),
+		orgs:      services.NewOrgService(storage, orgDefaults(cfg)),
+		snapshots: services.NewSnapshotService(storage),
+	}, nil
+}
+
+func (a *app) Close() {
+	a.storage.Close()
+}
+
+// adminContext привязывает контекст к организации и администратору cli;
+// все записи аудита одного запуска получают общий идентификатор запроса
+func adminContext(ctx context.Context, orgID string) context.Context {
+	ctx = requestid.WithRequestID(ctx, requestid.New())
+	ctx = auth.WithIdentity(ctx, auth.SystemIdentity(orgID, cliActor))
+	return tenant.WithOrgID(ctx, orgID)
+}
+
+// newFlagSet создает набор флагов подкоманды; usage — строка синтаксиса
+func newFlagSet(name, usage string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s %s\n", os.Args[0], usage)
+		hasFlags := false
+		fs.VisitAll(func(*flag.Flag) { hasFlags = true })
+		if hasFlags {
+			fmt.Fprintf(fs.Output(), "\nFlags:\n")
+			fs.PrintDefaults()
+		}
+	}
+	return fs
+}
+
+// orgFlag добавляет флаг -org с организацией, в которой работает команда
+func orgFlag(fs *flag.FlagSet) *string {
+	return fs.String("org", tenant.DefaultOrgID, "organization ID")
+}
+
+// writeJSON выводит значение в формате JSON с отступами
+func writeJSON(w io.Writer, v any) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
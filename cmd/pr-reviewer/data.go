@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/Vimp17/pr-reviewer-service/internal/config"
+	"github.com/Vimp17/pr-reviewer-service/internal/dataformat"
+)
+
+// formatFlag добавляет флаг -format; пустое значение — формат по расширению файла
+func formatFlag(fs *flag.FlagSet) *string {
+	return fs.String("format", "", "data format: json, jsonl or csv (default: by file extension, else json)")
+}
+
+// resolveFormat выбирает формат по флагу или расширению файла
+func resolveFormat(format, path string) (string, error) {
+	if format == "" {
+		format = dataformat.FromPath(path)
+	}
+	if format == "" {
+		format = dataformat.JSON
+	}
+	if !dataformat.Valid(format) {
+		return "", fmt.Errorf("%w: %s", dataformat.ErrUnknownFormat, format)
+	}
+	return format, nil
+}
+
+// exportData выводит настройки, команды, пользователей, PR и историю PR
+// организации из одного снимка данных; выгрузку можно загрузить командой
+// import в этот же или другой экземпляр
+func exportData(ctx context.Context, cfg config.Config, args []string) error {
+	fs := newFlagSet("export", "export [-org id] [-format json|jsonl|csv] [-o file]")
+	org := orgFlag(fs)
+	format := formatFlag(fs)
+	output := fs.String("o", "-", "output file (- for stdout)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	resolved, err := resolveFormat(*format, *output)
+	if err != nil {
+		return err
+	}
+
+	a, err := openApp(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer a.Close()
+	ctx = adminContext(ctx, *org)
+
+	snapshot, err := a.snapshots.Export(ctx)
+	if err != nil {
+		return err
+	}
+
+	if *output == "-" {
+		return dataformat.Encode(os.Stdout, resolved, snapshot)
+	}
+	file, err := os.Create(*output)
+	if err != nil {
+		return err
+	}
+	if err := dataformat.Encode(file, resolved, snapshot); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// importData загружает выгрузку: настройки перезаписываются, команды
+// создаются, пользователи и PR создаются или перезаписываются, история
+// дополняется одной транзакцией. Отчет выводится в JSON; при ошибках
+// в записях ничего не сохраняется и команда завершается с ошибкой.
+func importData(ctx context.Context, cfg config.Config, args []string) error {
+	fs := newFlagSet("import", "import [-org id] [-format json|jsonl|csv] [-dry-run] file|-")
+	org := orgFlag(fs)
+	format := formatFlag(fs)
+	dryRun := fs.Bool("dry-run", false, "validate records without saving them")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errors.New("expected data file")
+	}
+	path := fs.Arg(0)
+	resolved, err := resolveFormat(*format, path)
+	if err != nil {
+		return err
+	}
+
+	r := io.Reader(os.Stdin)
+	if path != "-" {
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		r = file
+	}
+	snapshot, positions, err := dataformat.Decode(r, resolved)
+	if err != nil {
+		return fmt.Errorf("invalid %s data: %w", resolved, err)
+	}
+
+	a, err := openApp(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer a.Close()
+	ctx = adminContext(ctx, *org)
+
+	report, err := a.snapshots.Import(ctx, snapshot, *dryRun)
+	if err != nil {
+		return err
+	}
+	positions.Locate(report.Errors)
+	if err := writeJSON(os.Stdout, report); err != nil {
+		return err
+	}
+	if len(report.Errors) > 0 {
+		return fmt.Errorf("%d invalid records, nothing imported", len(report.Errors))
+	}
+	return nil
+}
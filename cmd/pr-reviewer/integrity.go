@@ -0,0 +1,45 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/Vimp17/pr-reviewer-service/internal/config"
+	"github.com/Vimp17/pr-reviewer-service/internal/requestid"
+)
+
+// integrity проверяет целостность данных во всех организациях и с -repair
+// исправляет то, что можно исправить без участия оператора
+func integrity(ctx context.Context, cfg config.Config, args []string) error {
+	fs := newFlagSet("integrity", "integrity [-repair]")
+	repair := fs.Bool("repair", false, "fix repairable issues and validate constraints that hold")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	storage, err := openStorage(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer storage.Close()
+
+	// Исправления пишутся в журнал аудита каждой организации от имени cli
+	ctx = requestid.WithRequestID(ctx, requestid.New())
+	issues, err := storage.CheckIntegrity(ctx, *repair, cliActor)
+	if err != nil {
+		return err
+	}
+	if err := writeJSON(os.Stdout, issues); err != nil {
+		return err
+	}
+
+	remaining := 0
+	for _, issue := range issues {
+		remaining += issue.Count - issue.Repaired
+	}
+	if remaining > 0 {
+		return fmt.Errorf("%d integrity issues remain", remaining)
+	}
+	return nil
+}
@@ -1,74 +1,116 @@
+// Команда pr-reviewer запускает сервис и административные подкоманды:
+//
+//	pr-reviewer [-config файл] [--print-config] [команда] [флаги команды]
+//
+// Без команды выполняется serve.
 package main
 
 import (
 	"context"
-	"log"
-	"net/http"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
 	"os"
-	"os/signal"
-	"syscall"
-	"time"
+	"sort"
 
-	"github.com/Vimp17/pr-reviewer-service/internal/handlers"
-	"github.com/Vimp17/pr-reviewer-service/internal/services"
-	"github.com/Vimp17/pr-reviewer-service/internal/storage/postgres"
-	"github.com/gin-gonic/gin"
+	"github.com/Vimp17/pr-reviewer-service/internal/config"
+	"github.com/Vimp17/pr-reviewer-service/internal/logging"
 )
 
+// command подкоманда CLI
+type command struct {
+	summary string
+	run     func(ctx context.Context, cfg config.Config, args []string) error
+}
+
+var commands = map[string]command{
+	"serve":     {"run HTTP and gRPC servers (default)", serve},
+	"migrate":   {"apply or inspect DB migrations: up, down, status, redo", migrate},
+	"integrity": {"report and optionally repair orphaned references", integrity},
+	"seed":      {"create demo teams, users and pull requests", seed},
+	"import":    {"import teams, users and pull requests from JSON, JSONL or CSV", importData},
+	"export":    {"export teams, users and pull requests as JSON, JSONL or CSV", exportData},
+	"org":       {"manage organizations: create with an initial admin token", organization},
+	"users":     {"manage users: deactivate", users},
+	"pr":        {"manage pull requests: reassign", pr},
+	"stats":     {"print assignment or latency statistics as JSON", stats},
+}
+
 func main() {
-	ctx := context.Background()
+	flag.Usage = usage
+	// Настройки: файл (-config или CONFIG_FILE), затем переменные окружения
+	configPath := flag.String("config", os.Getenv("CONFIG_FILE"), "path to YAML or TOML config file")
+	printConfig := flag.Bool("print-config", false, "print effective config with secrets redacted and exit")
+	flag.Parse()
 
-	// 1. Подключаемся к БД
-	dsn := os.Getenv("DB_CONN_STRING")
-	if dsn == "" {
-		log.Fatal("DB_CONN_STRING environment variable is required")
+	name, args := "serve", flag.Args()
+	if len(args) > 0 {
+		name, args = args[0], args[1:]
+	}
+	cmd, ok := commands[name]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n", name)
+		usage()
+		os.Exit(2)
 	}
 
-	storage, err := postgres.NewStorage(ctx, dsn)
+	cfg, err := config.Load(*configPath)
+	if err != nil && !errors.Is(err, config.ErrInvalid) {
+		fatal("Failed to load config", err)
+	}
+	// --print-config выводит прочитанные настройки и при ошибках проверки
+	if *printConfig {
+		if printErr := cfg.Print(os.Stdout); printErr != nil {
+			fatal("Failed to print config", printErr)
+		}
+	}
 	if err != nil {
-		log.Fatalf("Failed to connect to DB: %v", err)
+		fatal("Invalid config", err)
 	}
-	defer storage.Close()
-
-	// 2. Применяем миграции
-	if err := storage.ApplyMigrations(ctx); err != nil {
-		log.Fatalf("Failed to apply migrations: %v", err)
+	if *printConfig {
+		return
 	}
 
-	// 3. Инициализируем сервисы
-	prService := services.NewPRService(storage)
-	teamService := services.NewTeamService(storage)
-	userService := services.NewUserService(storage)
-
-	// 4. Настраиваем роутер
-	router := gin.Default()
-
-	// Создаем обработчики
-	h := handlers.NewHandlers(prService, teamService, userService)
-
-	// Регистрируем маршруты
-	h.SetupRoutes(router)
-
-	// 5. Запускаем сервер
-	server := &http.Server{
-		Addr:    ":8080",
-		Handler: router,
+	// Журнал в формате JSON; у административных команд — в stderr, чтобы не
+	// смешиваться с их выводом
+	var logOutput io.Writer = os.Stderr
+	if name == "serve" {
+		logOutput = os.Stdout
 	}
+	level, _ := logging.ParseLevel(cfg.Logging.Level)
+	slog.SetDefault(logging.New(logOutput, level))
 
-	// Graceful shutdown
-	go func() {
-		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatalf("Server failed: %v", err)
+	if err := cmd.run(context.Background(), cfg, args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			os.Exit(2)
 		}
-	}()
+		fatal(name+" failed", err)
+	}
+}
 
-	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-	<-quit
+func usage() {
+	out := flag.CommandLine.Output()
+	fmt.Fprintf(out, "Usage: %s [flags] [command] [command flags]\n\nCommands:\n", os.Args[0])
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(out, "  %-10s %s\n", name, commands[name].summary)
+	}
+	fmt.Fprintf(out, "\nFlags:\n")
+	flag.PrintDefaults()
+}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-	if err := server.Shutdown(ctx); err != nil {
-		log.Fatalf("Server shutdown failed: %v", err)
+// fatal пишет ошибку запуска в журнал и завершает процесс
+func fatal(msg string, err error) {
+	if err != nil {
+		slog.Error(msg, "error", err)
+	} else {
+		slog.Error(msg)
 	}
+	os.Exit(1)
 }
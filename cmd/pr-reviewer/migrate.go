@@ -0,0 +1,31 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Vimp17/pr-reviewer-service/internal/config"
+)
+
+// migrateCommands поддерживаемые команды goose
+var migrateCommands = map[string]bool{"up": true, "down": true, "status": true, "redo": true}
+
+// migrate применяет, откатывает или показывает миграции схемы
+func migrate(ctx context.Context, cfg config.Config, args []string) error {
+	fs := newFlagSet("migrate", "migrate up|down|status|redo")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 || !migrateCommands[fs.Arg(0)] {
+		fs.Usage()
+		return fmt.Errorf("expected one of up, down, status, redo")
+	}
+
+	storage, err := openStorage(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer storage.Close()
+
+	return storage.Migrate(ctx, fs.Arg(0))
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Vimp17/pr-reviewer-service/internal/config"
+	"github.com/Vimp17/pr-reviewer-service/internal/models"
+	"github.com/Vimp17/pr-reviewer-service/internal/services"
+)
+
+// seedTeams демонстрационные команды
+var seedTeams = []models.Team{
+	{TeamName: "backend", Members: []models.User{
+		{UserID: "u1", Username: "Alice", IsActive: true},
+		{UserID: "u2", Username: "Bob", IsActive: true},
+		{UserID: "u3", Username: "Carol", IsActive: true},
+		{UserID: "u4", Username: "Dave", IsActive: false},
+	}},
+	{TeamName: "frontend", Members: []models.User{
+		{UserID: "u5", Username: "Eve", IsActive: true},
+		{UserID: "u6", Username: "Frank", IsActive: true},
+		{UserID: "u7", Username: "Grace", IsActive: true},
+	}},
+}
+
+// seedPRs демонстрационные PR; ревьюеры назначаются по правилам организации
+var seedPRs = []struct {
+	pr     models.PullRequest
+	merged bool
+}{
+	{pr: models.PullRequest{PullRequestID: "pr-1001", PullRequestName: "Add search", AuthorID: "u1"}},
+	{pr: models.PullRequest{PullRequestID: "pr-1002", PullRequestName: "Fix login redirect", AuthorID: "u2"}, merged: true},
+	{pr: models.PullRequest{PullRequestID: "pr-1003", PullRequestName: "New dashboard", AuthorID: "u5"}},
+}
+
+// seed создает демонстрационные команды, пользователей и PR; существующие пропускаются
+func seed(ctx context.Context, cfg config.Config, args []string) error {
+	fs := newFlagSet("seed", "seed [-org id]")
+	org := orgFlag(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	a, err := openApp(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer a.Close()
+	ctx = adminContext(ctx, *org)
+
+	for _, team := range seedTeams {
+		_, err := a.teams.CreateTeam(ctx, team)
+		switch {
+		case errors.Is(err, services.ErrTeamExists):
+			fmt.Printf("team %s: exists, skipped\n", team.TeamName)
+		case err != nil:
+			return fmt.Errorf("team %s: %w", team.TeamName, err)
+		default:
+			fmt.Printf("team %s: created with %d members\n", team.TeamName, len(team.Members))
+		}
+	}
+
+	for _, item := range seedPRs {
+		created, err := a.prs.CreatePR(ctx, item.pr)
+		if errors.Is(err, services.ErrPRExists) {
+			fmt.Printf("pull request %s: exists, skipped\n", item.pr.PullRequestID)
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("pull request %s: %w", item.pr.PullRequestID, err)
+		}
+		if item.merged {
+			if created, err = a.prs.MergePR(ctx, created.PullRequestID); err != nil {
+				return fmt.Errorf("pull request %s: %w", item.pr.PullRequestID, err)
+			}
+		}
+		fmt.Printf("pull request %s: %s, reviewers %v\n", created.PullRequestID, created.Status, created.AssignedReviewers)
+	}
+	return nil
+}
@@ -0,0 +1,228 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/Vimp17/pr-reviewer-service/internal/auth"
+	"github.com/Vimp17/pr-reviewer-service/internal/config"
+	"github.com/Vimp17/pr-reviewer-service/internal/grpcapi"
+	"github.com/Vimp17/pr-reviewer-service/internal/handlers"
+	"github.com/Vimp17/pr-reviewer-service/internal/health"
+	"github.com/Vimp17/pr-reviewer-service/internal/metrics"
+	"github.com/Vimp17/pr-reviewer-service/internal/services"
+	"github.com/Vimp17/pr-reviewer-service/internal/storage/postgres"
+	"github.com/Vimp17/pr-reviewer-service/internal/tenant"
+	"github.com/Vimp17/pr-reviewer-service/internal/tracing"
+	"github.com/gin-gonic/gin"
+)
+
+// serve запускает HTTP- и gRPC-серверы до сигнала SIGINT или SIGTERM
+func serve(ctx context.Context, cfg config.Config, args []string) error {
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	// Трассировка OpenTelemetry
+	shutdownTracing, err := tracing.Setup(ctx, tracing.Config{
+		Exporter:    cfg.Tracing.Exporter,
+		File:        cfg.Tracing.File,
+		ServiceName: cfg.Tracing.ServiceName,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to configure tracing: %w", err)
+	}
+
+	// 1. Подключаемся к БД
+	storage, err := openStorage(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer storage.Close()
+
+	// 2. Применяем миграции, если они не вынесены в отдельный шаг migrate up
+	if cfg.Database.AutoMigrate {
+		if err := storage.ApplyMigrations(ctx); err != nil {
+			return err
+		}
+	}
+
+	// Метрики пула соединений и открытых ревью собираются при каждом запросе /metrics
+	metrics.Registry.MustRegister(
+		metrics.NewPoolCollector(storage.PoolStat),
+		metrics.NewOpenReviewsCollector(storage.GetOpenReviewLoad),
+	)
+
+	// 3. Инициализируем сервисы
+	prService := services.NewPRService(storage)
+	teamService := services.NewTeamService(storage)
+	userService := services.NewUserService(storage)
+	orgService := services.NewOrgService(storage, orgDefaults(cfg))
+	authService := services.NewAuthService(storage)
+	auditService := services.NewAuditService(storage)
+	idempotencyService := services.NewIdempotencyService(storage, time.Duration(cfg.Idempotency.TTL))
+	snapshotService := services.NewSnapshotService(storage)
+
+	// Регистрируем токен администратора для первоначальной настройки
+	if token := cfg.Auth.BootstrapAdminToken; token != "" {
+		if err := authService.EnsureBootstrapToken(ctx, token); err != nil {
+			return fmt.Errorf("failed to register bootstrap token: %w", err)
+		}
+	}
+
+	// 4. Настраиваем роутер
+	router := gin.New()
+
+	// Создаем обработчики
+	h := handlers.NewHandlers(prService, teamService, userService, orgService, authService, auditService, idempotencyService, snapshotService)
+	h.UseWriteTimeout(time.Duration(cfg.HTTP.WriteTimeout))
+
+	// Выбираем способ аутентификации: apikey, jwt или both
+	var authenticator auth.Authenticator = authService
+	if mode := cfg.Auth.Mode; mode == "jwt" || mode == "both" {
+		jwtAuth, err := auth.NewJWTAuthenticator(ctx, auth.JWTConfig{
+			JWKSSource:   cfg.Auth.JWT.JWKS,
+			Issuer:       cfg.Auth.JWT.Issuer,
+			Audience:     cfg.Auth.JWT.Audience,
+			UserClaim:    cfg.Auth.JWT.UserClaim,
+			RolesClaim:   cfg.Auth.JWT.RolesClaim,
+			OrgClaim:     cfg.Auth.JWT.OrgClaim,
+			DefaultOrgID: tenant.DefaultOrgID,
+			OrgExists:    orgService.OrganizationExists,
+			Leeway:       time.Duration(cfg.Auth.JWT.Leeway),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to configure JWT authentication: %w", err)
+		}
+		if mode == "jwt" {
+			authenticator = jwtAuth
+		} else {
+			authenticator = auth.Chain{authService, jwtAuth}
+		}
+	}
+	h.UseAuthenticator(authenticator)
+
+	// Сверка запросов и ответов со спецификацией OpenAPI (для стендов и отладки)
+	if cfg.OpenAPIValidate {
+		h.EnableOpenAPIValidation()
+	}
+
+	// Регистрируем маршруты
+	h.SetupRoutes(router)
+
+	// 5. Запускаем сервер
+	server := &http.Server{
+		Addr:              cfg.HTTP.Addr,
+		Handler:           router,
+		ReadHeaderTimeout: time.Duration(cfg.HTTP.ReadHeaderTimeout),
+		ReadTimeout:       time.Duration(cfg.HTTP.ReadTimeout),
+		WriteTimeout:      time.Duration(cfg.HTTP.WriteTimeout),
+		IdleTimeout:       time.Duration(cfg.HTTP.IdleTimeout),
+	}
+
+	// Периодически удаляем просроченные ключи идемпотентности
+	purgeInterval := time.Duration(cfg.Idempotency.PurgeInterval)
+	purgeWorker := health.NewWorker(purgeInterval)
+	jobsCtx, stopJobs := context.WithCancel(ctx)
+	defer stopJobs()
+	go func(ctx context.Context) {
+		purgeWorker.Start()
+		ticker := time.NewTicker(purgeInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			_, err := idempotencyService.PurgeExpired(ctx)
+			if err != nil {
+				slog.Error("Failed to purge expired idempotency keys", "error", err)
+			}
+			metrics.JobRun("idempotency_purge", err)
+			purgeWorker.Done(err)
+		}
+	}(jobsCtx)
+
+	// /readyz проверяет пул соединений, версию схемы и фоновую задачу
+	expectedVersion, err := postgres.LatestMigrationVersion()
+	if err != nil {
+		return err
+	}
+	readiness := health.NewChecker(health.DefaultTimeout)
+	readiness.Add("postgres", health.Ping(storage.Ping))
+	readiness.Add("migrations", health.Migrations(storage.MigrationVersion, expectedVersion))
+	readiness.Add("idempotency_purge", purgeWorker.Check)
+	h.UseReadinessChecker(readiness)
+
+	// gRPC API на отдельном порту
+	grpcListener, err := net.Listen("tcp", cfg.GRPC.Addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", cfg.GRPC.Addr, err)
+	}
+	grpcServer := grpcapi.NewGRPCServer(grpcapi.NewServer(prService, teamService, userService), authenticator)
+
+	// Ошибки серверов приходят в основной цикл, чтобы остановка всегда шла
+	// общим путем: фоновые задачи, второй сервер, сброс трасс
+	serveErrs := make(chan error, 2)
+	go func() {
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			serveErrs <- fmt.Errorf("server failed: %w", err)
+		}
+	}()
+	go func() {
+		if err := grpcServer.Serve(grpcListener); err != nil {
+			serveErrs <- fmt.Errorf("gRPC server failed: %w", err)
+		}
+	}()
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(quit)
+
+	var serveErr error
+	select {
+	case <-quit:
+	case serveErr = <-serveErrs:
+		slog.Error("Server stopped, shutting down", "error", serveErr)
+	}
+	stopJobs()
+
+	// Graceful shutdown: HTTP и gRPC завершают текущие запросы параллельно
+	// в пределах shutdown_timeout, после него соединения закрываются
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(cfg.HTTP.ShutdownTimeout))
+	defer cancel()
+	grpcStopped := make(chan struct{})
+	go func() {
+		grpcServer.GracefulStop()
+		close(grpcStopped)
+	}()
+
+	var shutdownErr error
+	if err := server.Shutdown(ctx); err != nil {
+		shutdownErr = fmt.Errorf("server shutdown failed: %w", err)
+		server.Close()
+	}
+	select {
+	case <-grpcStopped:
+	case <-ctx.Done():
+		slog.Warn("gRPC graceful stop timed out, closing connections")
+		grpcServer.Stop()
+		<-grpcStopped
+	}
+
+	if err := shutdownTracing(ctx); err != nil {
+		slog.Error("Failed to flush traces", "error", err)
+	}
+	return errors.Join(serveErr, shutdownErr)
+}
@@ -0,0 +1,87 @@
+// Package apierror описывает ошибки API одной таблицей: код ответа, ошибку
+// сервиса, HTTP-статус, код gRPC и сообщение по умолчанию. По ней строятся
+// ответы REST (v1 и v2) и статусы gRPC, поэтому новый код достаточно добавить
+// сюда и в перечисление ErrorCode спецификации OpenAPI.
+package apierror
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/Vimp17/pr-reviewer-service/internal/auth"
+	"github.com/Vimp17/pr-reviewer-service/internal/services"
+	"google.golang.org/grpc/codes"
+)
+
+// Kind описание ошибки API
+type Kind struct {
+	Code string
+	// Err ошибка сервиса с этим кодом; nil — код выдают только обработчики
+	Err     error
+	HTTP    int
+	GRPC    codes.Code
+	Message string
+}
+
+// Internal внутренняя ошибка; ей же отвечают на неизвестные ошибки и коды
+var Internal = Kind{"INTERNAL_ERROR", nil, http.StatusInternalServerError, codes.Internal, "internal server error"}
+
+var kinds = []Kind{
+	{"INVALID_REQUEST", nil, http.StatusBadRequest, codes.InvalidArgument, "invalid request"},
+	{"TEAM_NAME_REQUIRED", services.ErrTeamNameRequired, http.StatusBadRequest, codes.InvalidArgument, "team_name is required"},
+	{"MEMBERS_REQUIRED", services.ErrMembersRequired, http.StatusBadRequest, codes.InvalidArgument, "at least one member is required"},
+	{"INVALID_VERDICT", services.ErrInvalidVerdict, http.StatusBadRequest, codes.InvalidArgument, "verdict must be APPROVED or CHANGES_REQUESTED"},
+	{"INVALID_ROLE", services.ErrInvalidRole, http.StatusBadRequest, codes.InvalidArgument, "role must be one of admin, team-lead, member, bot"},
+	{"INVALID_CURSOR", services.ErrInvalidCursor, http.StatusBadRequest, codes.InvalidArgument, "cursor is malformed or was issued for another sort"},
+	{"INVALID_SORT", services.ErrInvalidSort, http.StatusBadRequest, codes.InvalidArgument, "unsupported sort field"},
+	{"INVALID_SETTINGS", services.ErrInvalidSettings, http.StatusBadRequest, codes.InvalidArgument, "reviewers_per_pr must be 0..2, assignment_strategy random or least_loaded"},
+	{"UNAUTHENTICATED", auth.ErrUnauthenticated, http.StatusUnauthorized, codes.Unauthenticated, "missing or invalid credentials"},
+	{"FORBIDDEN", services.ErrForbidden, http.StatusForbidden, codes.PermissionDenied, "operation is not permitted for caller"},
+	{"NOT_FOUND", services.ErrNotFound, http.StatusNotFound, codes.NotFound, "resource not found"},
+	{"AUTHOR_NOT_FOUND", services.ErrAuthorNotFound, http.StatusNotFound, codes.NotFound, "author not found"},
+	{"USER_NOT_FOUND", services.ErrUserNotFound, http.StatusNotFound, codes.NotFound, "user not found"},
+	{"ORG_NOT_FOUND", services.ErrOrgNotFound, http.StatusNotFound, codes.NotFound, "organization not found"},
+	{"PR_EXISTS", services.ErrPRExists, http.StatusConflict, codes.AlreadyExists, "PR id already exists"},
+	{"TEAM_EXISTS", services.ErrTeamExists, http.StatusConflict, codes.AlreadyExists, "team_name already exists"},
+	{"ORG_EXISTS", services.ErrOrgExists, http.StatusConflict, codes.AlreadyExists, "org_id already exists"},
+	{"PR_MERGED", services.ErrPRMerged, http.StatusConflict, codes.FailedPrecondition, "cannot modify merged PR"},
+	{"NOT_ASSIGNED", services.ErrNotAssigned, http.StatusConflict, codes.FailedPrecondition, "reviewer is not assigned to this PR"},
+	{"NO_CANDIDATE", services.ErrNoCandidate, http.StatusConflict, codes.FailedPrecondition, "no active replacement candidate in team"},
+	{"CONFLICT", services.ErrConflict, http.StatusConflict, codes.Aborted, "PR was modified concurrently, retry the request"},
+	{"IDEMPOTENCY_KEY_REUSED", services.ErrIdempotencyMismatch, http.StatusUnprocessableEntity, codes.InvalidArgument, "Idempotency-Key was already used with a different request"},
+	{"IDEMPOTENCY_IN_PROGRESS", services.ErrIdempotencyInProgress, http.StatusConflict, codes.Aborted, "request with this Idempotency-Key is still being processed"},
+	Internal,
+}
+
+var byCode = func() map[string]Kind {
+	m := make(map[string]Kind, len(kinds))
+	for _, k := range kinds {
+		m[k.Code] = k
+	}
+	return m
+}()
+
+// All возвращает все описания ошибок в порядке таблицы
+func All() []Kind {
+	return append([]Kind(nil), kinds...)
+}
+
+// ByCode возвращает описание по коду; неизвестный код — Internal и false
+func ByCode(code string) (Kind, bool) {
+	k, ok := byCode[code]
+	if !ok {
+		return Internal, false
+	}
+	return k, true
+}
+
+// FromError находит описание ошибки сервиса через errors.Is, поэтому обернутые
+// ошибки тоже распознаются; неизвестная ошибка — Internal и false
+func FromError(err error) (Kind, bool) {
+	for _, k := range kinds {
+		if k.Err != nil && errors.Is(err, k.Err) {
+			return k, true
+		}
+	}
+	return Internal, false
+}
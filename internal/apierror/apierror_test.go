@@ -0,0 +1,75 @@
+package apierror
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/Vimp17/pr-reviewer-service/internal/openapi"
+	"github.com/Vimp17/pr-reviewer-service/internal/services"
+	"google.golang.org/grpc/codes"
+)
+
+func TestFromError(t *testing.T) {
+	for _, k := range All() {
+		if k.Err == nil {
+			continue
+		}
+		// Текст ошибки сервиса совпадает с кодом ответа
+		if k.Err.Error() != k.Code {
+			t.Errorf("%s: service error text %q", k.Code, k.Err.Error())
+		}
+		for _, err := range []error{k.Err, fmt.Errorf("context: %w", k.Err)} {
+			got, ok := FromError(err)
+			if !ok || got.Code != k.Code {
+				t.Errorf("FromError(%v) = %s, %v; want %s", err, got.Code, ok, k.Code)
+			}
+		}
+	}
+
+	// Ошибка с тем же текстом, но не сервисная, считается внутренней
+	if got, ok := FromError(errors.New(services.ErrNotFound.Error())); ok || got.Code != Internal.Code {
+		t.Errorf("FromError(text NOT_FOUND) = %s, %v; want internal", got.Code, ok)
+	}
+	if got, ok := FromError(errors.New("connection refused")); ok || got.HTTP != http.StatusInternalServerError || got.GRPC != codes.Internal {
+		t.Errorf("FromError(unknown) = %+v, %v", got, ok)
+	}
+}
+
+func TestByCode(t *testing.T) {
+	if k, ok := ByCode("CONFLICT"); !ok || k.HTTP != http.StatusConflict || k.GRPC != codes.Aborted {
+		t.Errorf("ByCode(CONFLICT) = %+v, %v", k, ok)
+	}
+	if k, ok := ByCode("NO_SUCH_CODE"); ok || k.Code != Internal.Code {
+		t.Errorf("ByCode(NO_SUCH_CODE) = %+v, %v", k, ok)
+	}
+}
+
+// Перечисление ErrorCode спецификации совпадает с таблицей
+func TestSpecListsAllCodes(t *testing.T) {
+	spec, err := openapi.Spec()
+	if err != nil {
+		t.Fatal(err)
+	}
+	enum := map[string]bool{}
+	for _, v := range spec.Components.Schemas["ErrorCode"].Value.Enum {
+		enum[v.(string)] = true
+	}
+
+	seen := map[string]bool{}
+	for _, k := range All() {
+		if seen[k.Code] {
+			t.Errorf("%s: duplicate code", k.Code)
+		}
+		seen[k.Code] = true
+		if !enum[k.Code] {
+			t.Errorf("%s: missing from ErrorCode in OpenAPI spec", k.Code)
+		}
+	}
+	for code := range enum {
+		if !seen[code] {
+			t.Errorf("%s: listed in OpenAPI spec but not in apierror", code)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+)
+
+var (
+	ErrUnauthenticated = errors.New("UNAUTHENTICATED")
+)
+
+// Role роль вызывающего, ограничивающая доступные операции
+type Role string
+
+const (
+	RoleAdmin    Role = "admin"
+	RoleTeamLead Role = "team-lead"
+	RoleMember   Role = "member"
+	RoleBot      Role = "bot"
+)
+
+// ValidRole проверяет, что роль входит в список поддерживаемых
+func ValidRole(role Role) bool {
+	switch role {
+	case RoleAdmin, RoleTeamLead, RoleMember, RoleBot:
+		return true
+	}
+	return false
+}
+
+// Identity описывает аутентифицированного вызывающего
+type Identity struct {
+	OrgID  string
+	UserID string // пусто для токенов, не привязанных к пользователю
+	Name   string
+	Roles  []Role
+	// System отмечает внутреннего вызывающего (SystemIdentity), а не владельца токена
+	System bool
+}
+
+// HasRole проверяет, есть ли у вызывающего хотя бы одна из ролей
+func (i *Identity) HasRole(roles ...Role) bool {
+	for _, have := range i.Roles {
+		for _, want := range roles {
+			if have == want {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// SystemIdentity возвращает личность внутреннего вызывающего — административной
+// команды или фоновой задачи: администратор организации orgID, который
+// в журнале аудита и истории PR записывается как name
+func SystemIdentity(orgID, name string) *Identity {
+	return &Identity{OrgID: orgID, Name: name, Roles: []Role{RoleAdmin}, System: true}
+}
+
+// Actor возвращает строку, идентифицирующую вызывающего в журналах
+func (i *Identity) Actor() string {
+	if i.UserID != "" {
+		return i.UserID
+	}
+	return i.Name
+}
+
+// Authenticator проверяет предъявленный токен и возвращает личность вызывающего
+type Authenticator interface {
+	Authenticate(ctx context.Context, token string) (*Identity, error)
+}
+
+type ctxKey struct{}
+
+// WithIdentity возвращает контекст с личностью вызывающего
+func WithIdentity(ctx context.Context, identity *Identity) context.Context {
+	return context.WithValue(ctx, ctxKey{}, identity)
+}
+
+// FromContext возвращает личность вызывающего, если она есть в контексте
+func FromContext(ctx context.Context) (*Identity, bool) {
+	identity, ok := ctx.Value(ctxKey{}).(*Identity)
+	return identity, ok && identity != nil
+}
+
+// HashToken возвращает хэш токена, под которым он хранится в БД
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// GenerateToken создает новый случайный API-токен
+func GenerateToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return "prs_" + hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,23 @@
+package auth
+
+import (
+	"context"
+	"errors"
+)
+
+// Chain пробует аутентификаторы по очереди и возвращает первую успешную личность
+type Chain []Authenticator
+
+// Authenticate реализует Authenticator
+func (c Chain) Authenticate(ctx context.Context, token string) (*Identity, error) {
+	for _, authenticator := range c {
+		identity, err := authenticator.Authenticate(ctx, token)
+		if err == nil {
+			return identity, nil
+		}
+		if !errors.Is(err, ErrUnauthenticated) {
+			return nil, err
+		}
+	}
+	return nil, ErrUnauthenticated
+}
@@ -0,0 +1,410 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// JWTConfig настройки проверки JWT, выпущенных корпоративным SSO
+type JWTConfig struct {
+	// JWKSSource путь к файлу или http(s) URL с набором ключей JWKS
+	JWKSSource string
+	// Issuer и Audience проверяются, если заданы
+	Issuer   string
+	Audience string
+	// Имена claims, из которых берутся пользователь, роли и организация
+	UserClaim  string
+	RolesClaim string
+	OrgClaim   string
+	// DefaultOrgID используется, если в токене нет claim организации
+	DefaultOrgID string
+	// OrgExists, если задан, проверяет организацию из токена: SSO не знает
+	// организаций сервиса, и токен неизвестной организации отклоняется
+	OrgExists func(ctx context.Context, orgID string) (bool, error)
+	// Leeway допустимое расхождение часов при проверке exp/nbf
+	Leeway time.Duration
+}
+
+// JWTAuthenticator проверяет RS256/ES256 токены по набору ключей JWKS
+type JWTAuthenticator struct {
+	cfg    JWTConfig
+	client *http.Client
+
+	mu         sync.RWMutex
+	keys       map[string]crypto.PublicKey
+	loadedAt   time.Time // время последней попытки загрузки, в том числе неудачной
+	refreshGap time.Duration
+	reload     singleflight.Group
+}
+
+// NewJWTAuthenticator создает аутентификатор и загружает ключи из JWKSSource
+func NewJWTAuthenticator(ctx context.Context, cfg JWTConfig) (*JWTAuthenticator, error) {
+	if cfg.JWKSSource == "" {
+		return nil, errors.New("JWKS source is required")
+	}
+	if cfg.UserClaim == "" {
+		cfg.UserClaim = "sub"
+	}
+	if cfg.RolesClaim == "" {
+		cfg.RolesClaim = "roles"
+	}
+	if cfg.OrgClaim == "" {
+		cfg.OrgClaim = "org_id"
+	}
+
+	a := &JWTAuthenticator{
+		cfg:        cfg,
+		client:     &http.Client{Timeout: 10 * time.Second},
+		refreshGap: time.Minute,
+	}
+	if err := a.loadKeys(ctx); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// Authenticate проверяет подпись и claims токена и возвращает личность вызывающего
+func (a *JWTAuthenticator) Authenticate(ctx context.Context, token string) (*Identity, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrUnauthenticated
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, ErrUnauthenticated
+	}
+
+	key, err := a.key(ctx, header.Kid)
+	if err != nil {
+		return nil, err
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, ErrUnauthenticated
+	}
+	if !verifySignature(header.Alg, key, parts[0]+"."+parts[1], signature) {
+		return nil, ErrUnauthenticated
+	}
+
+	var claims map[string]any
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, ErrUnauthenticated
+	}
+	if err := a.validateClaims(claims); err != nil {
+		return nil, err
+	}
+
+	userID, _ := claims[a.cfg.UserClaim].(string)
+	if userID == "" {
+		return nil, ErrUnauthenticated
+	}
+	orgID, _ := claims[a.cfg.OrgClaim].(string)
+	if orgID == "" {
+		orgID = a.cfg.DefaultOrgID
+	}
+	if a.cfg.OrgExists != nil {
+		exists, err := a.cfg.OrgExists(ctx, orgID)
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			return nil, ErrUnauthenticated
+		}
+	}
+	name, _ := claims["name"].(string)
+	if name == "" {
+		name = userID
+	}
+
+	return &Identity{
+		OrgID:  orgID,
+		UserID: userID,
+		Name:   name,
+		Roles:  parseRoles(claims[a.cfg.RolesClaim]),
+	}, nil
+}
+
+// validateClaims проверяет срок действия, издателя и аудиторию
+func (a *JWTAuthenticator) validateClaims(claims map[string]any) error {
+	now := time.Now()
+
+	exp, ok := claims["exp"].(float64)
+	if !ok || now.After(time.Unix(int64(exp), 0).Add(a.cfg.Leeway)) {
+		return ErrUnauthenticated
+	}
+	if nbf, ok := claims["nbf"].(float64); ok && now.Add(a.cfg.Leeway).Before(time.Unix(int64(nbf), 0)) {
+		return ErrUnauthenticated
+	}
+	if a.cfg.Issuer != "" {
+		if iss, _ := claims["iss"].(string); iss != a.cfg.Issuer {
+			return ErrUnauthenticated
+		}
+	}
+	if a.cfg.Audience != "" && !audienceContains(claims["aud"], a.cfg.Audience) {
+		return ErrUnauthenticated
+	}
+	return nil
+}
+
+// key возвращает ключ по kid, перечитывая JWKS при неизвестном kid не чаще
+// refreshGap. Одновременные запросы с неизвестным kid перечитывают набор
+// один раз; если JWKS недоступен, остаются прежние ключи, а токен отклоняется.
+func (a *JWTAuthenticator) key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	if key, ok := a.lookup(kid); ok {
+		return key, nil
+	}
+	if !a.stale() {
+		return nil, ErrUnauthenticated
+	}
+
+	_, err, _ := a.reload.Do("jwks", func() (any, error) {
+		// Пока запрос ждал, набор мог перечитать другой вызов
+		if !a.stale() {
+			return nil, nil
+		}
+		// Загрузка общая для всех ожидающих и не прерывается отменой одного запроса
+		return nil, a.loadKeys(context.WithoutCancel(ctx))
+	})
+	if err != nil {
+		slog.WarnContext(ctx, "JWKS reload failed", "kid", kid, "error", err)
+		return nil, ErrUnauthenticated
+	}
+
+	if key, ok := a.lookup(kid); ok {
+		return key, nil
+	}
+	return nil, ErrUnauthenticated
+}
+
+// stale сообщает, что с последней попытки загрузки JWKS прошло не меньше refreshGap
+func (a *JWTAuthenticator) stale() bool {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return time.Since(a.loadedAt) >= a.refreshGap
+}
+
+func (a *JWTAuthenticator) lookup(kid string) (crypto.PublicKey, bool) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	// Токен без kid допустим, только если ключ в наборе единственный
+	if kid == "" && len(a.keys) == 1 {
+		for _, key := range a.keys {
+			return key, true
+		}
+	}
+	key, ok := a.keys[kid]
+	return key, ok
+}
+
+// loadKeys читает JWKS из файла или по URL. Время попытки запоминается и при
+// ошибке, чтобы недоступный источник не запрашивался на каждый токен.
+func (a *JWTAuthenticator) loadKeys(ctx context.Context) error {
+	defer func() {
+		a.mu.Lock()
+		a.loadedAt = time.Now()
+		a.mu.Unlock()
+	}()
+
+	var data []byte
+	var err error
+
+	source := a.cfg.JWKSSource
+	if strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://") {
+		data, err = a.fetch(ctx, source)
+	} else {
+		data, err = os.ReadFile(strings.TrimPrefix(source, "file://"))
+	}
+	if err != nil {
+		return fmt.Errorf("failed to load JWKS: %w", err)
+	}
+
+	keys, err := parseJWKS(data)
+	if err != nil {
+		return fmt.Errorf("failed to parse JWKS: %w", err)
+	}
+
+	a.mu.Lock()
+	a.keys = keys
+	a.mu.Unlock()
+	return nil
+}
+
+func (a *JWTAuthenticator) fetch(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+}
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	// RSA
+	N string `json:"n"`
+	E string `json:"e"`
+	// EC
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// parseJWKS разбирает набор ключей, пропуская неподдерживаемые и ключи шифрования
+func parseJWKS(data []byte) (map[string]crypto.PublicKey, error) {
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]crypto.PublicKey, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		switch k.Kty {
+		case "RSA":
+			n, err := decodeBigInt(k.N)
+			if err != nil {
+				return nil, fmt.Errorf("key %q: %w", k.Kid, err)
+			}
+			e, err := decodeBigInt(k.E)
+			if err != nil {
+				return nil, fmt.Errorf("key %q: %w", k.Kid, err)
+			}
+			keys[k.Kid] = &rsa.PublicKey{N: n, E: int(e.Int64())}
+		case "EC":
+			if k.Crv != "P-256" {
+				continue
+			}
+			x, err := decodeBigInt(k.X)
+			if err != nil {
+				return nil, fmt.Errorf("key %q: %w", k.Kid, err)
+			}
+			y, err := decodeBigInt(k.Y)
+			if err != nil {
+				return nil, fmt.Errorf("key %q: %w", k.Kid, err)
+			}
+			if !elliptic.P256().IsOnCurve(x, y) {
+				return nil, fmt.Errorf("key %q: point is not on curve", k.Kid)
+			}
+			keys[k.Kid] = &ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y}
+		}
+	}
+
+	if len(keys) == 0 {
+		return nil, errors.New("no usable signing keys")
+	}
+	return keys, nil
+}
+
+// verifySignature проверяет подпись; алгоритм должен соответствовать типу ключа
+func verifySignature(alg string, key crypto.PublicKey, signingInput string, signature []byte) bool {
+	digest := sha256.Sum256([]byte(signingInput))
+
+	switch alg {
+	case "RS256":
+		pub, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return false
+		}
+		return rsa.VerifyPKCS1v15(pub, crypto.SHA256, digest[:], signature) == nil
+	case "ES256":
+		pub, ok := key.(*ecdsa.PublicKey)
+		if !ok || len(signature) != 64 {
+			return false
+		}
+		r := new(big.Int).SetBytes(signature[:32])
+		s := new(big.Int).SetBytes(signature[32:])
+		return ecdsa.Verify(pub, digest[:], r, s)
+	}
+	return false
+}
+
+// parseRoles принимает роли массивом строк или строкой через пробел
+func parseRoles(value any) []Role {
+	var raw []string
+	switch v := value.(type) {
+	case string:
+		raw = strings.Fields(v)
+	case []any:
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				raw = append(raw, s)
+			}
+		}
+	}
+
+	roles := make([]Role, 0, len(raw))
+	for _, r := range raw {
+		if role := Role(r); ValidRole(role) {
+			roles = append(roles, role)
+		}
+	}
+	return roles
+}
+
+func audienceContains(aud any, want string) bool {
+	switch v := aud.(type) {
+	case string:
+		return v == want
+	case []any:
+		for _, item := range v {
+			if s, ok := item.(string); ok && s == want {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func decodeSegment(segment string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(data), nil
+}
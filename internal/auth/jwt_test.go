@@ -0,0 +1,386 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// signingKey закрытый ключ теста с kid для заголовка и JWKS
+type signingKey struct {
+	kid string
+	rsa *rsa.PrivateKey
+	ec  *ecdsa.PrivateKey
+}
+
+func newRSAKey(t *testing.T, kid string) signingKey {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signingKey{kid: kid, rsa: key}
+}
+
+func newECKey(t *testing.T, kid string) signingKey {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signingKey{kid: kid, ec: key}
+}
+
+func b64(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// jwks собирает набор открытых ключей
+func jwks(t *testing.T, keys ...signingKey) []byte {
+	t.Helper()
+	set := struct {
+		Keys []jwk `json:"keys"`
+	}{}
+	for _, k := range keys {
+		if k.rsa != nil {
+			set.Keys = append(set.Keys, jwk{
+				Kty: "RSA", Kid: k.kid, Use: "sig",
+				N: b64(k.rsa.N.Bytes()),
+				E: b64(big.NewInt(int64(k.rsa.E)).Bytes()),
+			})
+			continue
+		}
+		set.Keys = append(set.Keys, jwk{
+			Kty: "EC", Kid: k.kid, Crv: "P-256",
+			X: b64(k.ec.X.FillBytes(make([]byte, 32))),
+			Y: b64(k.ec.Y.FillBytes(make([]byte, 32))),
+		})
+	}
+	data, err := json.Marshal(set)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+// sign выпускает токен; alg пустой — по типу ключа
+func sign(t *testing.T, key signingKey, alg string, claims map[string]any) string {
+	t.Helper()
+	if alg == "" {
+		alg = "RS256"
+		if key.ec != nil {
+			alg = "ES256"
+		}
+	}
+	header, _ := json.Marshal(map[string]string{"alg": alg, "kid": key.kid, "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	input := b64(header) + "." + b64(payload)
+	digest := sha256.Sum256([]byte(input))
+
+	var signature []byte
+	if key.rsa != nil {
+		var err error
+		if signature, err = rsa.SignPKCS1v15(rand.Reader, key.rsa, crypto.SHA256, digest[:]); err != nil {
+			t.Fatal(err)
+		}
+	} else {
+		r, s, err := ecdsa.Sign(rand.Reader, key.ec, digest[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		signature = append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+	}
+	return input + "." + b64(signature)
+}
+
+func validClaims() map[string]any {
+	return map[string]any{
+		"sub":    "u1",
+		"name":   "Alice",
+		"iss":    "https://sso.example.test",
+		"aud":    []any{"pr-reviewer"},
+		"exp":    time.Now().Add(time.Hour).Unix(),
+		"roles":  []any{"team-lead", "unknown"},
+		"org_id": "acme",
+	}
+}
+
+func testConfig(source string) JWTConfig {
+	return JWTConfig{
+		JWKSSource:   source,
+		Issuer:       "https://sso.example.test",
+		Audience:     "pr-reviewer",
+		DefaultOrgID: "default",
+		Leeway:       5 * time.Second,
+	}
+}
+
+func writeJWKS(t *testing.T, data []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// jwksServer отдает текущий набор ключей и считает запросы
+type jwksServer struct {
+	*httptest.Server
+	mu       sync.Mutex
+	body     []byte
+	status   int
+	requests atomic.Int32
+}
+
+func newJWKSServer(t *testing.T, body []byte) *jwksServer {
+	t.Helper()
+	s := &jwksServer{body: body, status: http.StatusOK}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.requests.Add(1)
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		w.WriteHeader(s.status)
+		w.Write(s.body)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *jwksServer) serve(status int, body []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.status, s.body = status, body
+}
+
+func TestJWTAuthenticateFromFile(t *testing.T) {
+	rsaKey := newRSAKey(t, "rsa-1")
+	ecKey := newECKey(t, "ec-1")
+	a, err := NewJWTAuthenticator(context.Background(), testConfig("file://"+writeJWKS(t, jwks(t, rsaKey, ecKey))))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, key := range []signingKey{rsaKey, ecKey} {
+		identity, err := a.Authenticate(context.Background(), sign(t, key, "", validClaims()))
+		if err != nil {
+			t.Fatalf("%s: %v", key.kid, err)
+		}
+		want := Identity{OrgID: "acme", UserID: "u1", Name: "Alice", Roles: []Role{RoleTeamLead}}
+		if identity.OrgID != want.OrgID || identity.UserID != want.UserID || identity.Name != want.Name ||
+			len(identity.Roles) != 1 || identity.Roles[0] != RoleTeamLead {
+			t.Errorf("%s: identity %+v, want %+v", key.kid, identity, want)
+		}
+	}
+
+	// Без claim организации используется организация по умолчанию
+	claims := validClaims()
+	delete(claims, "org_id")
+	identity, err := a.Authenticate(context.Background(), sign(t, rsaKey, "", claims))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if identity.OrgID != "default" {
+		t.Errorf("org without claim = %q, want default", identity.OrgID)
+	}
+}
+
+func TestJWTRejectsInvalidTokens(t *testing.T) {
+	rsaKey := newRSAKey(t, "rsa-1")
+	ecKey := newECKey(t, "ec-1")
+	other := newRSAKey(t, "rsa-1") // тот же kid, другой ключ
+	a, err := NewJWTAuthenticator(context.Background(), testConfig(writeJWKS(t, jwks(t, rsaKey, ecKey))))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	with := func(key string, value any) map[string]any {
+		claims := validClaims()
+		if value == nil {
+			delete(claims, key)
+		} else {
+			claims[key] = value
+		}
+		return claims
+	}
+	tokens := map[string]string{
+		"alg none":         sign(t, rsaKey, "none", validClaims()),
+		"alg HS256":        sign(t, rsaKey, "HS256", validClaims()),
+		"RS256 on EC key":  sign(t, signingKey{kid: "ec-1", rsa: rsaKey.rsa}, "RS256", validClaims()),
+		"ES256 on RSA key": sign(t, signingKey{kid: "rsa-1", ec: ecKey.ec}, "ES256", validClaims()),
+		"wrong signature":  sign(t, other, "", validClaims()),
+		"unknown kid":      sign(t, newRSAKey(t, "rsa-2"), "", validClaims()),
+		"expired":          sign(t, rsaKey, "", with("exp", time.Now().Add(-time.Minute).Unix())),
+		"no exp":           sign(t, rsaKey, "", with("exp", nil)),
+		"not yet valid":    sign(t, rsaKey, "", with("nbf", time.Now().Add(time.Minute).Unix())),
+		"wrong audience":   sign(t, rsaKey, "", with("aud", "another-service")),
+		"no audience":      sign(t, rsaKey, "", with("aud", nil)),
+		"wrong issuer":     sign(t, rsaKey, "", with("iss", "https://evil.example.test")),
+		"no subject":       sign(t, rsaKey, "", with("sub", nil)),
+		"malformed":        "not-a-jwt",
+	}
+
+	for name, token := range tokens {
+		if _, err := a.Authenticate(context.Background(), token); !errors.Is(err, ErrUnauthenticated) {
+			t.Errorf("%s: error %v, want %v", name, err, ErrUnauthenticated)
+		}
+	}
+
+	// Расхождение часов в пределах Leeway допустимо
+	token := sign(t, rsaKey, "", with("exp", time.Now().Add(-2*time.Second).Unix()))
+	if _, err := a.Authenticate(context.Background(), token); err != nil {
+		t.Errorf("expired within leeway: %v", err)
+	}
+}
+
+// Токен организации, которой нет в сервисе, отклоняется; ошибка проверки
+// передается вызывающему как есть
+func TestJWTRejectsUnknownOrg(t *testing.T) {
+	rsaKey := newRSAKey(t, "rsa-1")
+	cfg := testConfig(writeJWKS(t, jwks(t, rsaKey)))
+	lookupErr := errors.New("db is down")
+	var fail bool
+	cfg.OrgExists = func(ctx context.Context, orgID string) (bool, error) {
+		if fail {
+			return false, lookupErr
+		}
+		return orgID == "acme", nil
+	}
+	a, err := NewJWTAuthenticator(context.Background(), cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := a.Authenticate(context.Background(), sign(t, rsaKey, "", validClaims())); err != nil {
+		t.Errorf("known org: %v", err)
+	}
+	claims := validClaims()
+	claims["org_id"] = "ghost"
+	if _, err := a.Authenticate(context.Background(), sign(t, rsaKey, "", claims)); !errors.Is(err, ErrUnauthenticated) {
+		t.Errorf("unknown org: error %v, want %v", err, ErrUnauthenticated)
+	}
+	fail = true
+	if _, err := a.Authenticate(context.Background(), sign(t, rsaKey, "", validClaims())); !errors.Is(err, lookupErr) {
+		t.Errorf("failed lookup: error %v, want %v", err, lookupErr)
+	}
+}
+
+func TestJWTKeyRotationFromURL(t *testing.T) {
+	oldKey := newRSAKey(t, "2024")
+	newKey := newECKey(t, "2025")
+	server := newJWKSServer(t, jwks(t, oldKey))
+
+	a, err := NewJWTAuthenticator(context.Background(), testConfig(server.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := a.Authenticate(context.Background(), sign(t, oldKey, "", validClaims())); err != nil {
+		t.Fatalf("old key: %v", err)
+	}
+
+	// SSO публикует новый ключ; до истечения refreshGap набор не перечитывается
+	server.serve(http.StatusOK, jwks(t, oldKey, newKey))
+	if _, err := a.Authenticate(context.Background(), sign(t, newKey, "", validClaims())); !errors.Is(err, ErrUnauthenticated) {
+		t.Fatalf("new key before refresh gap: error %v, want %v", err, ErrUnauthenticated)
+	}
+	if got := server.requests.Load(); got != 1 {
+		t.Fatalf("JWKS requests = %d, want 1", got)
+	}
+
+	// После refreshGap неизвестный kid перечитывает набор
+	a.refreshGap = 0
+	if _, err := a.Authenticate(context.Background(), sign(t, newKey, "", validClaims())); err != nil {
+		t.Fatalf("new key after reload: %v", err)
+	}
+
+	// Старый ключ отозван: после перечитывания им подписанные токены отклоняются
+	server.serve(http.StatusOK, jwks(t, newKey))
+	a.refreshGap = 0
+	if _, err := a.Authenticate(context.Background(), sign(t, newRSAKey(t, "unknown"), "", validClaims())); !errors.Is(err, ErrUnauthenticated) {
+		t.Fatalf("unknown kid: error %v, want %v", err, ErrUnauthenticated)
+	}
+	if _, err := a.Authenticate(context.Background(), sign(t, oldKey, "", validClaims())); !errors.Is(err, ErrUnauthenticated) {
+		t.Fatalf("revoked key: error %v, want %v", err, ErrUnauthenticated)
+	}
+}
+
+func TestJWTReloadFailure(t *testing.T) {
+	key := newRSAKey(t, "2024")
+	server := newJWKSServer(t, jwks(t, key))
+	a, err := NewJWTAuthenticator(context.Background(), testConfig(server.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Источник недоступен: токен с неизвестным kid отклоняется как
+	// неаутентифицированный, а не внутренней ошибкой
+	server.serve(http.StatusInternalServerError, []byte("unavailable"))
+	a.refreshGap = time.Hour
+	a.loadedAt = time.Time{}
+	unknown := sign(t, newRSAKey(t, "2025"), "", validClaims())
+	for i := 0; i < 3; i++ {
+		if _, err := a.Authenticate(context.Background(), unknown); !errors.Is(err, ErrUnauthenticated) {
+			t.Fatalf("unknown kid with failing JWKS: error %v, want %v", err, ErrUnauthenticated)
+		}
+	}
+	// Неудачная попытка тоже сдвигает loadedAt: источник запрошен один раз
+	if got := server.requests.Load(); got != 2 {
+		t.Errorf("JWKS requests = %d, want 2 (initial load and one reload)", got)
+	}
+	// Прежние ключи продолжают работать
+	if _, err := a.Authenticate(context.Background(), sign(t, key, "", validClaims())); err != nil {
+		t.Errorf("known key after failed reload: %v", err)
+	}
+}
+
+func TestJWTConcurrentReloadIsShared(t *testing.T) {
+	oldKey := newRSAKey(t, "2024")
+	newKey := newRSAKey(t, "2025")
+	server := newJWKSServer(t, jwks(t, oldKey))
+	a, err := NewJWTAuthenticator(context.Background(), testConfig(server.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server.serve(http.StatusOK, jwks(t, oldKey, newKey))
+	a.loadedAt = time.Time{}
+	token := sign(t, newKey, "", validClaims())
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 50)
+	for i := 0; i < cap(errs); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := a.Authenticate(context.Background(), token)
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("concurrent authenticate: %v", err)
+		}
+	}
+	if got := server.requests.Load(); got != 2 {
+		t.Errorf("JWKS requests = %d, want 2 (initial load and one shared reload)", got)
+	}
+}
@@ -0,0 +1,312 @@
+// Package config загружает настройки сервиса из файла YAML или TOML
+// с переопределением через переменные окружения и проверяет их
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Vimp17/pr-reviewer-service/internal/logging"
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// ErrInvalid ошибка проверки настроек; сами настройки при этом прочитаны
+var ErrInvalid = errors.New("invalid config")
+
+// Duration длительность в формате time.ParseDuration ("30s", "1h")
+type Duration time.Duration
+
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+func (d *Duration) UnmarshalText(text []byte) error {
+	parsed, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// Config настройки сервиса. Тег env задает переменную окружения, которая
+// переопределяет значение из файла; значения с тегом secret скрываются в Redacted.
+type Config struct {
+	HTTP        HTTPConfig        `yaml:"http" toml:"http"`
+	GRPC        GRPCConfig        `yaml:"grpc" toml:"grpc"`
+	Database    DatabaseConfig    `yaml:"database" toml:"database"`
+	Assignment  AssignmentConfig  `yaml:"assignment" toml:"assignment"`
+	Auth        AuthConfig        `yaml:"auth" toml:"auth"`
+	Idempotency IdempotencyConfig `yaml:"idempotency" toml:"idempotency"`
+	Logging     LoggingConfig     `yaml:"logging" toml:"logging"`
+	Tracing     TracingConfig     `yaml:"tracing" toml:"tracing"`
+	Notifier    NotifierConfig    `yaml:"notifier" toml:"notifier"`
+	// OpenAPIValidate сверка запросов и ответов со спецификацией OpenAPI
+	OpenAPIValidate bool `yaml:"openapi_validate" toml:"openapi_validate" env:"OPENAPI_VALIDATE"`
+}
+
+// HTTPConfig настройки HTTP-сервера
+type HTTPConfig struct {
+	Addr              string   `yaml:"addr" toml:"addr" env:"HTTP_ADDR"`
+	ReadHeaderTimeout Duration `yaml:"read_header_timeout" toml:"read_header_timeout" env:"HTTP_READ_HEADER_TIMEOUT"`
+	ReadTimeout       Duration `yaml:"read_timeout" toml:"read_timeout" env:"HTTP_READ_TIMEOUT"`
+	WriteTimeout      Duration `yaml:"write_timeout" toml:"write_timeout" env:"HTTP_WRITE_TIMEOUT"`
+	IdleTimeout       Duration `yaml:"idle_timeout" toml:"idle_timeout" env:"HTTP_IDLE_TIMEOUT"`
+	// ShutdownTimeout время на завершение текущих HTTP- и gRPC-запросов при
+	// остановке; по его истечении соединения закрываются
+	ShutdownTimeout Duration `yaml:"shutdown_timeout" toml:"shutdown_timeout" env:"HTTP_SHUTDOWN_TIMEOUT"`
+}
+
+// GRPCConfig настройки gRPC-сервера
+type GRPCConfig struct {
+	Addr string `yaml:"addr" toml:"addr" env:"GRPC_ADDR"`
+}
+
+// DatabaseConfig подключение к PostgreSQL и пул соединений
+type DatabaseConfig struct {
+	DSN               string   `yaml:"dsn" toml:"dsn" env:"DB_CONN_STRING" secret:"dsn"`
+	MaxConns          int32    `yaml:"max_conns" toml:"max_conns" env:"DB_MAX_CONNS"`
+	MinConns          int32    `yaml:"min_conns" toml:"min_conns" env:"DB_MIN_CONNS"`
+	MaxConnLifetime   Duration `yaml:"max_conn_lifetime" toml:"max_conn_lifetime" env:"DB_MAX_CONN_LIFETIME"`
+	MaxConnIdleTime   Duration `yaml:"max_conn_idle_time" toml:"max_conn_idle_time" env:"DB_MAX_CONN_IDLE_TIME"`
+	HealthCheckPeriod Duration `yaml:"health_check_period" toml:"health_check_period" env:"DB_HEALTH_CHECK_PERIOD"`
+	// AutoMigrate применять миграции при запуске serve; false — только командой migrate up
+	AutoMigrate bool `yaml:"auto_migrate" toml:"auto_migrate" env:"DB_AUTO_MIGRATE"`
+}
+
+// AssignmentConfig настройки назначения ревьюеров для новых организаций
+type AssignmentConfig struct {
+	ReviewersPerPR int    `yaml:"reviewers_per_pr" toml:"reviewers_per_pr" env:"ASSIGNMENT_REVIEWERS_PER_PR"`
+	Strategy       string `yaml:"strategy" toml:"strategy" env:"ASSIGNMENT_STRATEGY"`
+}
+
+// AuthConfig настройки аутентификации
+type AuthConfig struct {
+	// Mode: apikey (API-токены), jwt или both
+	Mode string `yaml:"mode" toml:"mode" env:"AUTH_MODE"`
+	// BootstrapAdminToken токен администратора для первоначальной настройки
+	BootstrapAdminToken string    `yaml:"bootstrap_admin_token" toml:"bootstrap_admin_token" env:"BOOTSTRAP_ADMIN_TOKEN" secret:"true"`
+	JWT                 JWTConfig `yaml:"jwt" toml:"jwt"`
+}
+
+// JWTConfig проверка JWT корпоративного SSO (см. auth.JWTConfig)
+type JWTConfig struct {
+	JWKS       string   `yaml:"jwks" toml:"jwks" env:"JWT_JWKS"`
+	Issuer     string   `yaml:"issuer" toml:"issuer" env:"JWT_ISSUER"`
+	Audience   string   `yaml:"audience" toml:"audience" env:"JWT_AUDIENCE"`
+	UserClaim  string   `yaml:"user_claim" toml:"user_claim" env:"JWT_USER_CLAIM"`
+	RolesClaim string   `yaml:"roles_claim" toml:"roles_claim" env:"JWT_ROLES_CLAIM"`
+	OrgClaim   string   `yaml:"org_claim" toml:"org_claim" env:"JWT_ORG_CLAIM"`
+	Leeway     Duration `yaml:"leeway" toml:"leeway" env:"JWT_LEEWAY"`
+}
+
+// IdempotencyConfig хранение ответов для Idempotency-Key
+type IdempotencyConfig struct {
+	TTL           Duration `yaml:"ttl" toml:"ttl" env:"IDEMPOTENCY_TTL"`
+	PurgeInterval Duration `yaml:"purge_interval" toml:"purge_interval" env:"IDEMPOTENCY_PURGE_INTERVAL"`
+}
+
+// LoggingConfig настройки журнала
+type LoggingConfig struct {
+	// Level: debug, info, warn или error
+	Level string `yaml:"level" toml:"level" env:"LOG_LEVEL"`
+}
+
+// TracingConfig настройки трассировки OpenTelemetry
+type TracingConfig struct {
+	// Exporter: none, otlp или stdout
+	Exporter    string `yaml:"exporter" toml:"exporter" env:"TRACING_EXPORTER"`
+	File        string `yaml:"file" toml:"file" env:"TRACING_FILE"`
+	ServiceName string `yaml:"service_name" toml:"service_name" env:"TRACING_SERVICE_NAME"`
+}
+
+// NotifierConfig уведомления о назначениях ревьюеров и слиянии PR через вебхук
+type NotifierConfig struct {
+	// Enabled включает уведомления; при false webhook_url не обязателен
+	Enabled bool `yaml:"enabled" toml:"enabled" env:"NOTIFIER_ENABLED"`
+	// WebhookURL адрес http(s), куда отправляются события; часто содержит токен
+	WebhookURL string `yaml:"webhook_url" toml:"webhook_url" env:"NOTIFIER_WEBHOOK_URL" secret:"true"`
+	// Secret ключ HMAC-подписи тела запроса; пустой — без подписи
+	Secret     string   `yaml:"secret" toml:"secret" env:"NOTIFIER_SECRET" secret:"true"`
+	Timeout    Duration `yaml:"timeout" toml:"timeout" env:"NOTIFIER_TIMEOUT"`
+	MaxRetries int      `yaml:"max_retries" toml:"max_retries" env:"NOTIFIER_MAX_RETRIES"`
+}
+
+// Default возвращает настройки по умолчанию
+func Default() Config {
+	return Config{
+		HTTP: HTTPConfig{
+			Addr:              ":8080",
+			ReadHeaderTimeout: Duration(10 * time.Second),
+			ReadTimeout:       Duration(30 * time.Second),
+			WriteTimeout:      Duration(60 * time.Second),
+			IdleTimeout:       Duration(2 * time.Minute),
+			ShutdownTimeout:   Duration(5 * time.Second),
+		},
+		GRPC: GRPCConfig{Addr: ":9090"},
+		Database: DatabaseConfig{
+			MaxConns:          25,
+			MaxConnLifetime:   Duration(time.Hour),
+			MaxConnIdleTime:   Duration(30 * time.Minute),
+			HealthCheckPeriod: Duration(5 * time.Minute),
+			AutoMigrate:       true,
+		},
+		Assignment: AssignmentConfig{
+			ReviewersPerPR: 2,
+			Strategy:       "random",
+		},
+		Auth: AuthConfig{
+			Mode: "apikey",
+			JWT:  JWTConfig{Leeway: Duration(30 * time.Second)},
+		},
+		Idempotency: IdempotencyConfig{
+			TTL:           Duration(24 * time.Hour),
+			PurgeInterval: Duration(time.Hour),
+		},
+		Logging: LoggingConfig{Level: "info"},
+		Tracing: TracingConfig{Exporter: "none", ServiceName: "pr-reviewer-service"},
+		Notifier: NotifierConfig{
+			Timeout:    Duration(5 * time.Second),
+			MaxRetries: 3,
+		},
+	}
+}
+
+// Load собирает настройки: значения по умолчанию, затем файл path (если задан;
+// формат по расширению .yaml, .yml или .toml), затем переменные окружения.
+// Неизвестные ключи файла и некорректные значения — ошибка.
+func Load(path string) (Config, error) {
+	cfg := Default()
+	if path != "" {
+		if err := loadFile(path, &cfg); err != nil {
+			return cfg, err
+		}
+	}
+	if err := applyEnv(&cfg, os.LookupEnv); err != nil {
+		return cfg, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return cfg, err
+	}
+	return cfg, nil
+}
+
+func loadFile(path string, cfg *Config) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config: %w", err)
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		// Пустой файл допустим: остаются значения по умолчанию
+		if err := decoder.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+			return fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+	case ".toml":
+		decoder := toml.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(cfg); err != nil {
+			return fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+	default:
+		return fmt.Errorf("unsupported config format %q: use .yaml, .yml or .toml", filepath.Ext(path))
+	}
+	return nil
+}
+
+// Validate проверяет согласованность настроек и возвращает все найденные ошибки
+func (c Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	check(c.HTTP.Addr != "", "http.addr is required")
+	check(c.HTTP.ReadHeaderTimeout >= 0, "http.read_header_timeout must not be negative")
+	check(c.HTTP.ReadTimeout >= 0, "http.read_timeout must not be negative")
+	check(c.HTTP.WriteTimeout >= 0, "http.write_timeout must not be negative")
+	check(c.HTTP.IdleTimeout >= 0, "http.idle_timeout must not be negative")
+	check(c.HTTP.ShutdownTimeout > 0, "http.shutdown_timeout must be positive")
+	check(c.GRPC.Addr != "", "grpc.addr is required")
+
+	check(c.Database.DSN != "", "database.dsn (DB_CONN_STRING) is required")
+	check(c.Database.MaxConns >= 1, "database.max_conns must be at least 1")
+	check(c.Database.MinConns >= 0 && c.Database.MinConns <= c.Database.MaxConns,
+		"database.min_conns must be between 0 and database.max_conns")
+	check(c.Database.MaxConnLifetime > 0, "database.max_conn_lifetime must be positive")
+	check(c.Database.MaxConnIdleTime > 0, "database.max_conn_idle_time must be positive")
+	check(c.Database.HealthCheckPeriod > 0, "database.health_check_period must be positive")
+
+	// Ограничение 0..2 совпадает с проверкой в БД (organizations.reviewers_per_pr)
+	check(c.Assignment.ReviewersPerPR >= 0 && c.Assignment.ReviewersPerPR <= 2,
+		"assignment.reviewers_per_pr must be between 0 and 2")
+	check(oneOf(c.Assignment.Strategy, "random", "least_loaded"),
+		"assignment.strategy must be random or least_loaded, got %q", c.Assignment.Strategy)
+
+	check(oneOf(c.Auth.Mode, "apikey", "jwt", "both"), "auth.mode must be apikey, jwt or both, got %q", c.Auth.Mode)
+	if c.Auth.Mode == "jwt" || c.Auth.Mode == "both" {
+		check(c.Auth.JWT.JWKS != "", "auth.jwt.jwks is required for auth.mode %s", c.Auth.Mode)
+	}
+	check(c.Auth.JWT.Leeway >= 0, "auth.jwt.leeway must not be negative")
+	check(!placeholderSecret(c.Auth.BootstrapAdminToken),
+		"auth.bootstrap_admin_token must not be a placeholder; generate one, e.g. openssl rand -hex 32")
+
+	check(c.Idempotency.TTL > 0, "idempotency.ttl must be positive")
+	check(c.Idempotency.PurgeInterval > 0, "idempotency.purge_interval must be positive")
+
+	_, err := logging.ParseLevel(c.Logging.Level)
+	check(err == nil, "logging.level must be debug, info, warn or error, got %q", c.Logging.Level)
+	check(oneOf(c.Tracing.Exporter, "none", "otlp", "stdout"),
+		"tracing.exporter must be none, otlp or stdout, got %q", c.Tracing.Exporter)
+	check(c.Tracing.ServiceName != "", "tracing.service_name is required")
+
+	check(c.Notifier.Timeout > 0, "notifier.timeout must be positive")
+	check(c.Notifier.MaxRetries >= 0 && c.Notifier.MaxRetries <= 10, "notifier.max_retries must be between 0 and 10")
+	check(!placeholderSecret(c.Notifier.Secret), "notifier.secret must not be a placeholder")
+	if c.Notifier.Enabled {
+		u, err := url.Parse(c.Notifier.WebhookURL)
+		check(err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "",
+			"notifier.webhook_url must be an http or https URL when notifier.enabled is true")
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("%w: %w", ErrInvalid, errors.Join(errs...))
+	}
+	return nil
+}
+
+// placeholderSecrets фрагменты значений-заглушек из примеров настроек
+var placeholderSecrets = []string{"change-me", "changeme", "change_me", "replace-me", "placeholder", "example"}
+
+// placeholderSecret сообщает, что секрет оставлен значением-заглушкой из примеров
+func placeholderSecret(value string) bool {
+	value = strings.ToLower(value)
+	for _, placeholder := range placeholderSecrets {
+		if strings.Contains(value, placeholder) {
+			return true
+		}
+	}
+	return false
+}
+
+func oneOf(value string, allowed ...string) bool {
+	for _, a := range allowed {
+		if value == a {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,205 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testDSN = "postgres://app:s3cr3t@db:5432/pr_reviewer?sslmode=disable"
+
+func writeConfig(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// validConfig настройки по умолчанию с обязательными значениями
+func validConfig() Config {
+	cfg := Default()
+	cfg.Database.DSN = testDSN
+	return cfg
+}
+
+func TestLoadFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		wantErr string
+		check   func(Config) bool
+	}{
+		{"yaml", "config.yaml", "http:\n  addr: \":8081\"\nnotifier:\n  enabled: true\n  webhook_url: https://hooks.local/x\n  timeout: 2s\n", "",
+			func(c Config) bool {
+				return c.HTTP.Addr == ":8081" && c.Notifier.Enabled && c.Notifier.Timeout == Duration(2*time.Second) &&
+					c.Notifier.MaxRetries == 3
+			}},
+		{"toml", "config.toml", "[database]\nmax_conns = 5\n\n[notifier]\nmax_retries = 0\n", "",
+			func(c Config) bool { return c.Database.MaxConns == 5 && c.Notifier.MaxRetries == 0 }},
+		{"empty yaml", "config.yml", "", "",
+			func(c Config) bool { return c.HTTP.Addr == Default().HTTP.Addr }},
+		{"unknown yaml key", "config.yaml", "notifier:\n  url: https://hooks.local\n", "field url not found", nil},
+		{"unknown toml key", "config.toml", "[http]\nport = 8080\n", "strict mode", nil},
+		{"invalid duration", "config.yaml", "notifier:\n  timeout: soon\n", "soon", nil},
+		{"unsupported format", "config.json", "{}", "unsupported config format", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := validConfig()
+			err := loadFile(writeConfig(t, tt.file, tt.content), &cfg)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error %v, want containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !tt.check(cfg) {
+				t.Errorf("loaded %+v", cfg)
+			}
+		})
+	}
+}
+
+// Переменная окружения важнее файла, файл важнее значения по умолчанию
+func TestEnvOverrides(t *testing.T) {
+	path := writeConfig(t, "config.yaml", "http:\n  addr: \":8081\"\n  idle_timeout: 1m\nnotifier:\n  max_retries: 5\n")
+	t.Setenv("DB_CONN_STRING", testDSN)
+	t.Setenv("HTTP_ADDR", ":9000")
+	t.Setenv("NOTIFIER_MAX_RETRIES", "1")
+	t.Setenv("NOTIFIER_ENABLED", "true")
+	t.Setenv("NOTIFIER_WEBHOOK_URL", "https://hooks.local/x")
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.HTTP.Addr != ":9000" || cfg.HTTP.IdleTimeout != Duration(time.Minute) || cfg.HTTP.ReadTimeout != Default().HTTP.ReadTimeout {
+		t.Errorf("http %+v", cfg.HTTP)
+	}
+	if !cfg.Notifier.Enabled || cfg.Notifier.MaxRetries != 1 || cfg.Notifier.WebhookURL != "https://hooks.local/x" {
+		t.Errorf("notifier %+v", cfg.Notifier)
+	}
+
+	tests := []struct {
+		name, value string
+	}{
+		{"NOTIFIER_TIMEOUT", "5"},
+		{"NOTIFIER_ENABLED", "maybe"},
+		{"DB_MAX_CONNS", "many"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := validConfig()
+			lookup := func(name string) (string, bool) { return tt.value, name == tt.name }
+			if err := applyEnv(&cfg, lookup); err == nil || !strings.Contains(err.Error(), tt.name) {
+				t.Errorf("error %v, want mentioning %s", err, tt.name)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		change  func(*Config)
+		wantErr string
+	}{
+		{"valid", func(*Config) {}, ""},
+		{"notifier disabled without url", func(c *Config) { c.Notifier.WebhookURL = "" }, ""},
+		{"missing dsn", func(c *Config) { c.Database.DSN = "" }, "database.dsn"},
+		{"min conns above max", func(c *Config) { c.Database.MinConns = 30 }, "database.min_conns"},
+		{"negative timeout", func(c *Config) { c.HTTP.ReadTimeout = Duration(-time.Second) }, "http.read_timeout"},
+		{"reviewers per pr", func(c *Config) { c.Assignment.ReviewersPerPR = 3 }, "assignment.reviewers_per_pr"},
+		{"strategy", func(c *Config) { c.Assignment.Strategy = "round_robin" }, "assignment.strategy"},
+		{"jwt without jwks", func(c *Config) { c.Auth.Mode = "jwt" }, "auth.jwt.jwks"},
+		{"placeholder token", func(c *Config) { c.Auth.BootstrapAdminToken = "change-me" }, "auth.bootstrap_admin_token"},
+		{"log level", func(c *Config) { c.Logging.Level = "trace" }, "logging.level"},
+		{"notifier without url", func(c *Config) { c.Notifier.Enabled = true }, "notifier.webhook_url"},
+		{"notifier url scheme", func(c *Config) {
+			c.Notifier.Enabled = true
+			c.Notifier.WebhookURL = "ftp://hooks.local"
+		}, "notifier.webhook_url"},
+		{"notifier timeout", func(c *Config) { c.Notifier.Timeout = 0 }, "notifier.timeout"},
+		{"notifier retries", func(c *Config) { c.Notifier.MaxRetries = 11 }, "notifier.max_retries"},
+		{"notifier placeholder secret", func(c *Config) { c.Notifier.Secret = "replace-me" }, "notifier.secret"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := validConfig()
+			tt.change(&cfg)
+			err := cfg.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrInvalid) || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error %v, want %v mentioning %s", err, ErrInvalid, tt.wantErr)
+			}
+		})
+	}
+}
+
+// Validate возвращает все ошибки сразу, а не первую
+func TestValidateJoinsErrors(t *testing.T) {
+	cfg := Default()
+	cfg.Notifier.Enabled = true
+	err := cfg.Validate()
+	for _, key := range []string{"database.dsn", "notifier.webhook_url"} {
+		if err == nil || !strings.Contains(err.Error(), key) {
+			t.Errorf("error %v, want mentioning %s", err, key)
+		}
+	}
+}
+
+// --print-config скрывает токены и адрес вебхука целиком, а в DSN — только пароль
+func TestPrintRedactsSecrets(t *testing.T) {
+	tests := []struct {
+		name   string
+		dsn    string
+		hidden []string
+		shown  []string
+	}{
+		{"url dsn", testDSN, []string{"s3cr3t"}, []string{"app:REDACTED@db:5432"}},
+		{"key value dsn", "host=db user=app password='s3 cr3t' dbname=pr", []string{"s3 cr3t"}, []string{"user=app password=REDACTED"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := validConfig()
+			cfg.Database.DSN = tt.dsn
+			cfg.Auth.BootstrapAdminToken = "bootstrap-token-value"
+			cfg.Notifier.WebhookURL = "https://hooks.local/services/T0/B0/webhook-token"
+			cfg.Notifier.Secret = "hmac-key-value"
+
+			var out bytes.Buffer
+			if err := cfg.Print(&out); err != nil {
+				t.Fatal(err)
+			}
+			printed := out.String()
+			for _, secret := range append(tt.hidden, "bootstrap-token-value", "webhook-token", "hmac-key-value") {
+				if strings.Contains(printed, secret) {
+					t.Errorf("printed config contains %q:\n%s", secret, printed)
+				}
+			}
+			for _, value := range append(tt.shown, "webhook_url: REDACTED", "secret: REDACTED", "max_retries: 3") {
+				if !strings.Contains(printed, value) {
+					t.Errorf("printed config lacks %q:\n%s", value, printed)
+				}
+			}
+			if cfg.Notifier.Secret != "hmac-key-value" {
+				t.Error("Print changed the original config")
+			}
+		})
+	}
+}
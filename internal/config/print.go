@@ -0,0 +1,69 @@
+package config
+
+import (
+	"io"
+	"net/url"
+	"reflect"
+	"regexp"
+
+	"gopkg.in/yaml.v3"
+)
+
+// redacted заменяет значения секретов при выводе настроек
+const redacted = "REDACTED"
+
+// dsnPassword пароль в DSN формата key=value
+var dsnPassword = regexp.MustCompile(`(password\s*=\s*)('(?:[^'\\]|\\.)*'|\S+)`)
+
+// Redacted возвращает копию настроек со скрытыми секретами: токены заменяются
+// целиком, в DSN скрывается только пароль
+func (c Config) Redacted() Config {
+	redactStruct(reflect.ValueOf(&c).Elem())
+	return c
+}
+
+func redactStruct(v reflect.Value) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := v.Field(i)
+		if field.Kind() == reflect.Struct {
+			redactStruct(field)
+			continue
+		}
+		if field.Kind() != reflect.String || field.String() == "" {
+			continue
+		}
+		switch t.Field(i).Tag.Get("secret") {
+		case "true":
+			field.SetString(redacted)
+		case "dsn":
+			field.SetString(redactDSN(field.String()))
+		}
+	}
+}
+
+// redactDSN скрывает пароль в DSN вида URL или key=value
+func redactDSN(dsn string) string {
+	if u, err := url.Parse(dsn); err == nil && u.Scheme != "" {
+		if _, ok := u.User.Password(); ok {
+			u.User = url.UserPassword(u.User.Username(), redacted)
+		}
+		query := u.Query()
+		if query.Has("password") {
+			query.Set("password", redacted)
+			u.RawQuery = query.Encode()
+		}
+		return u.String()
+	}
+	return dsnPassword.ReplaceAllString(dsn, "${1}"+redacted)
+}
+
+// Print выводит настройки в формате YAML со скрытыми секретами
+func (c Config) Print(w io.Writer) error {
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(c.Redacted()); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
@@ -0,0 +1,77 @@
+// Package dataformat читает и записывает выгрузку организации в форматах
+// JSON (один объект), JSONL (запись на строку) и CSV (общая таблица для всех
+// видов записей), а также построчно выводит списки и статистику в CSV и JSONL
+package dataformat
+
+import (
+	"errors"
+	"mime"
+	"path/filepath"
+	"strings"
+)
+
+// Форматы выгрузки
+const (
+	JSON  = "json"
+	JSONL = "jsonl"
+	CSV   = "csv"
+)
+
+// Типы содержимого форматов
+const (
+	MediaTypeJSON  = "application/json"
+	MediaTypeJSONL = "application/x-ndjson"
+	MediaTypeCSV   = "text/csv"
+)
+
+// ErrUnknownFormat формат не поддерживается
+var ErrUnknownFormat = errors.New("unknown data format")
+
+// Valid сообщает, поддерживается ли формат
+func Valid(format string) bool {
+	return format == JSON || format == JSONL || format == CSV
+}
+
+// ContentType возвращает тип содержимого формата для заголовка ответа
+func ContentType(format string) string {
+	switch format {
+	case JSONL:
+		return MediaTypeJSONL + "; charset=utf-8"
+	case CSV:
+		return MediaTypeCSV + "; charset=utf-8"
+	default:
+		return MediaTypeJSON + "; charset=utf-8"
+	}
+}
+
+// FromMediaType определяет формат по заголовку Content-Type или одному
+// элементу Accept; пустая строка, если тип не поддерживается
+func FromMediaType(value string) string {
+	mediaType, _, err := mime.ParseMediaType(value)
+	if err != nil {
+		return ""
+	}
+	switch mediaType {
+	case MediaTypeJSON:
+		return JSON
+	case MediaTypeJSONL, "application/jsonl", "application/jsonlines":
+		return JSONL
+	case MediaTypeCSV:
+		return CSV
+	}
+	return ""
+}
+
+// FromPath определяет формат по расширению файла (.json, .jsonl, .ndjson,
+// .csv); пустая строка, если расширение не известно
+func FromPath(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return JSON
+	case ".jsonl", ".ndjson":
+		return JSONL
+	case ".csv":
+		return CSV
+	}
+	return ""
+}
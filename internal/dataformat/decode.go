@@ -0,0 +1,294 @@
+package dataformat
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Vimp17/pr-reviewer-service/internal/models"
+)
+
+// maxLineSize предельная длина строки JSONL
+const maxLineSize = 1 << 20
+
+// Decode читает выгрузку в заданном формате. Ошибка синтаксиса возвращается
+// с местом во входных данных; содержимое записей здесь не проверяется.
+func Decode(r io.Reader, format string) (models.Snapshot, Positions, error) {
+	snapshot := models.Snapshot{
+		Teams:        []models.SnapshotTeam{},
+		Users:        []models.User{},
+		PullRequests: []models.PullRequest{},
+		PREvents:     []models.PREvent{},
+	}
+	positions := Positions{}
+
+	var err error
+	switch format {
+	case JSON:
+		err = decodeJSON(r, &snapshot, positions)
+	case JSONL:
+		err = decodeJSONL(r, &snapshot, positions)
+	case CSV:
+		err = decodeCSV(r, &snapshot, positions)
+	default:
+		err = ErrUnknownFormat
+	}
+	return snapshot, positions, err
+}
+
+func decodeJSON(r io.Reader, snapshot *models.Snapshot, positions Positions) error {
+	var data struct {
+		Settings     *settingsRecord     `json:"settings"`
+		Teams        []teamRecord        `json:"teams"`
+		Users        []userRecord        `json:"users"`
+		PullRequests []pullRequestRecord `json:"pull_requests"`
+		PREvents     []prEventRecord     `json:"pr_events"`
+	}
+	decoder := json.NewDecoder(r)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&data); err != nil {
+		return err
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return errors.New("unexpected data after snapshot object")
+	}
+
+	if data.Settings != nil {
+		snapshot.Settings = data.Settings.model()
+		positions.add(models.RecordSettings, "settings")
+	}
+	for i, record := range data.Teams {
+		snapshot.Teams = append(snapshot.Teams, record.model())
+		positions.add(models.RecordTeam, fmt.Sprintf("teams[%d]", i))
+	}
+	for i, record := range data.Users {
+		snapshot.Users = append(snapshot.Users, record.model())
+		positions.add(models.RecordUser, fmt.Sprintf("users[%d]", i))
+	}
+	for i, record := range data.PullRequests {
+		snapshot.PullRequests = append(snapshot.PullRequests, record.model())
+		positions.add(models.RecordPullRequest, fmt.Sprintf("pull_requests[%d]", i))
+	}
+	for i, record := range data.PREvents {
+		snapshot.PREvents = append(snapshot.PREvents, record.model())
+		positions.add(models.RecordPREvent, fmt.Sprintf("pr_events[%d]", i))
+	}
+	return nil
+}
+
+func decodeJSONL(r io.Reader, snapshot *models.Snapshot, positions Positions) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxLineSize)
+
+	for line := 1; scanner.Scan(); line++ {
+		data := bytes.TrimSpace(scanner.Bytes())
+		if len(data) == 0 {
+			continue
+		}
+		position := fmt.Sprintf("line %d", line)
+
+		var header struct {
+			Kind string `json:"kind"`
+		}
+		if err := json.Unmarshal(data, &header); err != nil {
+			return fmt.Errorf("%s: %w", position, err)
+		}
+
+		var err error
+		switch header.Kind {
+		case models.RecordSettings:
+			var record settingsRecord
+			if err = unmarshalStrict(data, &record); err == nil {
+				err = setSettings(snapshot, record)
+			}
+		case models.RecordTeam:
+			var record teamRecord
+			if err = unmarshalStrict(data, &record); err == nil {
+				snapshot.Teams = append(snapshot.Teams, record.model())
+			}
+		case models.RecordUser:
+			var record userRecord
+			if err = unmarshalStrict(data, &record); err == nil {
+				snapshot.Users = append(snapshot.Users, record.model())
+			}
+		case models.RecordPullRequest:
+			var record pullRequestRecord
+			if err = unmarshalStrict(data, &record); err == nil {
+				snapshot.PullRequests = append(snapshot.PullRequests, record.model())
+			}
+		case models.RecordPREvent:
+			var record prEventRecord
+			if err = unmarshalStrict(data, &record); err == nil {
+				snapshot.PREvents = append(snapshot.PREvents, record.model())
+			}
+		default:
+			err = unknownKind(header.Kind)
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", position, err)
+		}
+		positions.add(header.Kind, position)
+	}
+	return scanner.Err()
+}
+
+func unmarshalStrict(data []byte, v any) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	return decoder.Decode(v)
+}
+
+func decodeCSV(r io.Reader, snapshot *models.Snapshot, positions Positions) error {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return errors.New("missing CSV header")
+	}
+	if err != nil {
+		return err
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		name = strings.TrimSpace(name)
+		if !knownColumn(name) {
+			return fmt.Errorf("unknown CSV column %q", name)
+		}
+		columns[name] = i
+	}
+	if _, ok := columns["kind"]; !ok {
+		return errors.New("CSV header must include kind column")
+	}
+
+	for {
+		row, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		line, _ := reader.FieldPos(0)
+		position := fmt.Sprintf("line %d", line)
+		field := func(name string) string {
+			if i, ok := columns[name]; ok {
+				return strings.TrimSpace(row[i])
+			}
+			return ""
+		}
+
+		kind := field("kind")
+		switch kind {
+		case models.RecordSettings:
+			record := settingsRecord{AssignmentStrategy: field("assignment_strategy")}
+			value := field("reviewers_per_pr")
+			if record.ReviewersPerPR, err = strconv.Atoi(value); err != nil {
+				return fmt.Errorf("%s: invalid reviewers_per_pr %q", position, value)
+			}
+			if err := setSettings(snapshot, record); err != nil {
+				return fmt.Errorf("%s: %w", position, err)
+			}
+		case models.RecordTeam:
+			snapshot.Teams = append(snapshot.Teams, teamRecord{TeamName: field("team_name")}.model())
+		case models.RecordUser:
+			record := userRecord{
+				UserID:   field("user_id"),
+				Username: field("username"),
+				TeamName: field("team_name"),
+			}
+			if value := field("is_active"); value != "" {
+				active, err := strconv.ParseBool(value)
+				if err != nil {
+					return fmt.Errorf("%s: invalid is_active %q", position, value)
+				}
+				record.IsActive = &active
+			}
+			snapshot.Users = append(snapshot.Users, record.model())
+		case models.RecordPullRequest:
+			record := pullRequestRecord{
+				PullRequestID:     field("pull_request_id"),
+				PullRequestName:   field("pull_request_name"),
+				AuthorID:          field("author_id"),
+				Status:            field("status"),
+				AssignedReviewers: splitList(field("assigned_reviewers")),
+			}
+			if record.CreatedAt, err = parseTime(field("created_at")); err != nil {
+				return fmt.Errorf("%s: invalid created_at: %w", position, err)
+			}
+			if record.MergedAt, err = parseTime(field("merged_at")); err != nil {
+				return fmt.Errorf("%s: invalid merged_at: %w", position, err)
+			}
+			snapshot.PullRequests = append(snapshot.PullRequests, record.model())
+		case models.RecordPREvent:
+			record := prEventRecord{
+				PullRequestID: field("pull_request_id"),
+				EventType:     field("event_type"),
+				ReviewerID:    field("reviewer_id"),
+				OldReviewerID: field("old_reviewer_id"),
+				Verdict:       field("verdict"),
+				Actor:         field("actor"),
+				Reason:        field("reason"),
+			}
+			if record.CreatedAt, err = parseTime(field("created_at")); err != nil {
+				return fmt.Errorf("%s: invalid created_at: %w", position, err)
+			}
+			snapshot.PREvents = append(snapshot.PREvents, record.model())
+		default:
+			return fmt.Errorf("%s: %w", position, unknownKind(kind))
+		}
+		positions.add(kind, position)
+	}
+}
+
+func knownColumn(name string) bool {
+	for _, column := range csvColumns {
+		if column == name {
+			return true
+		}
+	}
+	return false
+}
+
+// splitList разбирает список ревьюеров, разделенных точкой с запятой
+func splitList(value string) []string {
+	items := []string{}
+	for _, item := range strings.Split(value, listSeparator) {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func parseTime(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// setSettings задает настройки выгрузки; в выгрузке одна запись настроек
+func setSettings(snapshot *models.Snapshot, record settingsRecord) error {
+	if snapshot.Settings != nil {
+		return errors.New("settings are listed more than once")
+	}
+	snapshot.Settings = record.model()
+	return nil
+}
+
+func unknownKind(kind string) error {
+	return fmt.Errorf("unknown record kind %q (expected %s, %s, %s, %s or %s)", kind,
+		models.RecordSettings, models.RecordTeam, models.RecordUser, models.RecordPullRequest, models.RecordPREvent)
+}
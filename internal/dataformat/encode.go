@@ -0,0 +1,166 @@
+package dataformat
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Vimp17/pr-reviewer-service/internal/models"
+)
+
+// csvColumns столбцы CSV в порядке вывода; запись заполняет только столбцы
+// своего вида, team_name пользователя — его команда, created_at события —
+// время события. Новые столбцы добавляются только в конец.
+var csvColumns = []string{
+	"kind",
+	"team_name",
+	"user_id",
+	"username",
+	"is_active",
+	"pull_request_id",
+	"pull_request_name",
+	"author_id",
+	"status",
+	"assigned_reviewers",
+	"created_at",
+	"merged_at",
+	"reviewers_per_pr",
+	"assignment_strategy",
+	"event_type",
+	"reviewer_id",
+	"old_reviewer_id",
+	"verdict",
+	"actor",
+	"reason",
+}
+
+// listSeparator разделяет ревьюеров в столбце assigned_reviewers
+const listSeparator = ";"
+
+// Encode записывает выгрузку в заданном формате: настройки, команды,
+// пользователи, PR, затем история PR, так что результат можно сразу загрузить
+// обратно
+func Encode(w io.Writer, format string, snapshot *models.Snapshot) error {
+	switch format {
+	case JSON:
+		return json.NewEncoder(w).Encode(snapshot)
+	case JSONL:
+		return encodeJSONL(w, snapshot)
+	case CSV:
+		return encodeCSV(w, snapshot)
+	}
+	return ErrUnknownFormat
+}
+
+func encodeJSONL(w io.Writer, snapshot *models.Snapshot) error {
+	encoder := json.NewEncoder(w)
+	if snapshot.Settings != nil {
+		record := struct {
+			Kind string `json:"kind"`
+			models.OrgSettings
+		}{models.RecordSettings, *snapshot.Settings}
+		if err := encoder.Encode(record); err != nil {
+			return err
+		}
+	}
+	for _, team := range snapshot.Teams {
+		record := struct {
+			Kind string `json:"kind"`
+			models.SnapshotTeam
+		}{models.RecordTeam, team}
+		if err := encoder.Encode(record); err != nil {
+			return err
+		}
+	}
+	for _, user := range snapshot.Users {
+		record := struct {
+			Kind string `json:"kind"`
+			models.User
+		}{models.RecordUser, user}
+		if err := encoder.Encode(record); err != nil {
+			return err
+		}
+	}
+	for _, pr := range snapshot.PullRequests {
+		record := struct {
+			Kind string `json:"kind"`
+			models.PullRequest
+		}{models.RecordPullRequest, pr}
+		if err := encoder.Encode(record); err != nil {
+			return err
+		}
+	}
+	for _, event := range snapshot.PREvents {
+		record := struct {
+			Kind string `json:"kind"`
+			models.PREvent
+		}{models.RecordPREvent, event}
+		if err := encoder.Encode(record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func encodeCSV(w io.Writer, snapshot *models.Snapshot) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(csvColumns); err != nil {
+		return err
+	}
+
+	row := make([]string, len(csvColumns))
+	if settings := snapshot.Settings; settings != nil {
+		clear(row)
+		row[0] = models.RecordSettings
+		row[12], row[13] = strconv.Itoa(settings.ReviewersPerPR), settings.AssignmentStrategy
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+	for _, team := range snapshot.Teams {
+		clear(row)
+		row[0], row[1] = models.RecordTeam, team.TeamName
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+	for _, user := range snapshot.Users {
+		clear(row)
+		row[0], row[1], row[2], row[3], row[4] = models.RecordUser, user.TeamName, user.UserID, user.Username, strconv.FormatBool(user.IsActive)
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+	for _, pr := range snapshot.PullRequests {
+		clear(row)
+		row[0] = models.RecordPullRequest
+		row[5], row[6], row[7], row[8] = pr.PullRequestID, pr.PullRequestName, pr.AuthorID, pr.Status
+		row[9] = strings.Join(pr.AssignedReviewers, listSeparator)
+		row[10], row[11] = formatTime(pr.CreatedAt), formatTime(pr.MergedAt)
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+	for _, event := range snapshot.PREvents {
+		clear(row)
+		row[0], row[5], row[10] = models.RecordPREvent, event.PullRequestID, formatTime(event.CreatedAt)
+		row[14], row[15], row[16], row[17] = event.EventType, event.ReviewerID, event.OldReviewerID, event.Verdict
+		row[18], row[19] = event.Actor, event.Reason
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format(time.RFC3339Nano)
+}
@@ -0,0 +1,115 @@
+package dataformat
+
+import (
+	"time"
+
+	"github.com/Vimp17/pr-reviewer-service/internal/models"
+)
+
+// Записи в том виде, в каком они приходят на вход. В JSONL и CSV каждая
+// запись несет свой вид в поле kind; is_active по умолчанию true.
+
+type settingsRecord struct {
+	Kind               string `json:"kind,omitempty"`
+	ReviewersPerPR     int    `json:"reviewers_per_pr"`
+	AssignmentStrategy string `json:"assignment_strategy"`
+}
+
+type teamRecord struct {
+	Kind     string `json:"kind,omitempty"`
+	TeamName string `json:"team_name"`
+}
+
+type userRecord struct {
+	Kind     string `json:"kind,omitempty"`
+	UserID   string `json:"user_id"`
+	Username string `json:"username"`
+	TeamName string `json:"team_name"`
+	IsActive *bool  `json:"is_active"`
+}
+
+type pullRequestRecord struct {
+	Kind              string     `json:"kind,omitempty"`
+	PullRequestID     string     `json:"pull_request_id"`
+	PullRequestName   string     `json:"pull_request_name"`
+	AuthorID          string     `json:"author_id"`
+	Status            string     `json:"status"`
+	AssignedReviewers []string   `json:"assigned_reviewers"`
+	Version           int        `json:"version"` // игнорируется: версию ведет сервис
+	CreatedAt         *time.Time `json:"createdAt"`
+	MergedAt          *time.Time `json:"mergedAt"`
+}
+
+type prEventRecord struct {
+	Kind          string     `json:"kind,omitempty"`
+	ID            int64      `json:"id"` // игнорируется: ID назначает сервис
+	PullRequestID string     `json:"pull_request_id"`
+	EventType     string     `json:"event_type"`
+	ReviewerID    string     `json:"reviewer_id"`
+	OldReviewerID string     `json:"old_reviewer_id"`
+	Verdict       string     `json:"verdict"`
+	Actor         string     `json:"actor"`
+	Reason        string     `json:"reason"`
+	CreatedAt     *time.Time `json:"createdAt"`
+}
+
+func (r settingsRecord) model() *models.OrgSettings {
+	return &models.OrgSettings{
+		ReviewersPerPR:     r.ReviewersPerPR,
+		AssignmentStrategy: r.AssignmentStrategy,
+	}
+}
+
+func (r teamRecord) model() models.SnapshotTeam {
+	return models.SnapshotTeam{TeamName: r.TeamName}
+}
+
+func (r userRecord) model() models.User {
+	return models.User{
+		UserID:   r.UserID,
+		Username: r.Username,
+		TeamName: r.TeamName,
+		IsActive: r.IsActive == nil || *r.IsActive,
+	}
+}
+
+func (r pullRequestRecord) model() models.PullRequest {
+	return models.PullRequest{
+		PullRequestID:     r.PullRequestID,
+		PullRequestName:   r.PullRequestName,
+		AuthorID:          r.AuthorID,
+		Status:            r.Status,
+		AssignedReviewers: r.AssignedReviewers,
+		CreatedAt:         r.CreatedAt,
+		MergedAt:          r.MergedAt,
+	}
+}
+
+func (r prEventRecord) model() models.PREvent {
+	return models.PREvent{
+		PullRequestID: r.PullRequestID,
+		EventType:     r.EventType,
+		ReviewerID:    r.ReviewerID,
+		OldReviewerID: r.OldReviewerID,
+		Verdict:       r.Verdict,
+		Actor:         r.Actor,
+		Reason:        r.Reason,
+		CreatedAt:     r.CreatedAt,
+	}
+}
+
+// Positions места записей во входных данных по видам, в порядке записей
+type Positions map[string][]string
+
+func (p Positions) add(kind, position string) {
+	p[kind] = append(p[kind], position)
+}
+
+// Locate дополняет ошибки проверки местом записи во входных данных
+func (p Positions) Locate(errs []models.ImportError) {
+	for i := range errs {
+		if positions := p[errs[i].Kind]; errs[i].Index < len(positions) {
+			errs[i].Position = positions[errs[i].Index]
+		}
+	}
+}
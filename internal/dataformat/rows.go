@@ -0,0 +1,142 @@
+package dataformat
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/Vimp17/pr-reviewer-service/internal/models"
+)
+
+// flushEvery через сколько строк буфер отправляется клиенту
+const flushEvery = 100
+
+// Column столбец табличного вывода: заголовок CSV и значение ячейки
+type Column[T any] struct {
+	Name  string
+	Value func(T) string
+}
+
+// RowWriter пишет строки по мере поступления: в CSV — по столбцам с заголовком,
+// в JSONL — JSON-представление модели на строку. Заголовок пишется вместе с
+// первой строкой или в Close, поэтому, пока Started возвращает false, вместо
+// вывода еще можно ответить ошибкой.
+type RowWriter[T any] struct {
+	w       io.Writer
+	format  string
+	columns []Column[T]
+	csv     *csv.Writer
+	json    *json.Encoder
+	record  []string
+	rows    int
+	started bool
+}
+
+// NewRowWriter создает писатель строк в формате CSV или JSONL
+func NewRowWriter[T any](w io.Writer, format string, columns []Column[T]) *RowWriter[T] {
+	rw := &RowWriter[T]{w: w, format: format, columns: columns}
+	if format == CSV {
+		rw.csv = csv.NewWriter(w)
+		rw.record = make([]string, len(columns))
+	} else {
+		rw.json = json.NewEncoder(w)
+	}
+	return rw
+}
+
+// Started сообщает, начат ли вывод
+func (rw *RowWriter[T]) Started() bool {
+	return rw.started
+}
+
+func (rw *RowWriter[T]) start() error {
+	rw.started = true
+	if rw.csv == nil {
+		return nil
+	}
+	for i, column := range rw.columns {
+		rw.record[i] = column.Name
+	}
+	return rw.csv.Write(rw.record)
+}
+
+// Write выводит строку; каждые flushEvery строк буфер отправляется дальше
+func (rw *RowWriter[T]) Write(row T) error {
+	if !rw.started {
+		if err := rw.start(); err != nil {
+			return err
+		}
+	}
+
+	if rw.csv != nil {
+		for i, column := range rw.columns {
+			rw.record[i] = column.Value(row)
+		}
+		if err := rw.csv.Write(rw.record); err != nil {
+			return err
+		}
+	} else if err := rw.json.Encode(row); err != nil {
+		return err
+	}
+
+	if rw.rows++; rw.rows%flushEvery == 0 {
+		return rw.flush()
+	}
+	return nil
+}
+
+// Close завершает вывод; при отсутствии строк CSV состоит из одного заголовка
+func (rw *RowWriter[T]) Close() error {
+	if !rw.started {
+		if err := rw.start(); err != nil {
+			return err
+		}
+	}
+	return rw.flush()
+}
+
+func (rw *RowWriter[T]) flush() error {
+	if rw.csv != nil {
+		rw.csv.Flush()
+		if err := rw.csv.Error(); err != nil {
+			return err
+		}
+	}
+	if flusher, ok := rw.w.(interface{ Flush() }); ok {
+		flusher.Flush()
+	}
+	return nil
+}
+
+// Столбцы табличных выгрузок. Порядок и названия — часть API: новые столбцы
+// добавляются только в конец.
+var (
+	PullRequestColumns = []Column[models.PullRequest]{
+		{"pull_request_id", func(pr models.PullRequest) string { return pr.PullRequestID }},
+		{"pull_request_name", func(pr models.PullRequest) string { return pr.PullRequestName }},
+		{"author_id", func(pr models.PullRequest) string { return pr.AuthorID }},
+		{"status", func(pr models.PullRequest) string { return pr.Status }},
+		{"assigned_reviewers", func(pr models.PullRequest) string { return strings.Join(pr.AssignedReviewers, listSeparator) }},
+		{"version", func(pr models.PullRequest) string { return strconv.Itoa(pr.Version) }},
+		{"created_at", func(pr models.PullRequest) string { return formatTime(pr.CreatedAt) }},
+		{"merged_at", func(pr models.PullRequest) string { return formatTime(pr.MergedAt) }},
+	}
+
+	ReviewerStatsColumns = []Column[models.ReviewerStats]{
+		{"user_id", func(r models.ReviewerStats) string { return r.UserID }},
+		{"team_name", func(r models.ReviewerStats) string { return r.TeamName }},
+		{"open", func(r models.ReviewerStats) string { return strconv.Itoa(r.Open) }},
+		{"merged", func(r models.ReviewerStats) string { return strconv.Itoa(r.Merged) }},
+		{"total", func(r models.ReviewerStats) string { return strconv.Itoa(r.Total) }},
+	}
+
+	TeamStatsColumns = []Column[models.TeamStats]{
+		{"team_name", func(t models.TeamStats) string { return t.TeamName }},
+		{"pull_requests", func(t models.TeamStats) string { return strconv.Itoa(t.PullRequests) }},
+		{"open", func(t models.TeamStats) string { return strconv.Itoa(t.Open) }},
+		{"merged", func(t models.TeamStats) string { return strconv.Itoa(t.Merged) }},
+		{"assignments", func(t models.TeamStats) string { return strconv.Itoa(t.Assignments) }},
+	}
+)
@@ -0,0 +1,133 @@
+package graphqlapi
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+)
+
+// listMultiplier предполагаемый размер списка при оценке сложности:
+// стоимость выборки под полем-списком умножается на него
+const listMultiplier = 10
+
+// listSizes размеры списков, ограниченных моделью (не более двух ревьюеров у PR)
+var listSizes = map[string]int{
+	"PullRequest.reviewers": 2,
+}
+
+// Limits ограничения на запрос, проверяемые до выполнения
+type Limits struct {
+	MaxDepth      int // максимальная вложенность полей
+	MaxComplexity int // максимальная оценка стоимости запроса
+}
+
+// DefaultLimits ограничения по умолчанию; запрос дашборда «команды → участники →
+// открытые ревью → ревьюеры с командами» оценивается примерно в 11000
+var DefaultLimits = Limits{MaxDepth: 8, MaxComplexity: 20000}
+
+// queryCost оценивает глубину и стоимость операции: каждое поле стоит 1,
+// выборка под полем-списком умножается на его размер из listSizes или listMultiplier.
+// Поля интроспекции не учитываются — их размер ограничен схемой.
+func queryCost(schema *graphql.Schema, doc *ast.Document, operationName string) (depth, cost int) {
+	fragments := make(map[string]*ast.FragmentDefinition)
+	var operations []*ast.OperationDefinition
+	for _, def := range doc.Definitions {
+		switch def := def.(type) {
+		case *ast.FragmentDefinition:
+			fragments[def.Name.Value] = def
+		case *ast.OperationDefinition:
+			if operationName == "" || (def.Name != nil && def.Name.Value == operationName) {
+				operations = append(operations, def)
+			}
+		}
+	}
+
+	var walk func(set *ast.SelectionSet, parent *graphql.Object, level int, visiting map[string]bool) (int, int)
+	walk = func(set *ast.SelectionSet, parent *graphql.Object, level int, visiting map[string]bool) (int, int) {
+		if set == nil || parent == nil {
+			return level - 1, 0
+		}
+
+		maxDepth, total := level, 0
+		merge := func(d, c int) {
+			if d > maxDepth {
+				maxDepth = d
+			}
+			total += c
+		}
+
+		for _, selection := range set.Selections {
+			switch selection := selection.(type) {
+			case *ast.Field:
+				name := selection.Name.Value
+				field, ok := parent.Fields()[name]
+				if strings.HasPrefix(name, "__") || !ok {
+					continue
+				}
+
+				fieldType := field.Type
+				if nonNull, ok := fieldType.(*graphql.NonNull); ok {
+					fieldType = nonNull.OfType
+				}
+				_, isList := fieldType.(*graphql.List)
+				child, _ := graphql.GetNamed(field.Type).(*graphql.Object)
+
+				d, c := level, 0
+				if selection.SelectionSet != nil {
+					d, c = walk(selection.SelectionSet, child, level+1, visiting)
+				}
+				if isList {
+					size, ok := listSizes[parent.Name()+"."+name]
+					if !ok {
+						size = listMultiplier
+					}
+					c *= size
+				}
+				merge(d, 1+c)
+			case *ast.InlineFragment:
+				merge(walk(selection.SelectionSet, parent, level, visiting))
+			case *ast.FragmentSpread:
+				name := selection.Name.Value
+				fragment, ok := fragments[name]
+				if !ok || visiting[name] {
+					continue
+				}
+				visiting[name] = true
+				merge(walk(fragment.SelectionSet, parent, level, visiting))
+				delete(visiting, name)
+			}
+		}
+		return maxDepth, total
+	}
+
+	for _, op := range operations {
+		d, c := walk(op.SelectionSet, schema.QueryType(), 1, make(map[string]bool))
+		if d > depth {
+			depth = d
+		}
+		if c > cost {
+			cost = c
+		}
+	}
+	return depth, cost
+}
+
+// checkLimits отклоняет запросы, превышающие ограничения
+func checkLimits(schema *graphql.Schema, doc *ast.Document, operationName string, limits Limits) *codedError {
+	depth, cost := queryCost(schema, doc, operationName)
+	if limits.MaxDepth > 0 && depth > limits.MaxDepth {
+		return &codedError{
+			code:    "QUERY_TOO_DEEP",
+			message: fmt.Sprintf("query depth %d exceeds limit %d", depth, limits.MaxDepth),
+		}
+	}
+	if limits.MaxComplexity > 0 && cost > limits.MaxComplexity {
+		return &codedError{
+			code:    "QUERY_TOO_COMPLEX",
+			message: fmt.Sprintf("query complexity %d exceeds limit %d", cost, limits.MaxComplexity),
+		}
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+package graphqlapi
+
+import (
+	"context"
+	"sync"
+
+	"github.com/Vimp17/pr-reviewer-service/internal/models"
+	"github.com/Vimp17/pr-reviewer-service/internal/services"
+)
+
+// loader собирает ключи, запрошенные резолверами одного уровня запроса,
+// и загружает их одним вызовом fetch при первом обращении к результату.
+// Исполнитель graphql-go раскрывает thunk'и в ширину, поэтому все поля
+// уровня успевают поставить свои ключи в очередь до загрузки.
+type loader[K comparable, V any] struct {
+	mu      sync.Mutex
+	fetch   func(ctx context.Context, keys []K) (map[K]V, error)
+	pending []K
+	queued  map[K]bool
+	results map[K]V
+	errs    map[K]error
+}
+
+func newLoader[K comparable, V any](fetch func(ctx context.Context, keys []K) (map[K]V, error)) *loader[K, V] {
+	return &loader[K, V]{
+		fetch:   fetch,
+		queued:  make(map[K]bool),
+		results: make(map[K]V),
+		errs:    make(map[K]error),
+	}
+}
+
+// load ставит ключ в очередь и возвращает thunk, отдающий результат.
+// Отсутствующий в выборке ключ дает нулевое значение без ошибки.
+func (l *loader[K, V]) load(ctx context.Context, key K) func() (V, error) {
+	l.mu.Lock()
+	_, loaded := l.results[key]
+	if !loaded && l.errs[key] == nil && !l.queued[key] {
+		l.pending = append(l.pending, key)
+		l.queued[key] = true
+	}
+	l.mu.Unlock()
+
+	return func() (V, error) {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		if l.queued[key] {
+			l.dispatch(ctx)
+		}
+		return l.results[key], l.errs[key]
+	}
+}
+
+// dispatch загружает все ключи из очереди; вызывается под l.mu
+func (l *loader[K, V]) dispatch(ctx context.Context) {
+	keys := l.pending
+	l.pending = nil
+	for _, key := range keys {
+		delete(l.queued, key)
+	}
+
+	values, err := l.fetch(ctx, keys)
+	for _, key := range keys {
+		if err != nil {
+			l.errs[key] = err
+			continue
+		}
+		l.results[key] = values[key]
+	}
+}
+
+// loaders набор загрузчиков одного GraphQL-запроса
+type loaders struct {
+	users       *loader[string, *models.User]
+	teams       *loader[string, *models.Team]
+	openReviews *loader[string, []models.PullRequest]
+}
+
+func newLoaders(prService *services.PRService, teamService *services.TeamService, userService *services.UserService) *loaders {
+	return &loaders{
+		users: newLoader(func(ctx context.Context, ids []string) (map[string]*models.User, error) {
+			users, err := userService.GetUsers(ctx, ids)
+			if err != nil {
+				return nil, err
+			}
+			byID := make(map[string]*models.User, len(users))
+			for i := range users {
+				byID[users[i].UserID] = &users[i]
+			}
+			return byID, nil
+		}),
+		teams: newLoader(func(ctx context.Context, names []string) (map[string]*models.Team, error) {
+			teams, err := teamService.GetTeams(ctx, names)
+			if err != nil {
+				return nil, err
+			}
+			byName := make(map[string]*models.Team, len(teams))
+			for i := range teams {
+				byName[teams[i].TeamName] = &teams[i]
+			}
+			return byName, nil
+		}),
+		openReviews: newLoader(func(ctx context.Context, reviewerIDs []string) (map[string][]models.PullRequest, error) {
+			prs, err := prService.GetOpenPRsForReviewers(ctx, reviewerIDs)
+			if err != nil {
+				return nil, err
+			}
+			byReviewer := make(map[string][]models.PullRequest, len(reviewerIDs))
+			for _, pr := range prs {
+				for _, reviewerID := range pr.AssignedReviewers {
+					byReviewer[reviewerID] = append(byReviewer[reviewerID], pr)
+				}
+			}
+			return byReviewer, nil
+		}),
+	}
+}
+
+type loadersKey struct{}
+
+func withLoaders(ctx context.Context, l *loaders) context.Context {
+	return context.WithValue(ctx, loadersKey{}, l)
+}
+
+func loadersFrom(ctx context.Context) *loaders {
+	return ctx.Value(loadersKey{}).(*loaders)
+}
@@ -0,0 +1,270 @@
+package graphqlapi
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"github.com/Vimp17/pr-reviewer-service/internal/models"
+	"github.com/Vimp17/pr-reviewer-service/internal/services"
+	"github.com/graphql-go/graphql"
+)
+
+// codedError ошибка резолвера с кодом в extensions.code
+type codedError struct {
+	code    string
+	message string
+}
+
+func (e codedError) Error() string { return e.message }
+
+func (e codedError) Extensions() map[string]interface{} {
+	return map[string]interface{}{"code": e.code}
+}
+
+// internalError скрывает подробности ошибки сервиса от клиента и пишет ее в журнал
+func internalError(ctx context.Context, err error) error {
+	slog.ErrorContext(ctx, "graphql resolver failed", "error", err)
+	return codedError{code: "INTERNAL_ERROR", message: "internal server error"}
+}
+
+// newSchema строит схему Query { team, teams, user, pullRequest }
+// над Team, User и PullRequest
+func (s *Server) newSchema() (graphql.Schema, error) {
+	teamType := graphql.NewObject(graphql.ObjectConfig{
+		Name:   "Team",
+		Fields: graphql.Fields{},
+	})
+	userType := graphql.NewObject(graphql.ObjectConfig{
+		Name:   "User",
+		Fields: graphql.Fields{},
+	})
+	prType := graphql.NewObject(graphql.ObjectConfig{
+		Name:   "PullRequest",
+		Fields: graphql.Fields{},
+	})
+
+	// Team
+	teamType.AddFieldConfig("name", &graphql.Field{
+		Type: graphql.NewNonNull(graphql.String),
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return p.Source.(*models.Team).TeamName, nil
+		},
+	})
+	teamType.AddFieldConfig("members", &graphql.Field{
+		Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(userType))),
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			team := p.Source.(*models.Team)
+			members := make([]*models.User, 0, len(team.Members))
+			for i := range team.Members {
+				members = append(members, &team.Members[i])
+			}
+			return members, nil
+		},
+	})
+
+	// User
+	userType.AddFieldConfig("id", &graphql.Field{
+		Type: graphql.NewNonNull(graphql.String),
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return p.Source.(*models.User).UserID, nil
+		},
+	})
+	userType.AddFieldConfig("username", &graphql.Field{
+		Type: graphql.NewNonNull(graphql.String),
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return p.Source.(*models.User).Username, nil
+		},
+	})
+	userType.AddFieldConfig("teamName", &graphql.Field{
+		Type: graphql.NewNonNull(graphql.String),
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return p.Source.(*models.User).TeamName, nil
+		},
+	})
+	userType.AddFieldConfig("isActive", &graphql.Field{
+		Type: graphql.NewNonNull(graphql.Boolean),
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return p.Source.(*models.User).IsActive, nil
+		},
+	})
+	userType.AddFieldConfig("team", &graphql.Field{
+		Type: teamType,
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return s.resolveTeam(p, p.Source.(*models.User).TeamName), nil
+		},
+	})
+	userType.AddFieldConfig("openReviews", &graphql.Field{
+		Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(prType))),
+		Description: "Открытые PR, где пользователь назначен ревьюером",
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			thunk := loadersFrom(p.Context).openReviews.load(p.Context, p.Source.(*models.User).UserID)
+			return func() (interface{}, error) {
+				prs, err := thunk()
+				if err != nil {
+					return nil, internalError(p.Context, err)
+				}
+				result := make([]*models.PullRequest, 0, len(prs))
+				for i := range prs {
+					result = append(result, &prs[i])
+				}
+				return result, nil
+			}, nil
+		},
+	})
+
+	// PullRequest
+	prType.AddFieldConfig("id", &graphql.Field{
+		Type: graphql.NewNonNull(graphql.String),
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return p.Source.(*models.PullRequest).PullRequestID, nil
+		},
+	})
+	prType.AddFieldConfig("name", &graphql.Field{
+		Type: graphql.NewNonNull(graphql.String),
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return p.Source.(*models.PullRequest).PullRequestName, nil
+		},
+	})
+	prType.AddFieldConfig("status", &graphql.Field{
+		Type: graphql.NewNonNull(graphql.String),
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return p.Source.(*models.PullRequest).Status, nil
+		},
+	})
+	prType.AddFieldConfig("version", &graphql.Field{
+		Type: graphql.NewNonNull(graphql.Int),
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return p.Source.(*models.PullRequest).Version, nil
+		},
+	})
+	prType.AddFieldConfig("createdAt", &graphql.Field{
+		Type: graphql.DateTime,
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return p.Source.(*models.PullRequest).CreatedAt, nil
+		},
+	})
+	prType.AddFieldConfig("mergedAt", &graphql.Field{
+		Type: graphql.DateTime,
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return p.Source.(*models.PullRequest).MergedAt, nil
+		},
+	})
+	prType.AddFieldConfig("author", &graphql.Field{
+		Type: userType,
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return s.resolveUser(p, p.Source.(*models.PullRequest).AuthorID), nil
+		},
+	})
+	prType.AddFieldConfig("reviewers", &graphql.Field{
+		Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(userType))),
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			pr := p.Source.(*models.PullRequest)
+			thunks := make([]func() (*models.User, error), 0, len(pr.AssignedReviewers))
+			for _, reviewerID := range pr.AssignedReviewers {
+				thunks = append(thunks, loadersFrom(p.Context).users.load(p.Context, reviewerID))
+			}
+			return func() (interface{}, error) {
+				reviewers := make([]*models.User, 0, len(thunks))
+				for _, thunk := range thunks {
+					user, err := thunk()
+					if err != nil {
+						return nil, internalError(p.Context, err)
+					}
+					if user != nil {
+						reviewers = append(reviewers, user)
+					}
+				}
+				return reviewers, nil
+			}, nil
+		},
+	})
+
+	queryType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"team": &graphql.Field{
+				Type: teamType,
+				Args: graphql.FieldConfigArgument{
+					"name": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return s.resolveTeam(p, p.Args["name"].(string)), nil
+				},
+			},
+			"teams": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(teamType))),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					teams, err := s.teamService.ListTeams(p.Context)
+					if err != nil {
+						return nil, internalError(p.Context, err)
+					}
+					result := make([]*models.Team, 0, len(teams))
+					for i := range teams {
+						result = append(result, &teams[i])
+					}
+					return result, nil
+				},
+			},
+			"user": &graphql.Field{
+				Type: userType,
+				Args: graphql.FieldConfigArgument{
+					"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return s.resolveUser(p, p.Args["id"].(string)), nil
+				},
+			},
+			"pullRequest": &graphql.Field{
+				Type: prType,
+				Args: graphql.FieldConfigArgument{
+					"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					pr, err := s.prService.GetPR(p.Context, p.Args["id"].(string))
+					if err != nil {
+						if errors.Is(err, services.ErrNotFound) {
+							return nil, nil
+						}
+						return nil, internalError(p.Context, err)
+					}
+					return pr, nil
+				},
+			},
+		},
+	})
+
+	return graphql.NewSchema(graphql.SchemaConfig{Query: queryType})
+}
+
+// resolveUser загружает пользователя через загрузчик запроса;
+// отсутствующий пользователь дает null
+func (s *Server) resolveUser(p graphql.ResolveParams, userID string) func() (interface{}, error) {
+	thunk := loadersFrom(p.Context).users.load(p.Context, userID)
+	return func() (interface{}, error) {
+		user, err := thunk()
+		if err != nil {
+			return nil, internalError(p.Context, err)
+		}
+		if user == nil {
+			return nil, nil
+		}
+		return user, nil
+	}
+}
+
+// resolveTeam загружает команду через загрузчик запроса;
+// отсутствующая команда дает null
+func (s *Server) resolveTeam(p graphql.ResolveParams, teamName string) func() (interface{}, error) {
+	thunk := loadersFrom(p.Context).teams.load(p.Context, teamName)
+	return func() (interface{}, error) {
+		team, err := thunk()
+		if err != nil {
+			return nil, internalError(p.Context, err)
+		}
+		if team == nil {
+			return nil, nil
+		}
+		return team, nil
+	}
+}
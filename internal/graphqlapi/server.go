@@ -0,0 +1,83 @@
+// Package graphqlapi реализует GraphQL API для дашбордов поверх сервисов
+package graphqlapi
+
+import (
+	"context"
+
+	"github.com/Vimp17/pr-reviewer-service/internal/services"
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/location"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/graphql-go/graphql/language/source"
+)
+
+// Request тело GraphQL-запроса
+type Request struct {
+	Query         string                 `json:"query"`
+	Variables     map[string]interface{} `json:"variables"`
+	OperationName string                 `json:"operationName"`
+}
+
+// Server выполняет GraphQL-запросы
+type Server struct {
+	prService   *services.PRService
+	teamService *services.TeamService
+	userService *services.UserService
+	limits      Limits
+	schema      graphql.Schema
+}
+
+// NewServer создает новый экземпляр Server с указанными сервисами и ограничениями
+func NewServer(
+	prService *services.PRService,
+	teamService *services.TeamService,
+	userService *services.UserService,
+	limits Limits,
+) (*Server, error) {
+	s := &Server{
+		prService:   prService,
+		teamService: teamService,
+		userService: userService,
+		limits:      limits,
+	}
+
+	schema, err := s.newSchema()
+	if err != nil {
+		return nil, err
+	}
+	s.schema = schema
+	return s, nil
+}
+
+// Execute разбирает, проверяет и выполняет запрос. Каждый запрос получает
+// собственные загрузчики, так что пакетная выборка и кэш не выходят за его пределы.
+func (s *Server) Execute(ctx context.Context, req Request) *graphql.Result {
+	doc, err := parser.Parse(parser.ParseParams{
+		Source: source.NewSource(&source.Source{Body: []byte(req.Query), Name: "GraphQL request"}),
+	})
+	if err != nil {
+		return &graphql.Result{Errors: gqlerrors.FormatErrors(err)}
+	}
+
+	validation := graphql.ValidateDocument(&s.schema, doc, nil)
+	if !validation.IsValid {
+		return &graphql.Result{Errors: validation.Errors}
+	}
+
+	if limitErr := checkLimits(&s.schema, doc, req.OperationName, s.limits); limitErr != nil {
+		return &graphql.Result{Errors: []gqlerrors.FormattedError{{
+			Message:    limitErr.Error(),
+			Locations:  []location.SourceLocation{},
+			Extensions: limitErr.Extensions(),
+		}}}
+	}
+
+	return graphql.Execute(graphql.ExecuteParams{
+		Schema:        s.schema,
+		AST:           doc,
+		OperationName: req.OperationName,
+		Args:          req.Variables,
+		Context:       withLoaders(ctx, newLoaders(s.prService, s.teamService, s.userService)),
+	})
+}
@@ -0,0 +1,38 @@
+package grpcapi
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/Vimp17/pr-reviewer-service/internal/apierror"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/status"
+)
+
+// errorDomain домен ErrorInfo, по которому клиенты узнают ошибки сервиса
+const errorDomain = "pr-reviewer-service"
+
+// statusError строит ошибку gRPC с кодом сервиса в ErrorInfo.reason
+func statusError(code, message string) error {
+	kind, _ := apierror.ByCode(code)
+	if message == "" {
+		message = kind.Code
+	}
+
+	st := status.New(kind.GRPC, message)
+	if detailed, err := st.WithDetails(&errdetails.ErrorInfo{Reason: kind.Code, Domain: errorDomain}); err == nil {
+		st = detailed
+	}
+	return st.Err()
+}
+
+// serviceError переводит ошибку сервиса в ошибку gRPC. Неизвестные ошибки
+// считаются внутренними и пишутся в журнал.
+func serviceError(ctx context.Context, err error) error {
+	kind, ok := apierror.FromError(err)
+	if !ok {
+		slog.ErrorContext(ctx, "grpc call failed", "error", err)
+		return statusError(kind.Code, kind.Message)
+	}
+	return statusError(kind.Code, "")
+}
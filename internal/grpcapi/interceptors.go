@@ -0,0 +1,150 @@
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"runtime/debug"
+	"strings"
+
+	"github.com/Vimp17/pr-reviewer-service/internal/auth"
+	"github.com/Vimp17/pr-reviewer-service/internal/requestid"
+	"github.com/Vimp17/pr-reviewer-service/internal/tenant"
+	"github.com/Vimp17/pr-reviewer-service/internal/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Ключи метаданных gRPC (в нижнем регистре, как их передает grpc-go)
+const (
+	authorizationKey = "authorization"
+	apiKeyKey        = "x-api-key"
+	requestIDKey     = "x-request-id"
+	traceIDKey       = "x-trace-id"
+)
+
+// methodRoles ограничивает методы ролями так же, как маршруты HTTP API
+var methodRoles = map[string][]auth.Role{
+	"CreateTeam":       {auth.RoleAdmin},
+	"SetUserActive":    {auth.RoleAdmin, auth.RoleTeamLead},
+	"ReassignReviewer": {auth.RoleAdmin, auth.RoleTeamLead, auth.RoleMember},
+}
+
+// UnaryTracingInterceptor начинает серверный спан на каждый вызов, продолжая
+// входящую трассировку из метаданных traceparent, и возвращает ее идентификатор
+// в заголовке x-trace-id
+func UnaryTracingInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		md, _ := metadata.FromIncomingContext(ctx)
+		ctx = otel.GetTextMapPropagator().Extract(ctx, metadataCarrier(md))
+
+		ctx, span := tracing.Tracer().Start(ctx, strings.TrimPrefix(info.FullMethod, "/"),
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				attribute.String("rpc.system", "grpc"),
+				attribute.String("rpc.method", info.FullMethod),
+			),
+		)
+		defer span.End()
+
+		if id := tracing.TraceID(ctx); id != "" {
+			_ = grpc.SetHeader(ctx, metadata.Pairs(traceIDKey, id))
+		}
+
+		resp, err := handler(ctx, req)
+		code := status.Code(err)
+		span.SetAttributes(attribute.String("rpc.grpc.status_code", code.String()))
+		if err != nil {
+			span.SetStatus(codes.Error, code.String())
+		}
+		return resp, err
+	}
+}
+
+// UnaryRecoveryInterceptor перехватывает панику обработчика, пишет ее в журнал
+// и отвечает codes.Internal, не роняя процесс
+func UnaryRecoveryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				slog.ErrorContext(ctx, "grpc panic recovered",
+					"method", info.FullMethod,
+					"panic", recovered,
+					"stack", string(debug.Stack()),
+				)
+				resp, err = nil, statusError("INTERNAL_ERROR", "internal server error")
+			}
+		}()
+		return handler(ctx, req)
+	}
+}
+
+// metadataCarrier адаптирует метаданные gRPC к propagation.TextMapCarrier
+type metadataCarrier metadata.MD
+
+func (c metadataCarrier) Get(key string) string {
+	return firstValue(metadata.MD(c), key)
+}
+
+func (c metadataCarrier) Set(key, value string) {
+	metadata.MD(c).Set(key, value)
+}
+
+func (c metadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for key := range c {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// UnaryAuthInterceptor присваивает вызову идентификатор запроса, аутентифицирует
+// вызывающего по метаданным authorization (Bearer) или x-api-key и привязывает
+// контекст к его личности и организации
+func UnaryAuthInterceptor(authenticator auth.Authenticator) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		md, _ := metadata.FromIncomingContext(ctx)
+
+		id := firstValue(md, requestIDKey)
+		if id == "" || len(id) > 128 {
+			id = requestid.New()
+		}
+		ctx = requestid.WithRequestID(ctx, id)
+		_ = grpc.SetHeader(ctx, metadata.Pairs(requestIDKey, id))
+
+		token := firstValue(md, apiKeyKey)
+		if header := firstValue(md, authorizationKey); token == "" && strings.HasPrefix(header, "Bearer ") {
+			token = strings.TrimSpace(strings.TrimPrefix(header, "Bearer "))
+		}
+
+		identity, err := authenticator.Authenticate(ctx, token)
+		if err != nil {
+			if errors.Is(err, auth.ErrUnauthenticated) {
+				return nil, statusError("UNAUTHENTICATED", "missing or invalid API token")
+			}
+			slog.ErrorContext(ctx, "grpc authentication failed", "method", info.FullMethod, "error", err)
+			return nil, statusError("INTERNAL_ERROR", "")
+		}
+
+		method := info.FullMethod[strings.LastIndex(info.FullMethod, "/")+1:]
+		if roles, ok := methodRoles[method]; ok && !identity.HasRole(roles...) {
+			return nil, statusError("FORBIDDEN", "operation is not permitted for caller role")
+		}
+
+		ctx = auth.WithIdentity(ctx, identity)
+		ctx = tenant.WithOrgID(ctx, identity.OrgID)
+		return handler(ctx, req)
+	}
+}
+
+func firstValue(md metadata.MD, key string) string {
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
@@ -0,0 +1,416 @@
+// Package grpcapi реализует gRPC API поверх тех же сервисов, что и HTTP API
+package grpcapi
+
+import (
+	"context"
+	"time"
+
+	"github.com/Vimp17/pr-reviewer-service/internal/auth"
+	pb "github.com/Vimp17/pr-reviewer-service/internal/grpcapi/prreviewerv1"
+	"github.com/Vimp17/pr-reviewer-service/internal/models"
+	"github.com/Vimp17/pr-reviewer-service/internal/services"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Server реализует prreviewer.v1.PRReviewerService
+type Server struct {
+	pb.UnimplementedPRReviewerServiceServer
+
+	prService   *services.PRService
+	teamService *services.TeamService
+	userService *services.UserService
+}
+
+// NewServer создает новый экземпляр Server с указанными сервисами
+func NewServer(
+	prService *services.PRService,
+	teamService *services.TeamService,
+	userService *services.UserService,
+) *Server {
+	return &Server{
+		prService:   prService,
+		teamService: teamService,
+		userService: userService,
+	}
+}
+
+// NewGRPCServer создает gRPC-сервер с зарегистрированным Server, трассировкой
+// вызовов, перехватом паник и аутентификацией через authenticator
+func NewGRPCServer(srv *Server, authenticator auth.Authenticator, opts ...grpc.ServerOption) *grpc.Server {
+	opts = append(opts, grpc.ChainUnaryInterceptor(
+		UnaryTracingInterceptor(),
+		UnaryRecoveryInterceptor(),
+		UnaryAuthInterceptor(authenticator),
+	))
+	grpcServer := grpc.NewServer(opts...)
+	pb.RegisterPRReviewerServiceServer(grpcServer, srv)
+	return grpcServer
+}
+
+// Команды
+
+func (s *Server) CreateTeam(ctx context.Context, req *pb.CreateTeamRequest) (*pb.CreateTeamResponse, error) {
+	if req.GetTeam().GetTeamName() == "" || len(req.GetTeam().GetMembers()) == 0 {
+		return nil, statusError("INVALID_REQUEST", "team_name and members are required")
+	}
+
+	members := make([]models.User, 0, len(req.Team.Members))
+	for _, m := range req.Team.Members {
+		if m.GetUserId() == "" || m.GetUsername() == "" {
+			return nil, statusError("INVALID_REQUEST", "user_id and username are required for every member")
+		}
+		members = append(members, models.User{
+			UserID:   m.UserId,
+			Username: m.Username,
+			IsActive: m.IsActive,
+		})
+	}
+
+	team, err := s.teamService.CreateTeam(ctx, models.Team{TeamName: req.Team.TeamName, Members: members})
+	if err != nil {
+		return nil, serviceError(ctx, err)
+	}
+	return &pb.CreateTeamResponse{Team: teamToProto(team)}, nil
+}
+
+func (s *Server) GetTeam(ctx context.Context, req *pb.GetTeamRequest) (*pb.GetTeamResponse, error) {
+	if req.GetTeamName() == "" {
+		return nil, statusError("INVALID_REQUEST", "team_name is required")
+	}
+
+	team, err := s.teamService.GetTeam(ctx, req.TeamName)
+	if err != nil {
+		return nil, serviceError(ctx, err)
+	}
+	return &pb.GetTeamResponse{Team: teamToProto(team)}, nil
+}
+
+func (s *Server) ListTeams(ctx context.Context, _ *pb.ListTeamsRequest) (*pb.ListTeamsResponse, error) {
+	teams, err := s.teamService.ListTeams(ctx)
+	if err != nil {
+		return nil, serviceError(ctx, err)
+	}
+
+	resp := &pb.ListTeamsResponse{Teams: make([]*pb.Team, 0, len(teams))}
+	for i := range teams {
+		resp.Teams = append(resp.Teams, teamToProto(&teams[i]))
+	}
+	return resp, nil
+}
+
+// Пользователи
+
+func (s *Server) GetUser(ctx context.Context, req *pb.GetUserRequest) (*pb.GetUserResponse, error) {
+	if req.GetUserId() == "" {
+		return nil, statusError("INVALID_REQUEST", "user_id is required")
+	}
+
+	user, err := s.userService.GetUser(ctx, req.UserId)
+	if err != nil {
+		return nil, serviceError(ctx, err)
+	}
+	return &pb.GetUserResponse{User: userToProto(user)}, nil
+}
+
+func (s *Server) SetUserActive(ctx context.Context, req *pb.SetUserActiveRequest) (*pb.SetUserActiveResponse, error) {
+	if req.GetUserId() == "" {
+		return nil, statusError("INVALID_REQUEST", "user_id is required")
+	}
+
+	user, err := s.userService.SetUserActiveStatus(ctx, req.UserId, req.IsActive)
+	if err != nil {
+		return nil, serviceError(ctx, err)
+	}
+	return &pb.SetUserActiveResponse{User: userToProto(user)}, nil
+}
+
+func (s *Server) ListUserReviews(ctx context.Context, req *pb.ListUserReviewsRequest) (*pb.ListUserReviewsResponse, error) {
+	if req.GetUserId() == "" {
+		return nil, statusError("INVALID_REQUEST", "user_id is required")
+	}
+	for _, status := range req.Statuses {
+		if status != "OPEN" && status != "MERGED" {
+			return nil, statusError("INVALID_REQUEST", "statuses must be OPEN or MERGED")
+		}
+	}
+
+	filter := models.ReviewFilter{
+		ReviewerID: req.UserId,
+		Statuses:   req.Statuses,
+		Limit:      int(req.PageSize),
+	}
+	prs, next, err := s.userService.GetPRsForReviewer(ctx, filter, req.PageToken)
+	if err != nil {
+		return nil, serviceError(ctx, err)
+	}
+
+	resp := &pb.ListUserReviewsResponse{
+		PullRequests:  make([]*pb.PullRequestShort, 0, len(prs)),
+		NextPageToken: next,
+	}
+	for _, pr := range prs {
+		resp.PullRequests = append(resp.PullRequests, &pb.PullRequestShort{
+			PullRequestId:   pr.PullRequestID,
+			PullRequestName: pr.PullRequestName,
+			AuthorId:        pr.AuthorID,
+			Status:          pr.Status,
+			CreatedAt:       timestampToProto(pr.CreatedAt),
+			AgeSeconds:      pr.AgeSeconds,
+			Verdict:         pr.Verdict,
+		})
+	}
+	return resp, nil
+}
+
+// Pull Requests
+
+func (s *Server) CreatePullRequest(ctx context.Context, req *pb.CreatePullRequestRequest) (*pb.CreatePullRequestResponse, error) {
+	if req.GetPullRequestId() == "" || req.GetPullRequestName() == "" || req.GetAuthorId() == "" {
+		return nil, statusError("INVALID_REQUEST", "pull_request_id, pull_request_name and author_id are required")
+	}
+
+	pr, err := s.prService.CreatePR(ctx, models.PullRequest{
+		PullRequestID:   req.PullRequestId,
+		PullRequestName: req.PullRequestName,
+		AuthorID:        req.AuthorId,
+	})
+	if err != nil {
+		return nil, serviceError(ctx, err)
+	}
+	return &pb.CreatePullRequestResponse{PullRequest: prToProto(pr)}, nil
+}
+
+func (s *Server) GetPullRequest(ctx context.Context, req *pb.GetPullRequestRequest) (*pb.GetPullRequestResponse, error) {
+	if req.GetPullRequestId() == "" {
+		return nil, statusError("INVALID_REQUEST", "pull_request_id is required")
+	}
+
+	pr, err := s.prService.GetPR(ctx, req.PullRequestId)
+	if err != nil {
+		return nil, serviceError(ctx, err)
+	}
+	return &pb.GetPullRequestResponse{PullRequest: prToProto(pr)}, nil
+}
+
+func (s *Server) MergePullRequest(ctx context.Context, req *pb.MergePullRequestRequest) (*pb.MergePullRequestResponse, error) {
+	if req.GetPullRequestId() == "" {
+		return nil, statusError("INVALID_REQUEST", "pull_request_id is required")
+	}
+
+	pr, err := s.prService.MergePR(ctx, req.PullRequestId)
+	if err != nil {
+		return nil, serviceError(ctx, err)
+	}
+	return &pb.MergePullRequestResponse{PullRequest: prToProto(pr)}, nil
+}
+
+func (s *Server) ReassignReviewer(ctx context.Context, req *pb.ReassignReviewerRequest) (*pb.ReassignReviewerResponse, error) {
+	if req.GetPullRequestId() == "" || req.GetOldUserId() == "" {
+		return nil, statusError("INVALID_REQUEST", "pull_request_id and old_user_id are required")
+	}
+
+	pr, newReviewer, err := s.prService.ReassignReviewer(ctx, req.PullRequestId, req.OldUserId, req.Reason)
+	if err != nil {
+		return nil, serviceError(ctx, err)
+	}
+	return &pb.ReassignReviewerResponse{PullRequest: prToProto(pr), ReplacedBy: newReviewer}, nil
+}
+
+func (s *Server) SubmitVerdict(ctx context.Context, req *pb.SubmitVerdictRequest) (*pb.SubmitVerdictResponse, error) {
+	if req.GetPullRequestId() == "" || req.GetReviewerId() == "" || req.GetVerdict() == "" {
+		return nil, statusError("INVALID_REQUEST", "pull_request_id, reviewer_id and verdict are required")
+	}
+
+	err := s.prService.SubmitVerdict(ctx, req.PullRequestId, req.ReviewerId, req.Verdict, req.Comment)
+	if err != nil {
+		return nil, serviceError(ctx, err)
+	}
+	return &pb.SubmitVerdictResponse{}, nil
+}
+
+func (s *Server) GetPullRequestHistory(ctx context.Context, req *pb.GetPullRequestHistoryRequest) (*pb.GetPullRequestHistoryResponse, error) {
+	if req.GetPullRequestId() == "" {
+		return nil, statusError("INVALID_REQUEST", "pull_request_id is required")
+	}
+
+	events, err := s.prService.GetPRHistory(ctx, req.PullRequestId)
+	if err != nil {
+		return nil, serviceError(ctx, err)
+	}
+
+	resp := &pb.GetPullRequestHistoryResponse{Events: make([]*pb.PullRequestEvent, 0, len(events))}
+	for _, e := range events {
+		resp.Events = append(resp.Events, &pb.PullRequestEvent{
+			Id:            e.ID,
+			PullRequestId: e.PullRequestID,
+			EventType:     e.EventType,
+			ReviewerId:    e.ReviewerID,
+			OldReviewerId: e.OldReviewerID,
+			Verdict:       e.Verdict,
+			Actor:         e.Actor,
+			Reason:        e.Reason,
+			CreatedAt:     timestampToProto(e.CreatedAt),
+		})
+	}
+	return resp, nil
+}
+
+// Статистика
+
+func (s *Server) GetStats(ctx context.Context, req *pb.GetStatsRequest) (*pb.GetStatsResponse, error) {
+	filter, err := statsFilter(req.TeamName, req.From, req.To)
+	if err != nil {
+		return nil, err
+	}
+
+	stats, err := s.prService.GetStats(ctx, filter)
+	if err != nil {
+		return nil, serviceError(ctx, err)
+	}
+
+	resp := &pb.GetStatsResponse{
+		Assignments:           make(map[string]int64, len(stats.Reviewers)),
+		PullRequests:          int64(stats.PullRequests),
+		Reviewers:             make([]*pb.ReviewerStats, 0, len(stats.Reviewers)),
+		Teams:                 make([]*pb.TeamStats, 0, len(stats.Teams)),
+		TimeToMerge:           percentilesToProto(stats.TimeToMerge),
+		Reassignments:         int64(stats.Reassignments),
+		AvgReassignmentsPerPr: stats.AvgReassignmentsPerPR,
+		LoadGini:              stats.LoadGini,
+	}
+	for _, r := range stats.Reviewers {
+		if r.Total > 0 {
+			resp.Assignments[r.UserID] = int64(r.Total)
+		}
+		resp.Reviewers = append(resp.Reviewers, &pb.ReviewerStats{
+			UserId:   r.UserID,
+			TeamName: r.TeamName,
+			Open:     int64(r.Open),
+			Merged:   int64(r.Merged),
+			Total:    int64(r.Total),
+		})
+	}
+	for _, t := range stats.Teams {
+		resp.Teams = append(resp.Teams, &pb.TeamStats{
+			TeamName:     t.TeamName,
+			PullRequests: int64(t.PullRequests),
+			Open:         int64(t.Open),
+			Merged:       int64(t.Merged),
+			Assignments:  int64(t.Assignments),
+		})
+	}
+	return resp, nil
+}
+
+func (s *Server) GetLatencyStats(ctx context.Context, req *pb.GetLatencyStatsRequest) (*pb.GetLatencyStatsResponse, error) {
+	filter, err := statsFilter(req.TeamName, req.From, req.To)
+	if err != nil {
+		return nil, err
+	}
+
+	stats, err := s.prService.GetLatencyStats(ctx, filter)
+	if err != nil {
+		return nil, serviceError(ctx, err)
+	}
+
+	resp := &pb.GetLatencyStatsResponse{
+		Overall:   latencyToProto(stats.Overall),
+		Teams:     make([]*pb.TeamLatency, 0, len(stats.Teams)),
+		Reviewers: make([]*pb.ReviewerLatency, 0, len(stats.Reviewers)),
+		Weekly:    make([]*pb.WeeklyLatency, 0, len(stats.Weekly)),
+	}
+	for _, t := range stats.Teams {
+		resp.Teams = append(resp.Teams, &pb.TeamLatency{
+			TeamName: t.TeamName,
+			Latency:  latencyToProto(t.Latency),
+		})
+	}
+	for _, r := range stats.Reviewers {
+		resp.Reviewers = append(resp.Reviewers, &pb.ReviewerLatency{
+			UserId:   r.UserID,
+			TeamName: r.TeamName,
+			Latency:  latencyToProto(r.Latency),
+		})
+	}
+	for _, w := range stats.Weekly {
+		resp.Weekly = append(resp.Weekly, &pb.WeeklyLatency{
+			WeekStart:    timestamppb.New(w.WeekStart),
+			PullRequests: int64(w.PullRequests),
+			Latency:      latencyToProto(w.Latency),
+		})
+	}
+	return resp, nil
+}
+
+// statsFilter собирает охват статистики из полей запроса
+func statsFilter(teamName string, from, to *timestamppb.Timestamp) (models.StatsFilter, error) {
+	filter := models.StatsFilter{TeamName: teamName}
+	if from != nil {
+		t := from.AsTime()
+		filter.From = &t
+	}
+	if to != nil {
+		t := to.AsTime()
+		filter.To = &t
+	}
+	if filter.From != nil && filter.To != nil && !filter.From.Before(*filter.To) {
+		return filter, statusError("INVALID_REQUEST", "from must be before to")
+	}
+	return filter, nil
+}
+
+// Преобразование моделей в сообщения protobuf
+
+func userToProto(u *models.User) *pb.User {
+	return &pb.User{
+		UserId:   u.UserID,
+		Username: u.Username,
+		TeamName: u.TeamName,
+		IsActive: u.IsActive,
+	}
+}
+
+func teamToProto(t *models.Team) *pb.Team {
+	team := &pb.Team{TeamName: t.TeamName, Members: make([]*pb.User, 0, len(t.Members))}
+	for i := range t.Members {
+		team.Members = append(team.Members, userToProto(&t.Members[i]))
+	}
+	return team
+}
+
+func prToProto(pr *models.PullRequest) *pb.PullRequest {
+	return &pb.PullRequest{
+		PullRequestId:     pr.PullRequestID,
+		PullRequestName:   pr.PullRequestName,
+		AuthorId:          pr.AuthorID,
+		Status:            pr.Status,
+		AssignedReviewers: pr.AssignedReviewers,
+		Version:           int64(pr.Version),
+		CreatedAt:         timestampToProto(pr.CreatedAt),
+		MergedAt:          timestampToProto(pr.MergedAt),
+	}
+}
+
+func timestampToProto(t *time.Time) *timestamppb.Timestamp {
+	if t == nil {
+		return nil
+	}
+	return timestamppb.New(*t)
+}
+
+func percentilesToProto(d models.DurationPercentiles) *pb.DurationPercentiles {
+	return &pb.DurationPercentiles{
+		Count: int64(d.Count),
+		P50:   d.P50,
+		P90:   d.P90,
+		P99:   d.P99,
+	}
+}
+
+func latencyToProto(l models.Latency) *pb.Latency {
+	return &pb.Latency{
+		TimeToFirstReview: percentilesToProto(l.TimeToFirstReview),
+		TimeToMerge:       percentilesToProto(l.TimeToMerge),
+	}
+}
@@ -0,0 +1,293 @@
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+
+	"github.com/Vimp17/pr-reviewer-service/internal/apierror"
+	"github.com/Vimp17/pr-reviewer-service/internal/auth"
+	pb "github.com/Vimp17/pr-reviewer-service/internal/grpcapi/prreviewerv1"
+	"github.com/Vimp17/pr-reviewer-service/internal/models"
+	"github.com/Vimp17/pr-reviewer-service/internal/services"
+	"github.com/Vimp17/pr-reviewer-service/internal/testdb"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// stubAuthenticator сопоставляет токену личность; неизвестный токен не
+// аутентифицирован, токен "broken" дает внутреннюю ошибку
+type stubAuthenticator map[string]*auth.Identity
+
+func (a stubAuthenticator) Authenticate(_ context.Context, token string) (*auth.Identity, error) {
+	if token == "broken" {
+		return nil, errors.New("token store unavailable")
+	}
+	if identity, ok := a[token]; ok {
+		return identity, nil
+	}
+	return nil, auth.ErrUnauthenticated
+}
+
+func testAuthenticator(orgID string) stubAuthenticator {
+	return stubAuthenticator{
+		"admin":  auth.SystemIdentity(orgID, "test-admin"),
+		"lead":   {OrgID: orgID, UserID: "u1", Roles: []auth.Role{auth.RoleTeamLead}},
+		"member": {OrgID: orgID, UserID: "u2", Roles: []auth.Role{auth.RoleMember}},
+	}
+}
+
+// dial поднимает gRPC-сервер на bufconn и возвращает клиента к нему
+func dial(t *testing.T, srv *Server, authenticator auth.Authenticator) pb.PRReviewerServiceClient {
+	t.Helper()
+	listener := bufconn.Listen(1 << 20)
+	server := NewGRPCServer(srv, authenticator)
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return pb.NewPRReviewerServiceClient(conn)
+}
+
+func withToken(token string) context.Context {
+	return metadata.AppendToOutgoingContext(context.Background(), authorizationKey, "Bearer "+token)
+}
+
+// checkStatus сверяет код gRPC и причину в ErrorInfo
+func checkStatus(t *testing.T, name string, err error, code codes.Code, reason string) {
+	t.Helper()
+	st := status.Convert(err)
+	if st.Code() != code {
+		t.Errorf("%s: code %s (%s), want %s", name, st.Code(), st.Message(), code)
+		return
+	}
+	if reason == "" {
+		return
+	}
+	for _, d := range st.Details() {
+		if info, ok := d.(*errdetails.ErrorInfo); ok {
+			if info.Reason != reason || info.Domain != errorDomain {
+				t.Errorf("%s: ErrorInfo %s/%s, want %s/%s", name, info.Domain, info.Reason, errorDomain, reason)
+			}
+			return
+		}
+	}
+	t.Errorf("%s: no ErrorInfo in %v", name, st.Details())
+}
+
+func TestAuthInterceptor(t *testing.T) {
+	// Без сервисов: до обработчика доходят только вызовы, прошедшие проверки
+	client := dial(t, NewServer(nil, nil, nil), testAuthenticator("default"))
+	team := &pb.CreateTeamRequest{Team: &pb.Team{TeamName: "backend", Members: []*pb.User{{UserId: "u1", Username: "Alice"}}}}
+
+	_, err := client.ListTeams(context.Background(), &pb.ListTeamsRequest{})
+	checkStatus(t, "no token", err, codes.Unauthenticated, "UNAUTHENTICATED")
+
+	_, err = client.ListTeams(withToken("unknown"), &pb.ListTeamsRequest{})
+	checkStatus(t, "unknown token", err, codes.Unauthenticated, "UNAUTHENTICATED")
+
+	_, err = client.ListTeams(withToken("broken"), &pb.ListTeamsRequest{})
+	checkStatus(t, "authenticator failure", err, codes.Internal, "INTERNAL_ERROR")
+
+	_, err = client.CreateTeam(withToken("member"), team)
+	checkStatus(t, "member creates team", err, codes.PermissionDenied, "FORBIDDEN")
+
+	_, err = client.SetUserActive(withToken("member"), &pb.SetUserActiveRequest{UserId: "u3"})
+	checkStatus(t, "member sets user active", err, codes.PermissionDenied, "FORBIDDEN")
+
+	_, err = client.CreateTeam(withToken("lead"), team)
+	checkStatus(t, "lead creates team", err, codes.PermissionDenied, "FORBIDDEN")
+
+	// Ключ x-api-key принимается наравне с Bearer; проверка входа срабатывает
+	// раньше обращения к сервису
+	ctx := metadata.AppendToOutgoingContext(context.Background(), apiKeyKey, "admin")
+	_, err = client.GetTeam(ctx, &pb.GetTeamRequest{})
+	checkStatus(t, "x-api-key", err, codes.InvalidArgument, "INVALID_REQUEST")
+
+	var header metadata.MD
+	_, _ = client.GetTeam(withToken("admin"), &pb.GetTeamRequest{}, grpc.Header(&header))
+	if ids := header.Get(requestIDKey); len(ids) != 1 || ids[0] == "" {
+		t.Errorf("request id header = %v", ids)
+	}
+}
+
+func TestRecoveryInterceptor(t *testing.T) {
+	// Сервис не подключен: обращение к нему паникует в обработчике
+	client := dial(t, NewServer(nil, nil, nil), testAuthenticator("default"))
+
+	for i := 0; i < 2; i++ {
+		_, err := client.ListTeams(withToken("admin"), &pb.ListTeamsRequest{})
+		checkStatus(t, fmt.Sprintf("panic #%d", i+1), err, codes.Internal, "INTERNAL_ERROR")
+	}
+
+	// После паники сервер продолжает отвечать
+	_, err := client.GetUser(withToken("admin"), &pb.GetUserRequest{})
+	checkStatus(t, "after panic", err, codes.InvalidArgument, "INVALID_REQUEST")
+}
+
+func TestServiceErrorMapping(t *testing.T) {
+	ctx := context.Background()
+	for _, k := range apierror.All() {
+		if k.Err == nil {
+			continue
+		}
+		for _, err := range []error{k.Err, fmt.Errorf("context: %w", k.Err)} {
+			checkStatus(t, err.Error(), serviceError(ctx, err), k.GRPC, k.Code)
+		}
+	}
+
+	err := serviceError(ctx, errors.New("connection refused"))
+	checkStatus(t, "unknown error", err, codes.Internal, "INTERNAL_ERROR")
+	if msg := status.Convert(err).Message(); msg != apierror.Internal.Message {
+		t.Errorf("unknown error message %q leaks details", msg)
+	}
+
+	checkStatus(t, "unknown code", statusError("NO_SUCH_CODE", ""), codes.Internal, "INTERNAL_ERROR")
+	if msg := status.Convert(statusError("CONFLICT", "")).Message(); msg != "CONFLICT" {
+		t.Errorf("default message %q, want CONFLICT", msg)
+	}
+}
+
+// Каждый RPC против настоящей БД: успешный ответ и ошибка сервиса
+func TestRPCs(t *testing.T) {
+	storage := testdb.Open(t)
+	orgID := testdb.NewOrg(t, storage, "grpc")
+	client := dial(t, NewServer(
+		services.NewPRService(storage),
+		services.NewTeamService(storage),
+		services.NewUserService(storage),
+	), testAuthenticator(orgID))
+	ctx := withToken("admin")
+
+	members := make([]*pb.User, 0, 4)
+	for i := 1; i <= 4; i++ {
+		members = append(members, &pb.User{UserId: fmt.Sprintf("u%d", i), Username: fmt.Sprintf("User %d", i), IsActive: true})
+	}
+	created, err := client.CreateTeam(ctx, &pb.CreateTeamRequest{Team: &pb.Team{TeamName: "backend", Members: members}})
+	if err != nil {
+		t.Fatalf("CreateTeam: %v", err)
+	}
+	if created.Team.TeamName != "backend" || len(created.Team.Members) != 4 {
+		t.Errorf("CreateTeam = %v", created.Team)
+	}
+	_, err = client.CreateTeam(ctx, &pb.CreateTeamRequest{Team: &pb.Team{TeamName: "backend", Members: members[:1]}})
+	checkStatus(t, "CreateTeam duplicate", err, codes.AlreadyExists, "TEAM_EXISTS")
+
+	team, err := client.GetTeam(ctx, &pb.GetTeamRequest{TeamName: "backend"})
+	if err != nil || len(team.Team.Members) != 4 {
+		t.Errorf("GetTeam = %v, %v", team, err)
+	}
+	_, err = client.GetTeam(ctx, &pb.GetTeamRequest{TeamName: "missing"})
+	checkStatus(t, "GetTeam missing", err, codes.NotFound, "NOT_FOUND")
+
+	teams, err := client.ListTeams(ctx, &pb.ListTeamsRequest{})
+	if err != nil || len(teams.Teams) != 1 {
+		t.Errorf("ListTeams = %v, %v", teams, err)
+	}
+
+	user, err := client.GetUser(ctx, &pb.GetUserRequest{UserId: "u1"})
+	if err != nil || user.User.TeamName != "backend" {
+		t.Errorf("GetUser = %v, %v", user, err)
+	}
+	_, err = client.GetUser(ctx, &pb.GetUserRequest{UserId: "nobody"})
+	checkStatus(t, "GetUser missing", err, codes.NotFound, "NOT_FOUND")
+
+	inactive, err := client.SetUserActive(withToken("lead"), &pb.SetUserActiveRequest{UserId: "u4", IsActive: false})
+	if err != nil || inactive.User.IsActive {
+		t.Errorf("SetUserActive = %v, %v", inactive, err)
+	}
+
+	pr, err := client.CreatePullRequest(ctx, &pb.CreatePullRequestRequest{PullRequestId: "pr-1", PullRequestName: "Add search", AuthorId: "u1"})
+	if err != nil {
+		t.Fatalf("CreatePullRequest: %v", err)
+	}
+	reviewers := pr.PullRequest.AssignedReviewers
+	if pr.PullRequest.Status != "OPEN" || len(reviewers) != 2 {
+		t.Fatalf("CreatePullRequest = %v", pr.PullRequest)
+	}
+	for _, r := range reviewers {
+		if r == "u1" || r == "u4" {
+			t.Errorf("reviewer %s is the author or inactive", r)
+		}
+	}
+	_, err = client.CreatePullRequest(ctx, &pb.CreatePullRequestRequest{PullRequestId: "pr-1", PullRequestName: "Again", AuthorId: "u1"})
+	checkStatus(t, "CreatePullRequest duplicate", err, codes.AlreadyExists, "PR_EXISTS")
+
+	got, err := client.GetPullRequest(ctx, &pb.GetPullRequestRequest{PullRequestId: "pr-1"})
+	if err != nil || got.PullRequest.PullRequestName != "Add search" {
+		t.Errorf("GetPullRequest = %v, %v", got, err)
+	}
+
+	_, err = client.SubmitVerdict(ctx, &pb.SubmitVerdictRequest{PullRequestId: "pr-1", ReviewerId: reviewers[0], Verdict: models.VerdictApproved, Comment: "LGTM"})
+	if err != nil {
+		t.Errorf("SubmitVerdict: %v", err)
+	}
+	_, err = client.SubmitVerdict(ctx, &pb.SubmitVerdictRequest{PullRequestId: "pr-1", ReviewerId: "u1", Verdict: models.VerdictApproved})
+	checkStatus(t, "SubmitVerdict by non-reviewer", err, codes.FailedPrecondition, "NOT_ASSIGNED")
+
+	reviews, err := client.ListUserReviews(ctx, &pb.ListUserReviewsRequest{UserId: reviewers[0]})
+	if err != nil || len(reviews.PullRequests) != 1 || reviews.PullRequests[0].Verdict != models.VerdictApproved {
+		t.Errorf("ListUserReviews = %v, %v", reviews, err)
+	}
+
+	// Единственный свободный кандидат — второй ревьюер уже назначен, автор и
+	// неактивный исключены
+	_, err = client.ReassignReviewer(ctx, &pb.ReassignReviewerRequest{PullRequestId: "pr-1", OldUserId: reviewers[0], Reason: "vacation"})
+	checkStatus(t, "ReassignReviewer without candidates", err, codes.FailedPrecondition, "NO_CANDIDATE")
+	if _, err := client.SetUserActive(ctx, &pb.SetUserActiveRequest{UserId: "u4", IsActive: true}); err != nil {
+		t.Fatal(err)
+	}
+	reassigned, err := client.ReassignReviewer(ctx, &pb.ReassignReviewerRequest{PullRequestId: "pr-1", OldUserId: reviewers[0], Reason: "vacation"})
+	if err != nil || reassigned.ReplacedBy != "u4" {
+		t.Errorf("ReassignReviewer = %v, %v", reassigned, err)
+	}
+
+	merged, err := client.MergePullRequest(ctx, &pb.MergePullRequestRequest{PullRequestId: "pr-1"})
+	if err != nil || merged.PullRequest.Status != "MERGED" || merged.PullRequest.MergedAt == nil {
+		t.Errorf("MergePullRequest = %v, %v", merged, err)
+	}
+	_, err = client.ReassignReviewer(ctx, &pb.ReassignReviewerRequest{PullRequestId: "pr-1", OldUserId: "u4"})
+	checkStatus(t, "ReassignReviewer after merge", err, codes.FailedPrecondition, "PR_MERGED")
+
+	history, err := client.GetPullRequestHistory(ctx, &pb.GetPullRequestHistoryRequest{PullRequestId: "pr-1"})
+	if err != nil {
+		t.Fatalf("GetPullRequestHistory: %v", err)
+	}
+	types := make([]string, 0, len(history.Events))
+	for _, e := range history.Events {
+		types = append(types, e.EventType)
+	}
+	if fmt.Sprint(types) != fmt.Sprint([]string{
+		models.PREventCreated, models.PREventAssigned, models.PREventAssigned,
+		models.PREventVerdict, models.PREventReassigned, models.PREventMerged,
+	}) {
+		t.Errorf("history = %v", types)
+	}
+
+	stats, err := client.GetStats(ctx, &pb.GetStatsRequest{TeamName: "backend"})
+	if err != nil || stats.PullRequests != 1 || stats.Reassignments != 1 {
+		t.Errorf("GetStats = %v, %v", stats, err)
+	}
+	latency, err := client.GetLatencyStats(ctx, &pb.GetLatencyStatsRequest{})
+	if err != nil || latency.Overall == nil {
+		t.Errorf("GetLatencyStats = %v, %v", latency, err)
+	}
+	_, err = client.GetStats(ctx, &pb.GetStatsRequest{From: merged.PullRequest.MergedAt, To: merged.PullRequest.CreatedAt})
+	checkStatus(t, "GetStats with from after to", err, codes.InvalidArgument, "INVALID_REQUEST")
+}
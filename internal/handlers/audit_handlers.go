@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Vimp17/pr-reviewer-service/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+// GetAuditLog обработчик для получения журнала аудита с фильтрами и пагинацией
+func (h *Handlers) GetAuditLog(c *gin.Context) {
+	filter := models.AuditFilter{
+		Actor:      c.Query("actor"),
+		Action:     c.Query("action"),
+		TargetType: c.Query("target_type"),
+		TargetID:   c.Query("target_id"),
+	}
+
+	var err error
+	if filter.From, err = parseTimeQuery(c, "from"); err != nil {
+		invalidQuery(c, "from must be RFC3339 timestamp")
+		return
+	}
+	if filter.To, err = parseTimeQuery(c, "to"); err != nil {
+		invalidQuery(c, "to must be RFC3339 timestamp")
+		return
+	}
+	if filter.Limit, err = parseIntQuery(c, "limit"); err != nil {
+		invalidQuery(c, "limit must be an integer")
+		return
+	}
+	if filter.Offset, err = parseIntQuery(c, "offset"); err != nil {
+		invalidQuery(c, "offset must be an integer")
+		return
+	}
+
+	entries, total, err := h.auditService.ListEntries(c.Request.Context(), filter)
+	if err != nil {
+		internalError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"entries": entries,
+		"total":   total,
+		"offset":  filter.Offset,
+	})
+}
+
+// Вспомогательные функции разбора query-параметров
+
+func parseTimeQuery(c *gin.Context, name string) (*time.Time, error) {
+	value := c.Query(name)
+	if value == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func parseIntQuery(c *gin.Context, name string) (int, error) {
+	value := c.Query(name)
+	if value == "" {
+		return 0, nil
+	}
+	return strconv.Atoi(value)
+}
+
+func invalidQuery(c *gin.Context, message string) {
+	c.JSON(http.StatusBadRequest, gin.H{"error": gin.H{
+		"code":    "INVALID_REQUEST",
+		"message": message,
+	}})
+}
@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/Vimp17/pr-reviewer-service/internal/auth"
+	"github.com/Vimp17/pr-reviewer-service/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+type CreateTokenRequest struct {
+	Name   string `json:"name" binding:"required"`
+	UserID string `json:"user_id"`
+	Role   string `json:"role" binding:"required"`
+}
+
+type RevokeTokenRequest struct {
+	TokenID int64 `json:"token_id" binding:"required"`
+}
+
+// WhoAmI обработчик, возвращающий личность вызывающего
+func (h *Handlers) WhoAmI(c *gin.Context) {
+	identity, _ := auth.FromContext(c.Request.Context())
+	c.JSON(http.StatusOK, gin.H{
+		"org_id":  identity.OrgID,
+		"user_id": identity.UserID,
+		"name":    identity.Name,
+		"roles":   identity.Roles,
+	})
+}
+
+// CreateToken обработчик для выпуска API-токена
+func (h *Handlers) CreateToken(c *gin.Context) {
+	var req CreateTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": gin.H{
+			"code":    "INVALID_REQUEST",
+			"message": "Invalid token data",
+		}})
+		return
+	}
+
+	plain, token, err := h.authService.CreateToken(c.Request.Context(), req.Name, req.UserID, auth.Role(req.Role))
+	if err != nil {
+		respondV1ServiceError(c, err, v1NotFound(services.ErrUserNotFound, "User not found"))
+		return
+	}
+
+	redactOnReplay(c, "token")
+	c.JSON(http.StatusCreated, gin.H{"token": plain, "api_token": token})
+}
+
+// RevokeToken обработчик для отзыва API-токена
+func (h *Handlers) RevokeToken(c *gin.Context) {
+	var req RevokeTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": gin.H{
+			"code":    "INVALID_REQUEST",
+			"message": "Invalid token ID",
+		}})
+		return
+	}
+
+	if err := h.authService.RevokeToken(c.Request.Context(), req.TokenID); err != nil {
+		respondV1ServiceError(c, err, v1NotFound(services.ErrNotFound, "Token not found"))
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
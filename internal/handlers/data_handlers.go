@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/Vimp17/pr-reviewer-service/internal/dataformat"
+	"github.com/Vimp17/pr-reviewer-service/internal/tenant"
+	"github.com/gin-gonic/gin"
+)
+
+// maxImportSize предельный размер тела запроса импорта
+const maxImportSize = 32 << 20
+
+// ImportData обработчик загрузки настроек, команд, пользователей, PR и их
+// истории из выгрузки. Формат задается параметром format или заголовком
+// Content-Type. При ошибках в записях ничего не сохраняется и отчет
+// возвращается с 422; dry_run=true только проверяет записи.
+func (h *Handlers) ImportData(c *gin.Context) {
+	format := c.Query("format")
+	if format == "" {
+		format = dataformat.FromMediaType(c.ContentType())
+	}
+	if err := checkRowsFormat(format); err != nil {
+		invalidQuery(c, err.Error())
+		return
+	}
+	dryRun := c.Query("dry_run") == "true"
+
+	body := http.MaxBytesReader(c.Writer, c.Request.Body, maxImportSize)
+	snapshot, positions, err := dataformat.Decode(body, format)
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			err = fmt.Errorf("request body exceeds %d bytes", tooLarge.Limit)
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": gin.H{
+			"code":    "INVALID_REQUEST",
+			"message": "invalid " + format + " data: " + err.Error(),
+		}})
+		return
+	}
+
+	report, err := h.snapshotService.Import(c.Request.Context(), snapshot, dryRun)
+	if err != nil {
+		internalError(c, err)
+		return
+	}
+	positions.Locate(report.Errors)
+
+	status := http.StatusOK
+	if len(report.Errors) > 0 {
+		status = http.StatusUnprocessableEntity
+	}
+	c.JSON(status, report)
+}
+
+// ExportData обработчик полной выгрузки организации. Формат задается
+// параметром format или заголовком Accept, по умолчанию JSON.
+func (h *Handlers) ExportData(c *gin.Context) {
+	format := rowsFormat(c)
+	if err := checkRowsFormat(format); err != nil {
+		invalidQuery(c, err.Error())
+		return
+	}
+
+	snapshot, err := h.snapshotService.Export(c.Request.Context())
+	if err != nil {
+		internalError(c, err)
+		return
+	}
+
+	filename := fmt.Sprintf("%s-export.%s", tenant.MustOrgID(c.Request.Context()), format)
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.Header("Content-Type", dataformat.ContentType(format))
+	c.Status(http.StatusOK)
+	if err := dataformat.Encode(c.Writer, format, snapshot); err != nil {
+		// Заголовки уже отправлены, остается только записать ошибку в журнал
+		logRequestError(c, err)
+	}
+}
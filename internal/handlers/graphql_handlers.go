@@ -0,0 +1,23 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/Vimp17/pr-reviewer-service/internal/graphqlapi"
+	"github.com/gin-gonic/gin"
+)
+
+// GraphQL обработчик GraphQL-запросов для дашбордов.
+// Ошибки выполнения возвращаются в поле errors ответа со статусом 200.
+func (h *Handlers) GraphQL(c *gin.Context) {
+	var req graphqlapi.Request
+	if err := c.ShouldBindJSON(&req); err != nil || req.Query == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": gin.H{
+			"code":    "INVALID_REQUEST",
+			"message": "query is required",
+		}})
+		return
+	}
+
+	c.JSON(http.StatusOK, h.graphQL.Execute(c.Request.Context(), req))
+}
@@ -1,15 +1,31 @@
 package handlers
 
 import (
+	"time"
+
+	"github.com/Vimp17/pr-reviewer-service/internal/auth"
+	"github.com/Vimp17/pr-reviewer-service/internal/graphqlapi"
+	"github.com/Vimp17/pr-reviewer-service/internal/health"
+	"github.com/Vimp17/pr-reviewer-service/internal/metrics"
 	"github.com/Vimp17/pr-reviewer-service/internal/services"
 	"github.com/gin-gonic/gin"
 )
 
 // Handlers содержит все обработчики HTTP-запросов
 type Handlers struct {
-	prService   *services.PRService
-	teamService *services.TeamService
-	userService *services.UserService
+	prService          *services.PRService
+	teamService        *services.TeamService
+	userService        *services.UserService
+	orgService         *services.OrgService
+	authService        *services.AuthService
+	auditService       *services.AuditService
+	idempotencyService *services.IdempotencyService
+	snapshotService    *services.SnapshotService
+	authenticator      auth.Authenticator
+	graphQL            *graphqlapi.Server
+	validateOpenAPI    bool
+	readiness          *health.Checker
+	writeTimeout       time.Duration
 }
 
 // NewHandlers создает новый экземпляр Handlers с указанными сервисами
@@ -17,41 +33,146 @@ func NewHandlers(
 	prService *services.PRService,
 	teamService *services.TeamService,
 	userService *services.UserService,
+	orgService *services.OrgService,
+	authService *services.AuthService,
+	auditService *services.AuditService,
+	idempotencyService *services.IdempotencyService,
+	snapshotService *services.SnapshotService,
 ) *Handlers {
+	graphQL, err := graphqlapi.NewServer(prService, teamService, userService, graphqlapi.DefaultLimits)
+	if err != nil {
+		panic("graphql: invalid schema: " + err.Error())
+	}
+
 	return &Handlers{
-		prService:   prService,
-		teamService: teamService,
-		userService: userService,
+		prService:          prService,
+		teamService:        teamService,
+		userService:        userService,
+		orgService:         orgService,
+		authService:        authService,
+		auditService:       auditService,
+		idempotencyService: idempotencyService,
+		snapshotService:    snapshotService,
+		authenticator:      authService,
+		graphQL:            graphQL,
+		readiness:          health.NewChecker(health.DefaultTimeout),
 	}
 }
 
+// UseAuthenticator заменяет аутентификатор, используемый AuthMiddleware
+// (по умолчанию проверяются API-токены)
+func (h *Handlers) UseAuthenticator(authenticator auth.Authenticator) {
+	h.authenticator = authenticator
+}
+
+// UseReadinessChecker задает проверки зависимостей для /readyz
+// (по умолчанию проверок нет и сервис всегда готов)
+func (h *Handlers) UseReadinessChecker(checker *health.Checker) {
+	h.readiness = checker
+}
+
+// EnableOpenAPIValidation включает сверку запросов и ответов со спецификацией
+// OpenAPI вне тестового режима gin (в тестовом режиме она включена всегда)
+func (h *Handlers) EnableOpenAPIValidation() {
+	h.validateOpenAPI = true
+}
+
+// UseWriteTimeout задает таймаут записи сервера (http.write_timeout), который
+// потоковые выгрузки CSV и JSONL отсчитывают заново после каждой порции строк
+// (по умолчанию таймаута нет)
+func (h *Handlers) UseWriteTimeout(timeout time.Duration) {
+	h.writeTimeout = timeout
+}
+
 // SetupRoutes регистрирует все маршруты
 func (h *Handlers) SetupRoutes(router *gin.Engine) {
+	router.Use(MetricsMiddleware(), RequestIDMiddleware(), TracingMiddleware(), LoggingMiddleware(), RecoveryMiddleware())
+
+	// Сверка со спецификацией OpenAPI подключается в группах после
+	// аутентификации: вызов без токена получает 401, а не ошибку схемы
+	var validate []gin.HandlerFunc
+	if h.validateOpenAPI || gin.Mode() == gin.TestMode {
+		validate = append(validate, OpenAPIValidationMiddleware())
+	}
+	public := router.Group("/", validate...)
+
 	// Health check
-	router.GET("/health", h.healthHandler)
+	public.GET("/health", h.healthHandler)
+	public.GET("/livez", h.livenessHandler)
+	public.GET("/readyz", h.readinessHandler)
+
+	// Метрики Prometheus
+	public.GET("/metrics", gin.WrapH(metrics.Handler()))
+
+	// Спецификация OpenAPI 3
+	public.GET("/openapi.json", h.openAPIHandler)
+
+	// Все остальные маршруты требуют аутентификации
+	// и работают в рамках организации вызывающего;
+	// POST-запросы поддерживают заголовок Idempotency-Key
+	api := router.Group("/")
+	api.Use(h.AuthMiddleware())
+	api.Use(validate...)
+	api.Use(h.IdempotencyMiddleware())
+
+	admin := RequireRoles(auth.RoleAdmin)
+
+	// Auth endpoints
+	authGroup := api.Group("/auth")
+	{
+		authGroup.GET("/whoami", h.WhoAmI)
+		authGroup.POST("/createToken", admin, h.CreateToken)
+		authGroup.POST("/revokeToken", admin, h.RevokeToken)
+	}
+
+	// Organization endpoints
+	org := api.Group("/organization")
+	{
+		org.POST("/add", admin, h.CreateOrganization)
+		org.GET("/get", h.GetOrganization)
+		org.POST("/setSettings", admin, h.UpdateOrgSettings)
+	}
 
 	// Teams endpoints
-	teams := router.Group("/team")
+	teams := api.Group("/team")
 	{
-		teams.POST("/add", h.CreateTeam)
+		teams.POST("/add", admin, h.CreateTeam)
 		teams.GET("/get", h.GetTeam)
 	}
 
 	// Users endpoints
-	users := router.Group("/users")
+	users := api.Group("/users")
 	{
-		users.POST("/setIsActive", h.SetUserActiveStatus)
+		users.POST("/setIsActive", RequireRoles(auth.RoleAdmin, auth.RoleTeamLead), h.SetUserActiveStatus)
 		users.GET("/getReview", h.GetPRsForReviewer)
+		users.GET("/list", h.ListUsers)
 	}
 
 	// PR endpoints
-	pr := router.Group("/pullRequest")
+	pr := api.Group("/pullRequest")
 	{
 		pr.POST("/create", h.CreatePR)
 		pr.POST("/merge", h.MergePR)
-		pr.POST("/reassign", h.ReassignReviewer)
+		pr.POST("/reassign", RequireRoles(auth.RoleAdmin, auth.RoleTeamLead, auth.RoleMember), h.ReassignReviewer)
+		pr.POST("/verdict", h.SubmitVerdict)
+		pr.GET("/history", h.GetPRHistory)
+		pr.GET("/list", h.ListPRs)
 	}
 
 	// Дополнительный эндпоинт статистики
-	router.GET("/stats", h.GetStats)
+	api.GET("/stats", h.GetStats)
+	api.GET("/stats/latency", h.GetLatencyStats)
+
+	// Журнал аудита
+	api.GET("/audit", admin, h.GetAuditLog)
+
+	// Загрузка и выгрузка данных организации
+	api.POST("/import", admin, h.ImportData)
+	api.GET("/export", admin, h.ExportData)
+
+	// GraphQL для дашбордов
+	api.POST("/graphql", h.GraphQL)
+
+	// Ресурсно-ориентированный API v2 с единым форматом ответов
+	h.setupV2Routes(router, validate...)
 }
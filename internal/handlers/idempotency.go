@@ -0,0 +1,210 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+
+	"github.com/Vimp17/pr-reviewer-service/internal/apierror"
+	"github.com/Vimp17/pr-reviewer-service/internal/models"
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc/codes"
+)
+
+// IdempotencyKeyHeader заголовок с ключом идемпотентности
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// maxIdempotencyKeyLength ограничение длины ключа (размер колонки в БД)
+const maxIdempotencyKeyLength = 255
+
+// redactedFieldKey ключ gin.Context с именем поля ответа, которое не сохраняется
+const redactedFieldKey = "idempotency.redacted_field"
+
+// redactedValue значение секретного поля в сохраненном ответе
+const redactedValue = "[REDACTED]"
+
+// redactOnReplay помечает поле ответа как секрет (например, открытый токен):
+// при сохранении для повторов его значение заменяется на redactedValue на любой
+// глубине, а сам секрет получает только первый ответ
+func redactOnReplay(c *gin.Context, field string) {
+	c.Set(redactedFieldKey, field)
+}
+
+// redactJSON заменяет значения поля field в JSON-документе
+func redactJSON(body []byte, field string) ([]byte, error) {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var doc any
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, err
+	}
+
+	var walk func(v any)
+	walk = func(v any) {
+		switch v := v.(type) {
+		case map[string]any:
+			for k, child := range v {
+				if k == field {
+					v[k] = redactedValue
+					continue
+				}
+				walk(child)
+			}
+		case []any:
+			for _, child := range v {
+				walk(child)
+			}
+		}
+	}
+	walk(doc)
+	return json.Marshal(doc)
+}
+
+// retryableError проверяет, что ответ — ошибка, после которой повтор может
+// завершиться иначе (CONFLICT при конкурентном изменении PR): такой ответ
+// не сохраняется, чтобы повтор с тем же ключом выполнился заново
+func retryableError(status int, body []byte) bool {
+	if status != http.StatusConflict {
+		return false
+	}
+	var response struct {
+		Error struct {
+			Code string `json:"code"`
+		} `json:"error"`
+	}
+	if json.Unmarshal(body, &response) != nil {
+		return false
+	}
+	kind, ok := apierror.ByCode(response.Error.Code)
+	return ok && kind.GRPC == codes.Aborted
+}
+
+// responseRecorder копирует тело ответа для сохранения
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// IdempotencyMiddleware обрабатывает заголовок Idempotency-Key для POST-запросов:
+// первый ответ сохраняется и воспроизводится без изменений для повторов того же
+// вызывающего с тем же телом и параметрами, повтор ключа с другим телом или
+// параметрами отклоняется с 422. Секретные поля ответа (redactOnReplay) в
+// сохраненной копии заменяются на redactedValue.
+func (h *Handlers) IdempotencyMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyKeyHeader)
+		if c.Request.Method != http.MethodPost || key == "" {
+			c.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			abortError(c, "INVALID_REQUEST", "Idempotency-Key is too long")
+			return
+		}
+
+		// Тело хэшируется целиком, поэтому читается не больше, чем принимает
+		// самый крупный эндпоинт (импорт)
+		body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxImportSize))
+		if err != nil {
+			message := "Invalid request body"
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				message = fmt.Sprintf("request body exceeds %d bytes", tooLarge.Limit)
+			}
+			abortError(c, "INVALID_REQUEST", message)
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		// Ключ привязан к методу, пути и параметрам запроса: тот же ключ на другом
+		// эндпоинте или с другими параметрами (dry_run, format) — другой запрос.
+		// Параметры берутся в каноническом виде, порядок в URL не важен.
+		hash := sha256.New()
+		hash.Write([]byte(c.Request.Method + " " + c.Request.URL.Path + "?" + c.Request.URL.Query().Encode() + "\n"))
+		hash.Write(body)
+		requestHash := hex.EncodeToString(hash.Sum(nil))
+
+		ctx := c.Request.Context()
+		stored, err := h.idempotencyService.Begin(ctx, key, requestHash)
+		if err != nil {
+			kind, ok := apierror.FromError(err)
+			if !ok {
+				logRequestError(c, err)
+			}
+			abortError(c, kind.Code, "")
+			return
+		}
+
+		if stored != nil {
+			c.Header("Idempotent-Replayed", "true")
+			c.Data(stored.StatusCode, stored.ContentType, stored.Body)
+			c.Abort()
+			return
+		}
+
+		// Резерв снимается и сохраняется ответ, даже если клиент уже отключился
+		ctx = context.WithoutCancel(ctx)
+		release := func() {
+			if err := h.idempotencyService.Release(ctx, key); err != nil {
+				slog.ErrorContext(ctx, "failed to release idempotency key", "key", key, "error", err)
+			}
+		}
+		// Паника обработчика не должна оставить ключ зарезервированным навсегда:
+		// снимаем резерв и передаем панику дальше, в Recovery
+		defer func() {
+			if p := recover(); p != nil {
+				release()
+				panic(p)
+			}
+		}()
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		c.Next()
+
+		// Ответы с ошибкой сервера и конфликты, которые стоит повторить, не
+		// сохраняем: повтор должен выполниться заново
+		status := recorder.Status()
+		body = recorder.body.Bytes()
+		if status >= http.StatusInternalServerError || retryableError(status, body) {
+			release()
+			return
+		}
+
+		if field := c.GetString(redactedFieldKey); field != "" {
+			if body, err = redactJSON(body, field); err != nil {
+				// Секрет нельзя сохранить как есть: повтор выполнится заново
+				slog.ErrorContext(ctx, "failed to redact idempotent response", "key", key, "error", err)
+				release()
+				return
+			}
+		}
+
+		err = h.idempotencyService.Complete(ctx, key, models.IdempotentResponse{
+			RequestHash: requestHash,
+			StatusCode:  status,
+			ContentType: recorder.Header().Get("Content-Type"),
+			Body:        body,
+		})
+		if err != nil {
+			slog.ErrorContext(ctx, "failed to store idempotent response", "key", key, "error", err)
+		}
+	}
+}
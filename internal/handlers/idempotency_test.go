@@ -0,0 +1,240 @@
+package handlers
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Vimp17/pr-reviewer-service/internal/auth"
+	"github.com/Vimp17/pr-reviewer-service/internal/services"
+	"github.com/Vimp17/pr-reviewer-service/internal/testdb"
+	"github.com/gin-gonic/gin"
+)
+
+// testUserHeader задает в тестах пользователя, от имени которого идет запрос
+const testUserHeader = "X-Test-User"
+
+// idempotencyRouter собирает роутер с IdempotencyMiddleware перед handler;
+// без testUserHeader запрос идет от администратора организации
+func idempotencyRouter(h *Handlers, orgID string, handler gin.HandlerFunc) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(RecoveryMiddleware(), func(c *gin.Context) {
+		ctx := testdb.AdminContext(orgID)
+		if user := c.GetHeader(testUserHeader); user != "" {
+			ctx = testdb.IdentityContext(&auth.Identity{OrgID: orgID, UserID: user, Roles: []auth.Role{auth.RoleAdmin}})
+		}
+		c.Request = c.Request.WithContext(ctx)
+	}, h.IdempotencyMiddleware())
+	router.POST("/op", handler)
+	router.POST("/other", handler)
+	return router
+}
+
+func idempotentPost(router http.Handler, key string, body []byte) *httptest.ResponseRecorder {
+	return idempotentPostTo(router, "/op", key, body)
+}
+
+func idempotentPostTo(router http.Handler, target, key string, body []byte) *httptest.ResponseRecorder {
+	return idempotentPostAs(router, "", target, key, body)
+}
+
+func idempotentPostAs(router http.Handler, user, target, key string, body []byte) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, target, bytes.NewReader(body))
+	req.Header.Set(IdempotencyKeyHeader, key)
+	if user != "" {
+		req.Header.Set(testUserHeader, user)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestIdempotencyBodyLimit(t *testing.T) {
+	called := false
+	router := idempotencyRouter(&Handlers{}, "default", func(c *gin.Context) { called = true })
+
+	w := idempotentPost(router, "big", make([]byte, maxImportSize+1))
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "exceeds") {
+		t.Errorf("oversized body: %d %s", w.Code, w.Body.String())
+	}
+	if called {
+		t.Error("handler called for oversized body")
+	}
+}
+
+func TestIdempotencyReleaseOnPanic(t *testing.T) {
+	storage := testdb.Open(t)
+	orgID := testdb.NewOrg(t, storage, "idempotency")
+	h := &Handlers{idempotencyService: services.NewIdempotencyService(storage, 0)}
+
+	calls := 0
+	router := idempotencyRouter(h, orgID, func(c *gin.Context) {
+		calls++
+		if calls == 1 {
+			panic("boom")
+		}
+		c.JSON(http.StatusCreated, gin.H{"call": calls})
+	})
+
+	body := []byte(`{"name":"x"}`)
+	if w := idempotentPost(router, "k1", body); w.Code != http.StatusInternalServerError {
+		t.Fatalf("panicking handler: status %d", w.Code)
+	}
+	// Резерв снят: повтор выполняется заново, а не получает 409 IDEMPOTENCY_IN_PROGRESS
+	w := idempotentPost(router, "k1", body)
+	if w.Code != http.StatusCreated || calls != 2 {
+		t.Fatalf("retry after panic: status %d, calls %d, body %s", w.Code, calls, w.Body.String())
+	}
+	// Успешный ответ сохранен и воспроизводится
+	w = idempotentPost(router, "k1", body)
+	if w.Code != http.StatusCreated || calls != 2 || w.Header().Get("Idempotent-Replayed") != "true" {
+		t.Errorf("replay: status %d, calls %d, replayed %q", w.Code, calls, w.Header().Get("Idempotent-Replayed"))
+	}
+}
+
+// Тот же ключ с другими параметрами запроса или на другом пути — другой запрос:
+// 422 вместо воспроизведения чужого ответа
+func TestIdempotencyKeyCoversQuery(t *testing.T) {
+	storage := testdb.Open(t)
+	orgID := testdb.NewOrg(t, storage, "idempotency")
+	h := &Handlers{idempotencyService: services.NewIdempotencyService(storage, 0)}
+
+	calls := 0
+	router := idempotencyRouter(h, orgID, func(c *gin.Context) {
+		calls++
+		c.JSON(http.StatusOK, gin.H{"dry_run": c.Query("dry_run")})
+	})
+
+	body := []byte(`{"teams":[]}`)
+	if w := idempotentPostTo(router, "/op?dry_run=true&format=json", "k1", body); w.Code != http.StatusOK {
+		t.Fatalf("first request: %d %s", w.Code, w.Body.String())
+	}
+	for _, target := range []string{"/op", "/op?dry_run=false&format=json", "/op?dry_run=true&format=csv", "/other?dry_run=true&format=json"} {
+		w := idempotentPostTo(router, target, "k1", body)
+		if w.Code != http.StatusUnprocessableEntity || errorCode(t, w.Body.Bytes()) != "IDEMPOTENCY_KEY_REUSED" {
+			t.Errorf("%s: %d %s, want 422 IDEMPOTENCY_KEY_REUSED", target, w.Code, w.Body.String())
+		}
+	}
+	// Порядок параметров не важен
+	w := idempotentPostTo(router, "/op?format=json&dry_run=true", "k1", body)
+	if w.Code != http.StatusOK || w.Header().Get("Idempotent-Replayed") != "true" || calls != 1 {
+		t.Errorf("replay with reordered query: %d, replayed %q, calls %d", w.Code, w.Header().Get("Idempotent-Replayed"), calls)
+	}
+}
+
+// Ключ принадлежит вызывающему: другой пользователь организации с тем же ключом
+// выполняет свой запрос, а не получает ответ первого
+func TestIdempotencyKeyIsScopedToCaller(t *testing.T) {
+	storage := testdb.Open(t)
+	orgID := testdb.NewOrg(t, storage, "idempotency")
+	h := &Handlers{idempotencyService: services.NewIdempotencyService(storage, 0)}
+
+	router := idempotencyRouter(h, orgID, func(c *gin.Context) {
+		identity, _ := auth.FromContext(c.Request.Context())
+		c.JSON(http.StatusCreated, gin.H{"caller": identity.Actor()})
+	})
+
+	body := []byte(`{"name":"x"}`)
+	for _, user := range []string{"u1", "u2"} {
+		w := idempotentPostAs(router, user, "/op", "shared", body)
+		if w.Code != http.StatusCreated || w.Header().Get("Idempotent-Replayed") != "" || !strings.Contains(w.Body.String(), user) {
+			t.Errorf("%s: %d %s, replayed %q", user, w.Code, w.Body.String(), w.Header().Get("Idempotent-Replayed"))
+		}
+	}
+	w := idempotentPostAs(router, "u1", "/op", "shared", body)
+	if w.Header().Get("Idempotent-Replayed") != "true" || !strings.Contains(w.Body.String(), "u1") {
+		t.Errorf("replay for u1: %s, replayed %q", w.Body.String(), w.Header().Get("Idempotent-Replayed"))
+	}
+}
+
+// Открытый токен получает только первый ответ, повтор — заглушку
+func TestIdempotencyRedactsSecrets(t *testing.T) {
+	storage := testdb.Open(t)
+	orgID := testdb.NewOrg(t, storage, "idempotency")
+	h := &Handlers{idempotencyService: services.NewIdempotencyService(storage, 0)}
+
+	router := idempotencyRouter(h, orgID, func(c *gin.Context) {
+		redactOnReplay(c, "token")
+		respondData(c, http.StatusCreated, gin.H{"token": "prs_secret", "api_token": gin.H{"token_id": 7}})
+	})
+
+	body := []byte(`{"name":"ci","role":"bot"}`)
+	if w := idempotentPost(router, "k1", body); !strings.Contains(w.Body.String(), "prs_secret") {
+		t.Fatalf("first response: %s", w.Body.String())
+	}
+	w := idempotentPost(router, "k1", body)
+	if w.Code != http.StatusCreated || w.Header().Get("Idempotent-Replayed") != "true" {
+		t.Fatalf("replay: %d, replayed %q", w.Code, w.Header().Get("Idempotent-Replayed"))
+	}
+	if got := w.Body.String(); strings.Contains(got, "prs_secret") || !strings.Contains(got, `"token":"`+redactedValue+`"`) || !strings.Contains(got, `"token_id":7`) {
+		t.Errorf("replayed body %s", got)
+	}
+}
+
+// 409 CONFLICT не сохраняется: повтор с тем же ключом выполняется заново
+func TestIdempotencyDoesNotStoreConflicts(t *testing.T) {
+	storage := testdb.Open(t)
+	orgID := testdb.NewOrg(t, storage, "idempotency")
+	h := &Handlers{idempotencyService: services.NewIdempotencyService(storage, 0)}
+
+	calls := 0
+	router := idempotencyRouter(h, orgID, func(c *gin.Context) {
+		calls++
+		if calls == 1 {
+			respondError(c, "CONFLICT", "")
+			return
+		}
+		if calls == 2 {
+			respondError(c, "PR_EXISTS", "")
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"call": calls})
+	})
+
+	body := []byte(`{"pull_request_id":"pr-1"}`)
+	if w := idempotentPost(router, "k1", body); w.Code != http.StatusConflict {
+		t.Fatalf("first request: %d", w.Code)
+	}
+	// Другой 409 не предполагает повтора и сохраняется как обычный ответ
+	if w := idempotentPost(router, "k1", body); w.Code != http.StatusConflict || errorCode(t, w.Body.Bytes()) != "PR_EXISTS" || calls != 2 {
+		t.Fatalf("retry after CONFLICT: %d %s, calls %d", w.Code, w.Body.String(), calls)
+	}
+	w := idempotentPost(router, "k1", body)
+	if w.Header().Get("Idempotent-Replayed") != "true" || errorCode(t, w.Body.Bytes()) != "PR_EXISTS" || calls != 2 {
+		t.Errorf("replay of PR_EXISTS: %s, calls %d", w.Body.String(), calls)
+	}
+}
+
+func TestRedactJSON(t *testing.T) {
+	got, err := redactJSON([]byte(`{"data":{"token":"prs_x","api_token":{"token_id":9007199254740993,"name":"ci"}}}`), "token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"data":{"api_token":{"name":"ci","token_id":9007199254740993},"token":"[REDACTED]"}}`; string(got) != want {
+		t.Errorf("redacted %s, want %s", got, want)
+	}
+	if _, err := redactJSON([]byte("token=prs_x"), "token"); err == nil {
+		t.Error("non-JSON body redacted without error")
+	}
+}
+
+func TestRetryableError(t *testing.T) {
+	tests := []struct {
+		status int
+		body   string
+		want   bool
+	}{
+		{http.StatusConflict, `{"error":{"code":"CONFLICT","message":"retry"}}`, true},
+		{http.StatusConflict, `{"error":{"code":"PR_EXISTS","message":"exists"}}`, false},
+		{http.StatusOK, `{"error":{"code":"CONFLICT"}}`, false},
+		{http.StatusConflict, `not json`, false},
+	}
+	for _, tt := range tests {
+		if got := retryableError(tt.status, []byte(tt.body)); got != tt.want {
+			t.Errorf("retryableError(%d, %s) = %v, want %v", tt.status, tt.body, got, tt.want)
+		}
+	}
+}
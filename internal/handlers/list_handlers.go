@@ -0,0 +1,172 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Vimp17/pr-reviewer-service/internal/dataformat"
+	"github.com/Vimp17/pr-reviewer-service/internal/models"
+	"github.com/Vimp17/pr-reviewer-service/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// parsePRListQuery разбирает фильтры списка PR; ошибка содержит сообщение для клиента
+func parsePRListQuery(c *gin.Context) (models.PRListFilter, error) {
+	filter := models.PRListFilter{
+		Status:       c.Query("status"),
+		AuthorID:     c.Query("author_id"),
+		ReviewerID:   c.Query("reviewer_id"),
+		TeamName:     c.Query("team_name"),
+		NameContains: c.Query("q"),
+	}
+	if filter.Status != "" && filter.Status != "OPEN" && filter.Status != "MERGED" {
+		return filter, errors.New("status must be OPEN or MERGED")
+	}
+
+	var err error
+	for _, p := range []struct {
+		name  string
+		value **time.Time
+	}{
+		{"created_from", &filter.CreatedFrom},
+		{"created_to", &filter.CreatedTo},
+		{"merged_from", &filter.MergedFrom},
+		{"merged_to", &filter.MergedTo},
+	} {
+		if *p.value, err = parseTimeQuery(c, p.name); err != nil {
+			return filter, errors.New(p.name + " must be RFC3339 timestamp")
+		}
+	}
+	if filter.Limit, err = parseIntQuery(c, "limit"); err != nil {
+		return filter, errors.New("limit must be an integer")
+	}
+	return filter, nil
+}
+
+// parseUserListQuery разбирает фильтры списка пользователей
+func parseUserListQuery(c *gin.Context) (models.UserListFilter, error) {
+	filter := models.UserListFilter{
+		TeamName: c.Query("team_name"),
+		Query:    c.Query("q"),
+	}
+	if value := c.Query("is_active"); value != "" {
+		isActive, err := strconv.ParseBool(value)
+		if err != nil {
+			return filter, errors.New("is_active must be true or false")
+		}
+		filter.IsActive = &isActive
+	}
+
+	var err error
+	if filter.Limit, err = parseIntQuery(c, "limit"); err != nil {
+		return filter, errors.New("limit must be an integer")
+	}
+	return filter, nil
+}
+
+// parseReviewQuery разбирает фильтры списка ревью: status (через запятую
+// или повторением параметра: OPEN, MERGED) и limit
+func parseReviewQuery(c *gin.Context, reviewerID string) (models.ReviewFilter, error) {
+	filter := models.ReviewFilter{ReviewerID: reviewerID}
+	for _, value := range c.QueryArray("status") {
+		for _, status := range strings.Split(value, ",") {
+			status = strings.ToUpper(strings.TrimSpace(status))
+			if status != "OPEN" && status != "MERGED" {
+				return filter, errors.New("status must be OPEN or MERGED")
+			}
+			filter.Statuses = append(filter.Statuses, status)
+		}
+	}
+
+	var err error
+	if filter.Limit, err = parseIntQuery(c, "limit"); err != nil {
+		return filter, errors.New("limit must be an integer")
+	}
+	return filter, nil
+}
+
+// listError отвечает на ошибку сервиса списка в формате v1
+func listError(c *gin.Context, err error) {
+	switch err {
+	case services.ErrInvalidCursor:
+		c.JSON(http.StatusBadRequest, gin.H{"error": gin.H{
+			"code":    "INVALID_CURSOR",
+			"message": "cursor is malformed or was issued for another sort",
+		}})
+	case services.ErrInvalidSort:
+		c.JSON(http.StatusBadRequest, gin.H{"error": gin.H{
+			"code":    "INVALID_SORT",
+			"message": "unsupported sort field",
+		}})
+	default:
+		internalError(c, err)
+	}
+}
+
+// nextCursor возвращает курсор для ответа: null, если страница последняя
+func nextCursor(cursor string) any {
+	if cursor == "" {
+		return nil
+	}
+	return cursor
+}
+
+// ListPRs обработчик для списка PR с фильтрами, сортировкой и курсорной пагинацией
+func (h *Handlers) ListPRs(c *gin.Context) {
+	filter, err := parsePRListQuery(c)
+	if err != nil {
+		invalidQuery(c, err.Error())
+		return
+	}
+
+	if format := rowsFormat(c); format != dataformat.JSON {
+		if err := checkRowsFormat(format); err != nil {
+			invalidQuery(c, err.Error())
+			return
+		}
+		h.streamPRs(c, filter, format, listError)
+		return
+	}
+
+	prs, next, err := h.prService.ListPRs(c.Request.Context(), filter, c.Query("sort"), c.Query("cursor"))
+	if err != nil {
+		listError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"pull_requests": prs,
+		"next_cursor":   nextCursor(next),
+	})
+}
+
+// streamPRs отдает все PR по фильтру, сортировке и курсору запроса в CSV или
+// JSONL; limit, если задан, ограничивает число строк
+func (h *Handlers) streamPRs(c *gin.Context, filter models.PRListFilter, format string, onError func(*gin.Context, error)) {
+	streamRows(c, h.writeTimeout, format, dataformat.PullRequestColumns, func(fn func(models.PullRequest) error) error {
+		return h.prService.StreamPRs(c.Request.Context(), filter, c.Query("sort"), c.Query("cursor"), fn)
+	}, onError)
+}
+
+// ListUsers обработчик для списка пользователей с фильтрами, сортировкой и курсорной пагинацией
+func (h *Handlers) ListUsers(c *gin.Context) {
+	filter, err := parseUserListQuery(c)
+	if err != nil {
+		invalidQuery(c, err.Error())
+		return
+	}
+
+	users, next, err := h.userService.ListUsers(c.Request.Context(), filter, c.Query("sort"), c.Query("cursor"))
+	if err != nil {
+		listError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"users":       users,
+		"next_cursor": nextCursor(next),
+	})
+}
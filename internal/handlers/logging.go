@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// LoggingMiddleware пишет в журнал каждый запрос: метод, маршрут, статус и длительность.
+// Должен стоять после RequestIDMiddleware, чтобы запись содержала request_id.
+func LoggingMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		status := c.Writer.Status()
+		level := slog.LevelInfo
+		if status >= http.StatusInternalServerError {
+			level = slog.LevelError
+		}
+		slog.Log(c.Request.Context(), level, "http request",
+			"method", c.Request.Method,
+			"route", c.FullPath(),
+			"path", c.Request.URL.Path,
+			"status", status,
+			"duration_ms", float64(time.Since(start).Microseconds())/1000,
+			"client_ip", c.ClientIP(),
+		)
+	}
+}
+
+// RecoveryMiddleware перехватывает панику обработчика, пишет ее в журнал
+// и отвечает 500 в формате версии маршрута
+func RecoveryMiddleware() gin.HandlerFunc {
+	return gin.CustomRecoveryWithWriter(nil, func(c *gin.Context, recovered any) {
+		// Обрыв потокового ответа: net/http молча закрывает соединение
+		if recovered == http.ErrAbortHandler {
+			panic(recovered)
+		}
+		slog.ErrorContext(c.Request.Context(), "panic recovered",
+			"method", c.Request.Method,
+			"route", c.FullPath(),
+			"panic", recovered,
+			"stack", string(debug.Stack()),
+		)
+		abortError(c, "INTERNAL_ERROR", "")
+	})
+}
+
+// internalError пишет в журнал ошибку, из-за которой запрос завершается с 500,
+// и отвечает в формате v1
+func internalError(c *gin.Context, err error) {
+	logRequestError(c, err)
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+}
+
+// logRequestError пишет в журнал ошибку обработки запроса с его маршрутом
+func logRequestError(c *gin.Context, err error) {
+	slog.ErrorContext(c.Request.Context(), "request failed",
+		"method", c.Request.Method,
+		"route", c.FullPath(),
+		"error", err,
+	)
+}
@@ -0,0 +1,23 @@
+package handlers
+
+import (
+	"time"
+
+	"github.com/Vimp17/pr-reviewer-service/internal/metrics"
+	"github.com/gin-gonic/gin"
+)
+
+// MetricsMiddleware учитывает каждый HTTP-запрос в метриках по шаблону
+// маршрута gin (а не фактическому пути), чтобы число рядов не зависело от ID
+func MetricsMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		metrics.ObserveHTTP(c.Request.Method, route, c.Writer.Status(), time.Since(start))
+	}
+}
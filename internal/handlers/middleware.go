@@ -1,8 +1,14 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
+	"strings"
 
+	"github.com/Vimp17/pr-reviewer-service/internal/apierror"
+	"github.com/Vimp17/pr-reviewer-service/internal/auth"
+	"github.com/Vimp17/pr-reviewer-service/internal/requestid"
+	"github.com/Vimp17/pr-reviewer-service/internal/tenant"
 	"github.com/gin-gonic/gin"
 )
 
@@ -13,6 +19,7 @@ func ErrorHandler() gin.HandlerFunc {
 		// Обрабатываем паники
 		if len(c.Errors) > 0 {
 			err := c.Errors.Last()
+			logRequestError(c, err)
 			c.JSON(http.StatusInternalServerError, gin.H{"error": gin.H{
 				"code":    "INTERNAL_ERROR",
 				"message": err.Error(),
@@ -27,3 +34,86 @@ func ErrorHandler() gin.HandlerFunc {
 		}
 	}
 }
+
+// RequestIDMiddleware присваивает запросу идентификатор, принимая входящий X-Request-ID,
+// и возвращает его в ответе
+func RequestIDMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(requestid.Header)
+		if id == "" || len(id) > 128 {
+			id = requestid.New()
+		}
+
+		c.Header(requestid.Header, id)
+		c.Request = c.Request.WithContext(requestid.WithRequestID(c.Request.Context(), id))
+		c.Next()
+	}
+}
+
+// APIKeyHeader альтернативный заголовок для передачи API-токена
+const APIKeyHeader = "X-API-Key"
+
+// AuthMiddleware аутентифицирует вызывающего и привязывает контекст запроса
+// к его личности и организации
+func (h *Handlers) AuthMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token := c.GetHeader(APIKeyHeader)
+		if header := c.GetHeader("Authorization"); token == "" && strings.HasPrefix(header, "Bearer ") {
+			token = strings.TrimSpace(strings.TrimPrefix(header, "Bearer "))
+		}
+
+		identity, err := h.authenticator.Authenticate(c.Request.Context(), token)
+		if err != nil {
+			if errors.Is(err, auth.ErrUnauthenticated) {
+				abortError(c, "UNAUTHENTICATED", "missing or invalid API token")
+				return
+			}
+			logRequestError(c, err)
+			abortError(c, "INTERNAL_ERROR", "")
+			return
+		}
+
+		ctx := auth.WithIdentity(c.Request.Context(), identity)
+		ctx = tenant.WithOrgID(ctx, identity.OrgID)
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
+
+// RequireRoles пропускает только вызывающих с одной из указанных ролей
+func RequireRoles(roles ...auth.Role) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		identity, ok := auth.FromContext(c.Request.Context())
+		if !ok || !identity.HasRole(roles...) {
+			abortError(c, "FORBIDDEN", "operation is not permitted for caller role")
+			return
+		}
+		c.Next()
+	}
+}
+
+// v2Prefix префикс маршрутов API v2
+const v2Prefix = "/api/v2"
+
+// abortError прерывает запрос ошибкой общего для v1 и v2 middleware в формате
+// версии маршрута: v2 — respondError, v1 — прежние {"error": {"code", "message"}}
+// и {"error": "Internal server error"} для внутренних ошибок
+func abortError(c *gin.Context, code, message string) {
+	if path := c.Request.URL.Path; path == v2Prefix || strings.HasPrefix(path, v2Prefix+"/") {
+		respondError(c, code, message)
+		return
+	}
+
+	kind, _ := apierror.ByCode(code)
+	if kind.Code == apierror.Internal.Code {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+	if message == "" {
+		message = kind.Message
+	}
+	c.AbortWithStatusJSON(kind.HTTP, gin.H{"error": gin.H{
+		"code":    kind.Code,
+		"message": message,
+	}})
+}
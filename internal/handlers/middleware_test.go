@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Vimp17/pr-reviewer-service/internal/auth"
+	"github.com/gin-gonic/gin"
+)
+
+// stubAuthenticator возвращает заданную личность или ошибку
+type stubAuthenticator struct {
+	identity *auth.Identity
+	err      error
+}
+
+func (a stubAuthenticator) Authenticate(context.Context, string) (*auth.Identity, error) {
+	return a.identity, a.err
+}
+
+// Ошибки middleware на маршрутах v1 сохраняют прежние тела, на v2 — формат v2
+func TestMiddlewareErrorShapes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	member := &auth.Identity{OrgID: "default", UserID: "u1", Roles: []auth.Role{auth.RoleMember}}
+
+	tests := []struct {
+		name   string
+		authn  stubAuthenticator
+		path   string
+		status int
+		want   string
+	}{
+		{"v1 unauthenticated", stubAuthenticator{err: auth.ErrUnauthenticated}, "/team/add", http.StatusUnauthorized,
+			`{"error":{"code":"UNAUTHENTICATED","message":"missing or invalid API token"}}`},
+		{"v1 internal", stubAuthenticator{err: errors.New("db down")}, "/team/add", http.StatusInternalServerError,
+			`{"error":"Internal server error"}`},
+		{"v1 forbidden", stubAuthenticator{identity: member}, "/team/add", http.StatusForbidden,
+			`{"error":{"code":"FORBIDDEN","message":"operation is not permitted for caller role"}}`},
+		{"v2 unauthenticated", stubAuthenticator{err: auth.ErrUnauthenticated}, "/api/v2/teams", http.StatusUnauthorized,
+			`{"error":{"code":"UNAUTHENTICATED","message":"missing or invalid API token","request_id":""}}`},
+		{"v2 internal", stubAuthenticator{err: errors.New("db down")}, "/api/v2/teams", http.StatusInternalServerError,
+			`{"error":{"code":"INTERNAL_ERROR","message":"internal server error","request_id":""}}`},
+		{"v2 forbidden", stubAuthenticator{identity: member}, "/api/v2/teams", http.StatusForbidden,
+			`{"error":{"code":"FORBIDDEN","message":"operation is not permitted for caller role","request_id":""}}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := &Handlers{}
+			h.UseAuthenticator(tt.authn)
+			router := gin.New()
+			ok := func(c *gin.Context) { c.Status(http.StatusCreated) }
+			router.POST("/team/add", h.AuthMiddleware(), RequireRoles(auth.RoleAdmin), ok)
+			router.POST("/api/v2/teams", h.AuthMiddleware(), RequireRoles(auth.RoleAdmin), ok)
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, tt.path, nil))
+			if w.Code != tt.status {
+				t.Fatalf("status %d, want %d", w.Code, tt.status)
+			}
+			var got, want any
+			if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+				t.Fatalf("body %s: %v", w.Body.String(), err)
+			}
+			json.Unmarshal([]byte(tt.want), &want)
+			gotJSON, _ := json.Marshal(got)
+			wantJSON, _ := json.Marshal(want)
+			if string(gotJSON) != string(wantJSON) {
+				t.Errorf("body %s, want %s", gotJSON, wantJSON)
+			}
+		})
+	}
+}
+
+func TestRecoveryErrorShapes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(RecoveryMiddleware())
+	boom := func(c *gin.Context) { panic("boom") }
+	router.GET("/team/get", boom)
+	router.GET("/api/v2/teams", boom)
+
+	for path, want := range map[string]string{
+		"/team/get":     `{"error":"Internal server error"}`,
+		"/api/v2/teams": `{"error":{"code":"INTERNAL_ERROR","message":"internal server error","request_id":""}}`,
+	} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		if w.Code != http.StatusInternalServerError || w.Body.String() != want {
+			t.Errorf("%s: %d %s, want %s", path, w.Code, w.Body.String(), want)
+		}
+	}
+}
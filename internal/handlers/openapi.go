@@ -0,0 +1,197 @@
+package handlers
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"net/http"
+
+	"github.com/Vimp17/pr-reviewer-service/internal/dataformat"
+	"github.com/Vimp17/pr-reviewer-service/internal/openapi"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/getkin/kin-openapi/routers/legacy"
+	"github.com/gin-gonic/gin"
+)
+
+// openAPIHandler отдает спецификацию OpenAPI 3
+func (h *Handlers) openAPIHandler(c *gin.Context) {
+	spec, err := openapi.JSON()
+	if err != nil {
+		logRequestError(c, err)
+		respondError(c, "INTERNAL_ERROR", "")
+		return
+	}
+	c.Data(http.StatusOK, "application/json; charset=utf-8", spec)
+}
+
+// bufferedWriter придерживает ответ, пока он не будет сверен со спецификацией.
+// Потоковые ответы (CSV, JSONL) не придерживаются: они передаются клиенту
+// сразу и со спецификацией не сверяются, иначе выгрузка целиком оседала бы в
+// памяти.
+type bufferedWriter struct {
+	gin.ResponseWriter
+	status    int
+	streaming bool
+	body      bytes.Buffer
+}
+
+// streamingResponse сообщает, что ответ с заголовками header отдается потоком
+func streamingResponse(header http.Header) bool {
+	mediaType, _, err := mime.ParseMediaType(header.Get("Content-Type"))
+	return err == nil && (mediaType == dataformat.MediaTypeCSV || mediaType == dataformat.MediaTypeJSONL)
+}
+
+func (w *bufferedWriter) WriteHeader(code int) {
+	if w.status != 0 {
+		return
+	}
+	w.status = code
+	if streamingResponse(w.Header()) {
+		w.streaming = true
+		w.ResponseWriter.WriteHeader(code)
+	}
+}
+
+func (w *bufferedWriter) WriteHeaderNow() {
+	if w.status == 0 {
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.streaming {
+		w.ResponseWriter.WriteHeaderNow()
+	}
+}
+
+func (w *bufferedWriter) Write(data []byte) (int, error) {
+	w.WriteHeaderNow()
+	if w.streaming {
+		return w.ResponseWriter.Write(data)
+	}
+	return w.body.Write(data)
+}
+
+func (w *bufferedWriter) WriteString(s string) (int, error) {
+	w.WriteHeaderNow()
+	if w.streaming {
+		return w.ResponseWriter.WriteString(s)
+	}
+	return w.body.WriteString(s)
+}
+
+// Flush отправляет только потоковый ответ; остальные придерживаются до сверки
+func (w *bufferedWriter) Flush() {
+	if w.streaming {
+		w.ResponseWriter.Flush()
+	}
+}
+
+// Unwrap открывает исходный writer для http.ResponseController
+func (w *bufferedWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func (w *bufferedWriter) Status() int {
+	if w.status == 0 {
+		return http.StatusOK
+	}
+	return w.status
+}
+
+func (w *bufferedWriter) Size() int {
+	if w.streaming {
+		return w.ResponseWriter.Size()
+	}
+	if w.status == 0 {
+		return -1
+	}
+	return w.body.Len()
+}
+
+func (w *bufferedWriter) Written() bool {
+	return w.status != 0
+}
+
+// OpenAPIValidationMiddleware сверяет запросы и ответы со спецификацией OpenAPI.
+// Некорректный запрос отклоняется с INVALID_REQUEST, а ответ, не соответствующий
+// спецификации, заменяется на INTERNAL_ERROR — так расхождение handlers и
+// спецификации сразу видно в тестах. Потоковые ответы CSV и JSONL проходят
+// без сверки. Аутентификация здесь не проверяется: middleware подключается
+// после AuthMiddleware.
+func OpenAPIValidationMiddleware() gin.HandlerFunc {
+	spec, err := openapi.Spec()
+	if err != nil {
+		panic("openapi: invalid embedded spec: " + err.Error())
+	}
+	router, err := legacy.NewRouter(spec)
+	if err != nil {
+		panic("openapi: " + err.Error())
+	}
+	// Тело JSONL (импорт и выгрузки) сверяется как строка, как и CSV
+	openapi3filter.RegisterBodyDecoder(dataformat.MediaTypeJSONL, openapi3filter.FileBodyDecoder)
+
+	options := &openapi3filter.Options{
+		AuthenticationFunc:    openapi3filter.NoopAuthenticationFunc,
+		IncludeResponseStatus: true,
+	}
+
+	return func(c *gin.Context) {
+		// Неизвестные gin маршруты отвечают 404 сами по себе
+		if c.FullPath() == "" {
+			c.Next()
+			return
+		}
+
+		route, pathParams, err := router.FindRoute(c.Request)
+		if err != nil {
+			if err == routers.ErrPathNotFound || err == routers.ErrMethodNotAllowed {
+				logRequestError(c, err)
+				abortError(c, "INTERNAL_ERROR", "route is not described in OpenAPI spec: "+c.Request.Method+" "+c.FullPath())
+				return
+			}
+			abortError(c, "INVALID_REQUEST", err.Error())
+			return
+		}
+
+		input := &openapi3filter.RequestValidationInput{
+			Request:    c.Request,
+			PathParams: pathParams,
+			Route:      route,
+			Options:    options,
+		}
+		if err := openapi3filter.ValidateRequest(c.Request.Context(), input); err != nil {
+			abortError(c, "INVALID_REQUEST", err.Error())
+			return
+		}
+
+		original := c.Writer
+		buffered := &bufferedWriter{ResponseWriter: original}
+		c.Writer = buffered
+		c.Next()
+		c.Writer = original
+		if buffered.streaming {
+			return
+		}
+
+		status := buffered.Status()
+		err = openapi3filter.ValidateResponse(c.Request.Context(), &openapi3filter.ResponseValidationInput{
+			RequestValidationInput: input,
+			Status:                 status,
+			Header:                 original.Header(),
+			Body:                   io.NopCloser(bytes.NewReader(buffered.body.Bytes())),
+			Options:                options,
+		})
+		if err != nil {
+			original.Header().Del("Content-Type")
+			logRequestError(c, err)
+			abortError(c, "INTERNAL_ERROR", "response does not match OpenAPI spec: "+err.Error())
+			return
+		}
+
+		original.WriteHeader(status)
+		if buffered.body.Len() > 0 {
+			original.Write(buffered.body.Bytes())
+		} else {
+			original.WriteHeaderNow()
+		}
+	}
+}
@@ -0,0 +1,220 @@
+package handlers
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Vimp17/pr-reviewer-service/internal/auth"
+	"github.com/Vimp17/pr-reviewer-service/internal/dataformat"
+	"github.com/Vimp17/pr-reviewer-service/internal/health"
+	"github.com/Vimp17/pr-reviewer-service/internal/models"
+	"github.com/Vimp17/pr-reviewer-service/internal/openapi"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/routers/legacy"
+	"github.com/gin-gonic/gin"
+)
+
+var publicRoutes = map[string]bool{
+	"/health": true, "/livez": true, "/readyz": true, "/metrics": true, "/openapi.json": true,
+}
+
+// testRouter собирает роутер сервиса без БД; сверка с OpenAPI включена тестовым режимом gin
+func testRouter(authn auth.Authenticator) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	h := NewHandlers(nil, nil, nil, nil, nil, nil, nil, nil)
+	h.UseAuthenticator(authn)
+	h.UseReadinessChecker(health.NewChecker(health.DefaultTimeout))
+	router := gin.New()
+	h.SetupRoutes(router)
+	return router
+}
+
+// routePath подставляет значения параметров пути gin
+func routePath(path string) string {
+	parts := strings.Split(path, "/")
+	for i, p := range parts {
+		if strings.HasPrefix(p, ":") {
+			parts[i] = "x"
+		}
+	}
+	return strings.Join(parts, "/")
+}
+
+// requiresInput сообщает, что операция требует тело или параметр запроса
+func requiresInput(op *openapi3.Operation) bool {
+	if op.RequestBody != nil && op.RequestBody.Value.Required {
+		return true
+	}
+	for _, p := range op.Parameters {
+		if p.Value.In == openapi3.ParameterInQuery && p.Value.Required {
+			return true
+		}
+	}
+	return false
+}
+
+func errorCode(t *testing.T, body []byte) string {
+	t.Helper()
+	var resp struct {
+		Error json.RawMessage `json:"error"`
+	}
+	var detail struct {
+		Code string `json:"code"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil || json.Unmarshal(resp.Error, &detail) != nil {
+		t.Fatalf("unexpected error body %s", body)
+	}
+	return detail.Code
+}
+
+// Каждый маршрут описан в спецификации и проходит через сверку: без токена —
+// 401 (аутентификация раньше сверки), с токеном и без обязательных данных — 400
+func TestRoutesWithOpenAPIValidation(t *testing.T) {
+	spec, err := openapi.Spec()
+	if err != nil {
+		t.Fatal(err)
+	}
+	specRouter, err := legacy.NewRouter(spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	anonymous := testRouter(stubAuthenticator{err: auth.ErrUnauthenticated})
+	admin := testRouter(stubAuthenticator{identity: auth.SystemIdentity("default", "test")})
+
+	routes := admin.Routes()
+	if len(routes) < 40 {
+		t.Fatalf("only %d routes registered", len(routes))
+	}
+	for _, r := range routes {
+		name := r.Method + " " + r.Path
+		path := routePath(r.Path)
+
+		req := httptest.NewRequest(r.Method, path, nil)
+		route, _, err := specRouter.FindRoute(req)
+		if err != nil {
+			t.Errorf("%s: not described in OpenAPI spec: %v", name, err)
+			continue
+		}
+
+		if publicRoutes[r.Path] {
+			w := httptest.NewRecorder()
+			anonymous.ServeHTTP(w, httptest.NewRequest(r.Method, path, nil))
+			if w.Code != http.StatusOK {
+				t.Errorf("%s: status %d, body %s", name, w.Code, w.Body.String())
+			}
+			continue
+		}
+
+		// Некорректное тело не мешает ответить 401
+		w := httptest.NewRecorder()
+		anonymous.ServeHTTP(w, httptest.NewRequest(r.Method, path+"?limit=bad", strings.NewReader("{")))
+		if w.Code != http.StatusUnauthorized || errorCode(t, w.Body.Bytes()) != "UNAUTHENTICATED" {
+			t.Errorf("%s without token: status %d, body %s", name, w.Code, w.Body.String())
+		}
+
+		if !requiresInput(route.Operation) {
+			continue
+		}
+		w = httptest.NewRecorder()
+		admin.ServeHTTP(w, httptest.NewRequest(r.Method, path, nil))
+		if w.Code != http.StatusBadRequest || errorCode(t, w.Body.Bytes()) != "INVALID_REQUEST" {
+			t.Errorf("%s without required input: status %d, body %s", name, w.Code, w.Body.String())
+		}
+	}
+}
+
+// streamingServer запускает сервер с таймаутом записи timeout и выгрузкой
+// rows строк JSONL; pause вызывается перед каждой порцией строк после первой
+func streamingServer(t *testing.T, timeout time.Duration, rows int, pause func(batch int)) *httptest.Server {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/pullRequest/list", OpenAPIValidationMiddleware(), func(c *gin.Context) {
+		streamRows(c, timeout, dataformat.JSONL, dataformat.PullRequestColumns, func(fn func(models.PullRequest) error) error {
+			for i := 0; i < rows; i++ {
+				if i > 0 && i%streamBatch == 0 {
+					pause(i / streamBatch)
+				}
+				if err := fn(models.PullRequest{PullRequestID: fmt.Sprintf("pr-%d", i), Status: "OPEN"}); err != nil {
+					return err
+				}
+			}
+			return nil
+		}, func(c *gin.Context, err error) { t.Errorf("stream error: %v", err) })
+	})
+
+	server := httptest.NewUnstartedServer(router)
+	server.Config.WriteTimeout = timeout
+	server.Start()
+	t.Cleanup(server.Close)
+	return server
+}
+
+// streamBatch строк до отправки буфера клиенту
+const streamBatch = 100
+
+// readStream читает выгрузку JSONL и возвращает число строк; received
+// закрывается после первой порции
+func readStream(t *testing.T, url string, received chan struct{}) (int, error) {
+	resp, err := http.Get(url + "/pullRequest/list?format=jsonl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || !strings.HasPrefix(resp.Header.Get("Content-Type"), dataformat.MediaTypeJSONL) {
+		t.Fatalf("status %d, content type %q", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+
+	lines := 0
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		if lines++; lines == streamBatch {
+			close(received)
+		}
+	}
+	return lines, scanner.Err()
+}
+
+// Потоковый ответ проходит сверку без буферизации и не обрывается таймаутом
+// записи сервера, пока порции строк отправляются чаще таймаута: первые строки
+// доходят до клиента, пока выгрузка еще идет
+func TestStreamingResponsePassesThrough(t *testing.T) {
+	const batches = 4
+	received := make(chan struct{})
+	server := streamingServer(t, 200*time.Millisecond, batches*streamBatch, func(batch int) {
+		if batch == 1 {
+			select {
+			case <-received:
+			case <-time.After(5 * time.Second):
+				t.Error("first rows were not sent before the stream ended")
+			}
+		}
+		time.Sleep(120 * time.Millisecond) // в сумме дольше таймаута записи
+	})
+
+	lines, err := readStream(t, server.URL, received)
+	if err != nil || lines != batches*streamBatch {
+		t.Errorf("read %d rows, want %d (error %v)", lines, batches*streamBatch, err)
+	}
+}
+
+// Порция, которую не удалось отправить за таймаут записи, обрывает поток:
+// выгрузка не держит соединение бесконечно
+func TestStreamingResponseStallTimesOut(t *testing.T) {
+	received := make(chan struct{})
+	server := streamingServer(t, 100*time.Millisecond, 2*streamBatch, func(int) {
+		<-received
+		time.Sleep(300 * time.Millisecond) // дольше таймаута записи
+	})
+
+	lines, _ := readStream(t, server.URL, received)
+	if lines != streamBatch {
+		t.Errorf("read %d rows, want the stream cut after %d", lines, streamBatch)
+	}
+}
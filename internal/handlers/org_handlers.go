@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/Vimp17/pr-reviewer-service/internal/models"
+	"github.com/Vimp17/pr-reviewer-service/internal/services"
+	"github.com/Vimp17/pr-reviewer-service/internal/tenant"
+	"github.com/gin-gonic/gin"
+)
+
+type CreateOrganizationRequest struct {
+	OrgID string `json:"org_id" binding:"required"`
+	Name  string `json:"name" binding:"required"`
+}
+
+type UpdateOrgSettingsRequest struct {
+	ReviewersPerPR     *int   `json:"reviewers_per_pr" binding:"required"`
+	AssignmentStrategy string `json:"assignment_strategy" binding:"required"`
+}
+
+// CreateOrganization обработчик для создания организации
+func (h *Handlers) CreateOrganization(c *gin.Context) {
+	var req CreateOrganizationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": gin.H{
+			"code":    "INVALID_REQUEST",
+			"message": "Invalid organization data",
+		}})
+		return
+	}
+
+	org, plain, err := h.orgService.CreateOrganization(c.Request.Context(), req.OrgID, req.Name)
+	if err != nil {
+		respondV1ServiceError(c, err)
+		return
+	}
+
+	// Первый токен администратора новой организации получает только первый ответ
+	redactOnReplay(c, "admin_token")
+	c.JSON(http.StatusCreated, gin.H{"organization": org, "admin_token": plain})
+}
+
+// GetOrganization обработчик для получения организации вызывающего и ее настроек
+func (h *Handlers) GetOrganization(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	org, err := h.orgService.GetOrganization(ctx, tenant.MustOrgID(ctx))
+	if err != nil {
+		respondV1ServiceError(c, err, v1NotFound(services.ErrOrgNotFound, "Organization not found"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"organization": org})
+}
+
+// UpdateOrgSettings обработчик для изменения настроек назначения организации
+func (h *Handlers) UpdateOrgSettings(c *gin.Context) {
+	var req UpdateOrgSettingsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": gin.H{
+			"code":    "INVALID_REQUEST",
+			"message": "Invalid settings",
+		}})
+		return
+	}
+
+	org, err := h.orgService.UpdateSettings(c.Request.Context(), models.OrgSettings{
+		ReviewersPerPR:     *req.ReviewersPerPR,
+		AssignmentStrategy: req.AssignmentStrategy,
+	})
+	if err != nil {
+		respondV1ServiceError(c, err, v1NotFound(services.ErrOrgNotFound, "Organization not found"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"organization": org})
+}
@@ -21,6 +21,14 @@ type MergePRRequest struct {
 type ReassignReviewerRequest struct {
 	PullRequestID string `json:"pull_request_id" binding:"required"`
 	OldUserID     string `json:"old_user_id" binding:"required"`
+	Reason        string `json:"reason"`
+}
+
+type SubmitVerdictRequest struct {
+	PullRequestID string `json:"pull_request_id" binding:"required"`
+	ReviewerID    string `json:"reviewer_id" binding:"required"`
+	Verdict       string `json:"verdict" binding:"required"`
+	Comment       string `json:"comment"`
 }
 
 // CreatePR обработчик для создания PR
@@ -45,20 +53,7 @@ func (h *Handlers) CreatePR(c *gin.Context) {
 	// Вызываем сервис
 	createdPR, err := h.prService.CreatePR(c.Request.Context(), pr)
 	if err != nil {
-		switch {
-		case err == services.ErrPRExists:
-			c.JSON(http.StatusConflict, gin.H{"error": gin.H{
-				"code":    "PR_EXISTS",
-				"message": "PR id already exists",
-			}})
-		case err == services.ErrAuthorNotFound:
-			c.JSON(http.StatusNotFound, gin.H{"error": gin.H{
-				"code":    "NOT_FOUND",
-				"message": "Author not found",
-			}})
-		default:
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
-		}
+		respondV1ServiceError(c, err, v1NotFound(services.ErrAuthorNotFound, "Author not found"))
 		return
 	}
 
@@ -78,14 +73,7 @@ func (h *Handlers) MergePR(c *gin.Context) {
 
 	pr, err := h.prService.MergePR(c.Request.Context(), req.PullRequestID)
 	if err != nil {
-		if err == services.ErrNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": gin.H{
-				"code":    "NOT_FOUND",
-				"message": "PR not found",
-			}})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		respondV1ServiceError(c, err, v1NotFound(services.ErrNotFound, "PR not found"))
 		return
 	}
 
@@ -103,29 +91,60 @@ func (h *Handlers) ReassignReviewer(c *gin.Context) {
 		return
 	}
 
-	pr, newReviewer, err := h.prService.ReassignReviewer(c.Request.Context(), req.PullRequestID, req.OldUserID)
+	pr, newReviewer, err := h.prService.ReassignReviewer(c.Request.Context(), req.PullRequestID, req.OldUserID, req.Reason)
 	if err != nil {
-		switch {
-		case err == services.ErrPRMerged:
-			c.JSON(http.StatusConflict, gin.H{"error": gin.H{
-				"code":    "PR_MERGED",
-				"message": "cannot reassign on merged PR",
-			}})
-		case err == services.ErrNotAssigned:
-			c.JSON(http.StatusConflict, gin.H{"error": gin.H{
-				"code":    "NOT_ASSIGNED",
-				"message": "reviewer is not assigned to this PR",
-			}})
-		case err == services.ErrNoCandidate:
-			c.JSON(http.StatusConflict, gin.H{"error": gin.H{
-				"code":    "NO_CANDIDATE",
-				"message": "no active replacement candidate in team",
-			}})
-		default:
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
-		}
+		respondV1ServiceError(c, err,
+			v1Error{Err: services.ErrPRMerged, Message: "cannot reassign on merged PR"},
+			v1NotFound(services.ErrNotFound, "PR not found"),
+		)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"pr": pr, "replaced_by": newReviewer})
 }
+
+// SubmitVerdict обработчик для вердикта ревьюера
+func (h *Handlers) SubmitVerdict(c *gin.Context) {
+	var req SubmitVerdictRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": gin.H{
+			"code":    "INVALID_REQUEST",
+			"message": "Invalid request",
+		}})
+		return
+	}
+
+	err := h.prService.SubmitVerdict(c.Request.Context(), req.PullRequestID, req.ReviewerID, req.Verdict, req.Comment)
+	if err != nil {
+		respondV1ServiceError(c, err,
+			v1Error{Err: services.ErrPRMerged, Message: "cannot submit verdict on merged PR"},
+			v1NotFound(services.ErrNotFound, "PR not found"),
+		)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// GetPRHistory обработчик для получения истории назначений PR
+func (h *Handlers) GetPRHistory(c *gin.Context) {
+	prID := c.Query("pull_request_id")
+	if prID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": gin.H{
+			"code":    "INVALID_REQUEST",
+			"message": "pull_request_id is required",
+		}})
+		return
+	}
+
+	events, err := h.prService.GetPRHistory(c.Request.Context(), prID)
+	if err != nil {
+		respondV1ServiceError(c, err, v1NotFound(services.ErrNotFound, "PR not found"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"pull_request_id": prID,
+		"events":          events,
+	})
+}
@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Vimp17/pr-reviewer-service/internal/dataformat"
+	"github.com/gin-gonic/gin"
+)
+
+// rowsFormat выбирает формат ответа списка: параметр format или заголовок
+// Accept (text/csv, application/x-ndjson), по умолчанию JSON
+func rowsFormat(c *gin.Context) string {
+	if format := c.Query("format"); format != "" {
+		return format
+	}
+	return acceptedFormat(c.GetHeader("Accept"))
+}
+
+// acceptedFormat выбирает первый поддерживаемый формат из заголовка Accept;
+// JSON, если подходящего нет
+func acceptedFormat(accept string) string {
+	for _, value := range strings.Split(accept, ",") {
+		if format := dataformat.FromMediaType(strings.TrimSpace(value)); format != "" {
+			return format
+		}
+	}
+	return dataformat.JSON
+}
+
+// checkRowsFormat проверяет формат табличного ответа
+func checkRowsFormat(format string) error {
+	if !dataformat.Valid(format) {
+		return errors.New("format must be json, jsonl or csv")
+	}
+	return nil
+}
+
+// streamRows отдает строки в CSV или JSONL по мере того, как stream передает
+// их в fn, без накопления ответа в памяти. Ошибка до первой строки (например,
+// неверный курсор) передается onError как обычный ответ с ошибкой. Ошибка
+// после начала вывода записывается в журнал и обрывает соединение, чтобы
+// клиент не принял неполный ответ за полный. Таймаут записи writeTimeout
+// (http.write_timeout) отсчитывается заново после каждой отправленной порции
+// строк: выгрузка может идти дольше, но клиент, который перестал читать, за
+// время таймаута обрывает поток и освобождает соединение с БД.
+func streamRows[T any](
+	c *gin.Context,
+	writeTimeout time.Duration,
+	format string,
+	columns []dataformat.Column[T],
+	stream func(fn func(T) error) error,
+	onError func(*gin.Context, error),
+) {
+	w := &deadlineWriter{ResponseWriter: c.Writer, controller: http.NewResponseController(c.Writer), timeout: writeTimeout}
+	w.extend()
+
+	c.Header("Content-Type", dataformat.ContentType(format))
+	rows := dataformat.NewRowWriter(w, format, columns)
+
+	err := stream(rows.Write)
+	if err == nil {
+		err = rows.Close()
+	}
+	if err == nil {
+		return
+	}
+
+	if !rows.Started() {
+		c.Writer.Header().Del("Content-Type")
+		onError(c, err)
+		return
+	}
+	logRequestError(c, err)
+	panic(http.ErrAbortHandler)
+}
+
+// deadlineWriter переносит дедлайн записи соединения на timeout вперед после
+// каждой отправки буфера клиенту; нулевой timeout снимает дедлайн
+type deadlineWriter struct {
+	gin.ResponseWriter
+	controller *http.ResponseController
+	timeout    time.Duration
+}
+
+func (w *deadlineWriter) Flush() {
+	w.ResponseWriter.Flush()
+	w.extend()
+}
+
+func (w *deadlineWriter) extend() {
+	var deadline time.Time
+	if w.timeout > 0 {
+		deadline = time.Now().Add(w.timeout)
+	}
+	// Ошибка означает, что writer не поддерживает дедлайны (например, в тестах)
+	_ = w.controller.SetWriteDeadline(deadline)
+}
@@ -1,23 +1,132 @@
 package handlers
 
 import (
+	"errors"
+	"log/slog"
 	"net/http"
 
+	"github.com/Vimp17/pr-reviewer-service/internal/apierror"
+	"github.com/Vimp17/pr-reviewer-service/internal/dataformat"
+	"github.com/Vimp17/pr-reviewer-service/internal/health"
+	"github.com/Vimp17/pr-reviewer-service/internal/models"
+	"github.com/Vimp17/pr-reviewer-service/internal/services"
 	"github.com/gin-gonic/gin"
-	// Добавлен импорт services
 )
 
+// parseStatsQuery разбирает охват статистики: team_name и период from/to (RFC3339)
+func parseStatsQuery(c *gin.Context) (models.StatsFilter, error) {
+	filter := models.StatsFilter{TeamName: c.Query("team_name")}
+
+	var err error
+	if filter.From, err = parseTimeQuery(c, "from"); err != nil {
+		return filter, errors.New("from must be RFC3339 timestamp")
+	}
+	if filter.To, err = parseTimeQuery(c, "to"); err != nil {
+		return filter, errors.New("to must be RFC3339 timestamp")
+	}
+	if filter.From != nil && filter.To != nil && !filter.From.Before(*filter.To) {
+		return filter, errors.New("from must be before to")
+	}
+	return filter, nil
+}
+
 // GetStats обработчик для получения статистики назначений
 func (h *Handlers) GetStats(c *gin.Context) {
-	stats, err := h.prService.GetAssignmentStats(c.Request.Context())
+	filter, err := parseStatsQuery(c)
+	if err != nil {
+		invalidQuery(c, err.Error())
+		return
+	}
+
+	if format := rowsFormat(c); format != dataformat.JSON {
+		table, err := parseStatsTable(c)
+		if err == nil {
+			err = checkRowsFormat(format)
+		}
+		if err != nil {
+			invalidQuery(c, err.Error())
+			return
+		}
+		h.streamStats(c, filter, format, table, statsError)
+		return
+	}
+
+	stats, err := h.prService.GetStats(c.Request.Context(), filter)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get stats"})
+		statsError(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, stats)
 }
 
+// parseStatsTable разбирает таблицу /stats для ответа в CSV или JSONL:
+// reviewers (по умолчанию) или teams
+func parseStatsTable(c *gin.Context) (string, error) {
+	table := c.DefaultQuery("table", "reviewers")
+	if table != "reviewers" && table != "teams" {
+		return "", errors.New("table must be reviewers or teams")
+	}
+	return table, nil
+}
+
+// streamStats отдает таблицу статистики в CSV или JSONL; строки в том же
+// порядке и составе, что и в JSON-ответе
+func (h *Handlers) streamStats(c *gin.Context, filter models.StatsFilter, format, table string, onError func(*gin.Context, error)) {
+	ctx := c.Request.Context()
+	if table == "teams" {
+		streamRows(c, h.writeTimeout, format, dataformat.TeamStatsColumns, func(fn func(models.TeamStats) error) error {
+			return h.prService.StreamTeamStats(ctx, filter, fn)
+		}, onError)
+		return
+	}
+	streamRows(c, h.writeTimeout, format, dataformat.ReviewerStatsColumns, func(fn func(models.ReviewerStats) error) error {
+		return h.prService.StreamReviewerStats(ctx, filter, fn)
+	}, onError)
+}
+
+// GetLatencyStats обработчик для аналитики задержек ревью и слияния
+func (h *Handlers) GetLatencyStats(c *gin.Context) {
+	filter, err := parseStatsQuery(c)
+	if err != nil {
+		invalidQuery(c, err.Error())
+		return
+	}
+
+	stats, err := h.prService.GetLatencyStats(c.Request.Context(), filter)
+	if err != nil {
+		statsError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, stats)
+}
+
+// statsError отвечает на ошибку сервиса статистики в формате v1
+func statsError(c *gin.Context, err error) {
+	if _, ok := apierror.FromError(err); ok {
+		respondV1ServiceError(c, err, v1NotFound(services.ErrNotFound, "team not found"))
+		return
+	}
+	logRequestError(c, err)
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get stats"})
+}
+
 // healthHandler обработчик для проверки работоспособности
 func (h *Handlers) healthHandler(c *gin.Context) {
 	c.Status(http.StatusOK)
 }
+
+// livenessHandler отвечает, пока процесс обслуживает запросы; зависимости не проверяются
+func (h *Handlers) livenessHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": health.StatusOK})
+}
+
+// readinessHandler выполняет проверки зависимостей и отвечает 503, если хотя бы одна не прошла
+func (h *Handlers) readinessHandler(c *gin.Context) {
+	report := h.readiness.Run(c.Request.Context())
+	status := http.StatusOK
+	if report.Status != health.StatusOK {
+		status = http.StatusServiceUnavailable
+		slog.WarnContext(c.Request.Context(), "readiness check failed", "checks", report.Checks)
+	}
+	c.JSON(status, report)
+}
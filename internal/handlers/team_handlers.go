@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/Vimp17/pr-reviewer-service/internal/models"
@@ -48,14 +49,7 @@ func (h *Handlers) CreateTeam(c *gin.Context) {
 	// Вызываем сервис
 	createdTeam, err := h.teamService.CreateTeam(c.Request.Context(), team)
 	if err != nil {
-		if err == services.ErrTeamExists {
-			c.JSON(http.StatusBadRequest, gin.H{"error": gin.H{
-				"code":    "TEAM_EXISTS",
-				"message": "team_name already exists",
-			}})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		respondV1ServiceError(c, err, v1Error{Err: services.ErrTeamExists, Status: http.StatusBadRequest})
 		return
 	}
 
@@ -75,14 +69,14 @@ func (h *Handlers) GetTeam(c *gin.Context) {
 
 	team, err := h.teamService.GetTeam(c.Request.Context(), teamName)
 	if err != nil {
-		if err.Error() == "NOT_FOUND" {
+		if errors.Is(err, services.ErrNotFound) {
 			c.JSON(http.StatusNotFound, gin.H{"error": gin.H{
 				"code":    "NOT_FOUND",
 				"message": "Team not found",
 			}})
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		internalError(c, err)
 		return
 	}
 
@@ -3,8 +3,8 @@ package handlers
 import (
 	"net/http"
 
+	"github.com/Vimp17/pr-reviewer-service/internal/services"
 	"github.com/gin-gonic/gin"
-	// Добавлен импорт services
 )
 
 type SetUserActiveRequest struct {
@@ -25,21 +25,15 @@ func (h *Handlers) SetUserActiveStatus(c *gin.Context) {
 
 	user, err := h.userService.SetUserActiveStatus(c.Request.Context(), req.UserID, req.IsActive)
 	if err != nil {
-		if err.Error() == "NOT_FOUND" {
-			c.JSON(http.StatusNotFound, gin.H{"error": gin.H{
-				"code":    "NOT_FOUND",
-				"message": "User not found",
-			}})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		respondV1ServiceError(c, err, v1NotFound(services.ErrNotFound, "User not found"))
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"user": user})
 }
 
-// GetPRsForReviewer обработчик для получения PR, где пользователь назначен ревьювером
+// GetPRsForReviewer обработчик для получения PR, где пользователь назначен ревьювером.
+// По умолчанию возвращаются только открытые PR; поддерживаются status, limit и cursor.
 func (h *Handlers) GetPRsForReviewer(c *gin.Context) {
 	userID := c.Query("user_id")
 	if userID == "" {
@@ -50,14 +44,21 @@ func (h *Handlers) GetPRsForReviewer(c *gin.Context) {
 		return
 	}
 
-	prs, err := h.userService.GetPRsForReviewer(c.Request.Context(), userID)
+	filter, err := parseReviewQuery(c, userID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		invalidQuery(c, err.Error())
+		return
+	}
+
+	prs, next, err := h.userService.GetPRsForReviewer(c.Request.Context(), filter, c.Query("cursor"))
+	if err != nil {
+		listError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"user_id":       userID,
 		"pull_requests": prs,
+		"next_cursor":   nextCursor(next),
 	})
 }
@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/Vimp17/pr-reviewer-service/internal/apierror"
+	"github.com/gin-gonic/gin"
+)
+
+// v1Error прежний ответ эндпоинта v1 на ошибку сервиса там, где он отличается
+// от таблицы apierror; пустые поля берутся из таблицы
+type v1Error struct {
+	Err     error
+	Status  int
+	Code    string
+	Message string
+}
+
+// respondV1ServiceError переводит ошибку сервиса в ответ v1
+// {"error": {"code", "message"}}. Ошибка распознается через apierror.FromError,
+// поэтому обернутые ошибки тоже; overrides сохраняют прежние статусы, коды и
+// сообщения эндпоинта. Неизвестные ошибки — {"error": "Internal server error"}
+// с записью в журнал.
+func respondV1ServiceError(c *gin.Context, err error, overrides ...v1Error) {
+	kind, ok := apierror.FromError(err)
+	if !ok {
+		internalError(c, err)
+		return
+	}
+
+	status, code, message := kind.HTTP, kind.Code, kind.Message
+	for _, o := range overrides {
+		if !errors.Is(err, o.Err) {
+			continue
+		}
+		if o.Status != 0 {
+			status = o.Status
+		}
+		if o.Code != "" {
+			code = o.Code
+		}
+		if o.Message != "" {
+			message = o.Message
+		}
+		break
+	}
+	c.JSON(status, gin.H{"error": gin.H{
+		"code":    code,
+		"message": message,
+	}})
+}
+
+// v1NotFound прежний ответ v1 на отсутствующий ресурс err
+func v1NotFound(err error, message string) v1Error {
+	return v1Error{Err: err, Status: http.StatusNotFound, Code: "NOT_FOUND", Message: message}
+}
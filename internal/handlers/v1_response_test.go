@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Vimp17/pr-reviewer-service/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// Ошибки v1 распознаются и обернутыми, а тела сохраняют прежние статусы,
+// коды и сообщения эндпоинтов
+func TestRespondV1ServiceError(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	overrides := []v1Error{
+		v1NotFound(services.ErrAuthorNotFound, "Author not found"),
+		{Err: services.ErrTeamExists, Status: http.StatusBadRequest},
+		{Err: services.ErrPRMerged, Message: "cannot reassign on merged PR"},
+	}
+
+	tests := []struct {
+		name   string
+		err    error
+		status int
+		want   string
+	}{
+		{"wrapped conflict", fmt.Errorf("reassign: %w", services.ErrConflict), http.StatusConflict,
+			`{"error":{"code":"CONFLICT","message":"PR was modified concurrently, retry the request"}}`},
+		{"forbidden", services.ErrForbidden, http.StatusForbidden,
+			`{"error":{"code":"FORBIDDEN","message":"operation is not permitted for caller"}}`},
+		{"code override", fmt.Errorf("create: %w", services.ErrAuthorNotFound), http.StatusNotFound,
+			`{"error":{"code":"NOT_FOUND","message":"Author not found"}}`},
+		{"status override", services.ErrTeamExists, http.StatusBadRequest,
+			`{"error":{"code":"TEAM_EXISTS","message":"team_name already exists"}}`},
+		{"message override", services.ErrPRMerged, http.StatusConflict,
+			`{"error":{"code":"PR_MERGED","message":"cannot reassign on merged PR"}}`},
+		{"internal", errors.New("db down"), http.StatusInternalServerError,
+			`{"error":"Internal server error"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodPost, "/pullRequest/reassign", nil)
+
+			respondV1ServiceError(c, tt.err, overrides...)
+			if w.Code != tt.status || w.Body.String() != tt.want {
+				t.Errorf("got %d %s, want %d %s", w.Code, w.Body.String(), tt.status, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,448 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/Vimp17/pr-reviewer-service/internal/auth"
+	"github.com/Vimp17/pr-reviewer-service/internal/dataformat"
+	"github.com/Vimp17/pr-reviewer-service/internal/models"
+	"github.com/Vimp17/pr-reviewer-service/internal/tenant"
+	"github.com/gin-gonic/gin"
+)
+
+type PatchUserRequest struct {
+	IsActive *bool `json:"is_active" binding:"required"`
+}
+
+type ReassignRequestV2 struct {
+	OldUserID string `json:"old_user_id" binding:"required"`
+	Reason    string `json:"reason"`
+}
+
+type VerdictRequestV2 struct {
+	ReviewerID string `json:"reviewer_id" binding:"required"`
+	Verdict    string `json:"verdict" binding:"required"`
+	Comment    string `json:"comment"`
+}
+
+// setupV2Routes регистрирует ресурсно-ориентированные маршруты /api/v2;
+// validate — сверка со спецификацией OpenAPI, если она включена
+func (h *Handlers) setupV2Routes(router *gin.Engine, validate ...gin.HandlerFunc) {
+	v2 := router.Group("/api/v2")
+	v2.Use(h.AuthMiddleware())
+	v2.Use(validate...)
+	v2.Use(h.IdempotencyMiddleware())
+
+	admin := RequireRoles(auth.RoleAdmin)
+
+	v2.GET("/me", h.v2Me)
+	v2.POST("/tokens", admin, h.v2CreateToken)
+	v2.DELETE("/tokens/:token_id", admin, h.v2RevokeToken)
+
+	v2.POST("/organizations", admin, h.v2CreateOrganization)
+	v2.GET("/organization", h.v2GetOrganization)
+	v2.PATCH("/organization/settings", admin, h.v2UpdateOrgSettings)
+
+	v2.GET("/teams", h.v2ListTeams)
+	v2.POST("/teams", admin, h.v2CreateTeam)
+	v2.GET("/teams/:team_name", h.v2GetTeam)
+
+	v2.GET("/users", h.v2ListUsers)
+	v2.GET("/users/:user_id", h.v2GetUser)
+	v2.PATCH("/users/:user_id", RequireRoles(auth.RoleAdmin, auth.RoleTeamLead), h.v2PatchUser)
+	v2.GET("/users/:user_id/reviews", h.v2GetUserReviews)
+
+	v2.GET("/pull-requests", h.v2ListPRs)
+	v2.POST("/pull-requests", h.v2CreatePR)
+	v2.GET("/pull-requests/:pull_request_id", h.v2GetPR)
+	v2.POST("/pull-requests/:pull_request_id/merge", h.v2MergePR)
+	v2.POST("/pull-requests/:pull_request_id/reassign",
+		RequireRoles(auth.RoleAdmin, auth.RoleTeamLead, auth.RoleMember), h.v2ReassignReviewer)
+	v2.POST("/pull-requests/:pull_request_id/verdicts", h.v2SubmitVerdict)
+	v2.GET("/pull-requests/:pull_request_id/history", h.v2GetPRHistory)
+
+	v2.GET("/stats", h.v2GetStats)
+	v2.GET("/stats/latency", h.v2GetLatencyStats)
+	v2.GET("/audit", admin, h.v2GetAuditLog)
+}
+
+// bindJSONV2 разбирает тело запроса, отвечая ошибкой v2 при неудаче
+func bindJSONV2(c *gin.Context, req any) bool {
+	if err := c.ShouldBindJSON(req); err != nil {
+		respondError(c, "INVALID_REQUEST", "invalid request body")
+		return false
+	}
+	return true
+}
+
+func (h *Handlers) v2Me(c *gin.Context) {
+	identity, _ := auth.FromContext(c.Request.Context())
+	respondData(c, http.StatusOK, gin.H{
+		"org_id":  identity.OrgID,
+		"user_id": identity.UserID,
+		"name":    identity.Name,
+		"roles":   identity.Roles,
+	})
+}
+
+func (h *Handlers) v2CreateToken(c *gin.Context) {
+	var req CreateTokenRequest
+	if !bindJSONV2(c, &req) {
+		return
+	}
+
+	plain, token, err := h.authService.CreateToken(c.Request.Context(), req.Name, req.UserID, auth.Role(req.Role))
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	redactOnReplay(c, "token")
+	respondData(c, http.StatusCreated, gin.H{"token": plain, "api_token": token})
+}
+
+func (h *Handlers) v2RevokeToken(c *gin.Context) {
+	tokenID, err := strconv.ParseInt(c.Param("token_id"), 10, 64)
+	if err != nil {
+		respondError(c, "INVALID_REQUEST", "token_id must be an integer")
+		return
+	}
+
+	if err := h.authService.RevokeToken(c.Request.Context(), tokenID); err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	respondData(c, http.StatusOK, gin.H{"token_id": tokenID, "revoked": true})
+}
+
+func (h *Handlers) v2CreateOrganization(c *gin.Context) {
+	var req CreateOrganizationRequest
+	if !bindJSONV2(c, &req) {
+		return
+	}
+
+	org, plain, err := h.orgService.CreateOrganization(c.Request.Context(), req.OrgID, req.Name)
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	redactOnReplay(c, "admin_token")
+	respondData(c, http.StatusCreated, gin.H{"organization": org, "admin_token": plain})
+}
+
+func (h *Handlers) v2GetOrganization(c *gin.Context) {
+	ctx := c.Request.Context()
+	org, err := h.orgService.GetOrganization(ctx, tenant.MustOrgID(ctx))
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	respondData(c, http.StatusOK, org)
+}
+
+func (h *Handlers) v2UpdateOrgSettings(c *gin.Context) {
+	var req UpdateOrgSettingsRequest
+	if !bindJSONV2(c, &req) {
+		return
+	}
+
+	org, err := h.orgService.UpdateSettings(c.Request.Context(), models.OrgSettings{
+		ReviewersPerPR:     *req.ReviewersPerPR,
+		AssignmentStrategy: req.AssignmentStrategy,
+	})
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	respondData(c, http.StatusOK, org)
+}
+
+func (h *Handlers) v2ListTeams(c *gin.Context) {
+	teams, err := h.teamService.ListTeams(c.Request.Context())
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	respondData(c, http.StatusOK, teams)
+}
+
+func (h *Handlers) v2CreateTeam(c *gin.Context) {
+	var req CreateTeamRequest
+	if !bindJSONV2(c, &req) {
+		return
+	}
+
+	members := make([]models.User, 0, len(req.Members))
+	for _, m := range req.Members {
+		members = append(members, models.User{
+			UserID:   m.UserID,
+			Username: m.Username,
+			IsActive: m.IsActive,
+		})
+	}
+
+	team, err := h.teamService.CreateTeam(c.Request.Context(), models.Team{
+		TeamName: req.TeamName,
+		Members:  members,
+	})
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	respondData(c, http.StatusCreated, team)
+}
+
+func (h *Handlers) v2GetTeam(c *gin.Context) {
+	team, err := h.teamService.GetTeam(c.Request.Context(), c.Param("team_name"))
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	respondData(c, http.StatusOK, team)
+}
+
+func (h *Handlers) v2GetUser(c *gin.Context) {
+	user, err := h.userService.GetUser(c.Request.Context(), c.Param("user_id"))
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	respondData(c, http.StatusOK, user)
+}
+
+func (h *Handlers) v2PatchUser(c *gin.Context) {
+	var req PatchUserRequest
+	if !bindJSONV2(c, &req) {
+		return
+	}
+
+	user, err := h.userService.SetUserActiveStatus(c.Request.Context(), c.Param("user_id"), *req.IsActive)
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	respondData(c, http.StatusOK, user)
+}
+
+func (h *Handlers) v2GetUserReviews(c *gin.Context) {
+	filter, err := parseReviewQuery(c, c.Param("user_id"))
+	if err != nil {
+		respondError(c, "INVALID_REQUEST", err.Error())
+		return
+	}
+
+	prs, next, err := h.userService.GetPRsForReviewer(c.Request.Context(), filter, c.Query("cursor"))
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"data": prs,
+		"meta": gin.H{"next_cursor": nextCursor(next)},
+	})
+}
+
+func (h *Handlers) v2ListUsers(c *gin.Context) {
+	filter, err := parseUserListQuery(c)
+	if err != nil {
+		respondError(c, "INVALID_REQUEST", err.Error())
+		return
+	}
+
+	users, next, err := h.userService.ListUsers(c.Request.Context(), filter, c.Query("sort"), c.Query("cursor"))
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"data": users,
+		"meta": gin.H{"next_cursor": nextCursor(next)},
+	})
+}
+
+func (h *Handlers) v2ListPRs(c *gin.Context) {
+	filter, err := parsePRListQuery(c)
+	if err != nil {
+		respondError(c, "INVALID_REQUEST", err.Error())
+		return
+	}
+
+	if format := rowsFormat(c); format != dataformat.JSON {
+		if err := checkRowsFormat(format); err != nil {
+			respondError(c, "INVALID_REQUEST", err.Error())
+			return
+		}
+		h.streamPRs(c, filter, format, respondServiceError)
+		return
+	}
+
+	prs, next, err := h.prService.ListPRs(c.Request.Context(), filter, c.Query("sort"), c.Query("cursor"))
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"data": prs,
+		"meta": gin.H{"next_cursor": nextCursor(next)},
+	})
+}
+
+func (h *Handlers) v2CreatePR(c *gin.Context) {
+	var req CreatePRRequest
+	if !bindJSONV2(c, &req) {
+		return
+	}
+
+	pr, err := h.prService.CreatePR(c.Request.Context(), models.PullRequest{
+		PullRequestID:   req.PullRequestID,
+		PullRequestName: req.PullRequestName,
+		AuthorID:        req.AuthorID,
+	})
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	respondData(c, http.StatusCreated, pr)
+}
+
+func (h *Handlers) v2GetPR(c *gin.Context) {
+	pr, err := h.prService.GetPR(c.Request.Context(), c.Param("pull_request_id"))
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	respondData(c, http.StatusOK, pr)
+}
+
+func (h *Handlers) v2MergePR(c *gin.Context) {
+	pr, err := h.prService.MergePR(c.Request.Context(), c.Param("pull_request_id"))
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	respondData(c, http.StatusOK, pr)
+}
+
+func (h *Handlers) v2ReassignReviewer(c *gin.Context) {
+	var req ReassignRequestV2
+	if !bindJSONV2(c, &req) {
+		return
+	}
+
+	pr, newReviewer, err := h.prService.ReassignReviewer(
+		c.Request.Context(), c.Param("pull_request_id"), req.OldUserID, req.Reason,
+	)
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	respondData(c, http.StatusOK, gin.H{"pull_request": pr, "replaced_by": newReviewer})
+}
+
+func (h *Handlers) v2SubmitVerdict(c *gin.Context) {
+	var req VerdictRequestV2
+	if !bindJSONV2(c, &req) {
+		return
+	}
+
+	prID := c.Param("pull_request_id")
+	err := h.prService.SubmitVerdict(c.Request.Context(), prID, req.ReviewerID, req.Verdict, req.Comment)
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	respondData(c, http.StatusCreated, gin.H{
+		"pull_request_id": prID,
+		"reviewer_id":     req.ReviewerID,
+		"verdict":         req.Verdict,
+	})
+}
+
+func (h *Handlers) v2GetPRHistory(c *gin.Context) {
+	events, err := h.prService.GetPRHistory(c.Request.Context(), c.Param("pull_request_id"))
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	respondData(c, http.StatusOK, events)
+}
+
+func (h *Handlers) v2GetStats(c *gin.Context) {
+	filter, err := parseStatsQuery(c)
+	if err != nil {
+		respondError(c, "INVALID_REQUEST", err.Error())
+		return
+	}
+
+	if format := rowsFormat(c); format != dataformat.JSON {
+		table, err := parseStatsTable(c)
+		if err == nil {
+			err = checkRowsFormat(format)
+		}
+		if err != nil {
+			respondError(c, "INVALID_REQUEST", err.Error())
+			return
+		}
+		h.streamStats(c, filter, format, table, respondServiceError)
+		return
+	}
+
+	stats, err := h.prService.GetStats(c.Request.Context(), filter)
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	respondData(c, http.StatusOK, stats)
+}
+
+func (h *Handlers) v2GetLatencyStats(c *gin.Context) {
+	filter, err := parseStatsQuery(c)
+	if err != nil {
+		respondError(c, "INVALID_REQUEST", err.Error())
+		return
+	}
+
+	stats, err := h.prService.GetLatencyStats(c.Request.Context(), filter)
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	respondData(c, http.StatusOK, stats)
+}
+
+func (h *Handlers) v2GetAuditLog(c *gin.Context) {
+	filter := models.AuditFilter{
+		Actor:      c.Query("actor"),
+		Action:     c.Query("action"),
+		TargetType: c.Query("target_type"),
+		TargetID:   c.Query("target_id"),
+	}
+
+	var err error
+	if filter.From, err = parseTimeQuery(c, "from"); err != nil {
+		respondError(c, "INVALID_REQUEST", "from must be RFC3339 timestamp")
+		return
+	}
+	if filter.To, err = parseTimeQuery(c, "to"); err != nil {
+		respondError(c, "INVALID_REQUEST", "to must be RFC3339 timestamp")
+		return
+	}
+	if filter.Limit, err = parseIntQuery(c, "limit"); err != nil {
+		respondError(c, "INVALID_REQUEST", "limit must be an integer")
+		return
+	}
+	if filter.Offset, err = parseIntQuery(c, "offset"); err != nil {
+		respondError(c, "INVALID_REQUEST", "offset must be an integer")
+		return
+	}
+
+	entries, total, err := h.auditService.ListEntries(c.Request.Context(), filter)
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"data": entries,
+		"meta": gin.H{"total": total, "offset": filter.Offset},
+	})
+}
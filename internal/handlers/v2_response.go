@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"github.com/Vimp17/pr-reviewer-service/internal/apierror"
+	"github.com/Vimp17/pr-reviewer-service/internal/requestid"
+	"github.com/Vimp17/pr-reviewer-service/internal/tracing"
+	"github.com/gin-gonic/gin"
+)
+
+// Единый формат ответов API v2:
+//
+//	успех:  {"data": ...}
+//	ошибка: {"error": {"code": "...", "message": "...", "request_id": "...", "trace_id": "..."}}
+//
+// Общие middleware отвечают в формате версии маршрута (см. abortError).
+// Коды, статусы и сообщения ошибок — в apierror.
+
+// respondData отправляет успешный ответ в формате v2
+func respondData(c *gin.Context, status int, data any) {
+	c.JSON(status, gin.H{"data": data})
+}
+
+// respondError отправляет ошибку в формате v2 по коду из apierror
+func respondError(c *gin.Context, code, message string) {
+	kind, _ := apierror.ByCode(code)
+	if message == "" {
+		message = kind.Message
+	}
+
+	body := gin.H{
+		"code":       kind.Code,
+		"message":    message,
+		"request_id": requestid.FromContext(c.Request.Context()),
+	}
+	if traceID := tracing.TraceID(c.Request.Context()); traceID != "" {
+		body["trace_id"] = traceID
+	}
+	c.AbortWithStatusJSON(kind.HTTP, gin.H{"error": body})
+}
+
+// respondServiceError переводит ошибку сервиса в ответ v2. Неизвестные ошибки
+// считаются внутренними и пишутся в журнал.
+func respondServiceError(c *gin.Context, err error) {
+	kind, ok := apierror.FromError(err)
+	if !ok {
+		logRequestError(c, err)
+	}
+	respondError(c, kind.Code, "")
+}
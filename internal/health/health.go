@@ -0,0 +1,123 @@
+// Package health выполняет проверки готовности сервиса для /readyz
+package health
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Статусы проверок и отчета
+const (
+	StatusOK   = "ok"
+	StatusFail = "fail"
+)
+
+// DefaultTimeout ограничение длительности одной проверки
+const DefaultTimeout = 2 * time.Second
+
+// Details сведения проверки, попадающие в отчет
+type Details map[string]any
+
+// CheckFunc проверяет одну зависимость; ошибка означает неготовность сервиса
+type CheckFunc func(ctx context.Context) (Details, error)
+
+// Result результат одной проверки
+type Result struct {
+	Status     string  `json:"status"`
+	DurationMs float64 `json:"duration_ms"`
+	Error      string  `json:"error,omitempty"`
+	Details    Details `json:"details,omitempty"`
+}
+
+// Report отчет о готовности: ok, только если все проверки прошли
+type Report struct {
+	Status string            `json:"status"`
+	Checks map[string]Result `json:"checks"`
+}
+
+type namedCheck struct {
+	name  string
+	check CheckFunc
+}
+
+// Checker набор проверок готовности. Проверки регистрируются при запуске
+// и выполняются параллельно на каждый запрос.
+type Checker struct {
+	timeout time.Duration
+	checks  []namedCheck
+}
+
+// NewChecker создает набор проверок с ограничением длительности каждой проверки
+func NewChecker(timeout time.Duration) *Checker {
+	return &Checker{timeout: timeout}
+}
+
+// Add регистрирует проверку под именем name
+func (c *Checker) Add(name string, check CheckFunc) {
+	c.checks = append(c.checks, namedCheck{name: name, check: check})
+}
+
+// Run выполняет все проверки
+func (c *Checker) Run(ctx context.Context) Report {
+	report := Report{Status: StatusOK, Checks: make(map[string]Result, len(c.checks))}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, nc := range c.checks {
+		wg.Add(1)
+		go func(nc namedCheck) {
+			defer wg.Done()
+			result := c.run(ctx, nc.check)
+			mu.Lock()
+			defer mu.Unlock()
+			report.Checks[nc.name] = result
+			if result.Status != StatusOK {
+				report.Status = StatusFail
+			}
+		}(nc)
+	}
+	wg.Wait()
+	return report
+}
+
+func (c *Checker) run(ctx context.Context, check CheckFunc) Result {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	start := time.Now()
+	details, err := check(ctx)
+	result := Result{
+		Status:     StatusOK,
+		DurationMs: float64(time.Since(start).Microseconds()) / 1000,
+		Details:    details,
+	}
+	if err != nil {
+		result.Status = StatusFail
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// Ping проверяет доступность БД через пул соединений
+func Ping(ping func(ctx context.Context) error) CheckFunc {
+	return func(ctx context.Context) (Details, error) {
+		return nil, ping(ctx)
+	}
+}
+
+// Migrations проверяет, что схема БД на ожидаемой версии миграций
+func Migrations(current func(ctx context.Context) (int64, error), expected int64) CheckFunc {
+	return func(ctx context.Context) (Details, error) {
+		version, err := current(ctx)
+		if err != nil {
+			return nil, err
+		}
+		details := Details{"current": version, "expected": expected}
+		if version != expected {
+			return details, fmt.Errorf("schema version %d, expected %d", version, expected)
+		}
+		return details, nil
+	}
+}
@@ -0,0 +1,67 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Worker состояние периодической фоновой задачи. Задача отмечает каждый запуск
+// через Done; проверка не проходит, если запусков не было дольше двух интервалов
+// (цикл задачи остановился или завис).
+type Worker struct {
+	interval time.Duration
+
+	mu        sync.Mutex
+	startedAt time.Time
+	lastRun   time.Time
+	lastError error
+}
+
+// NewWorker создает состояние задачи, запускаемой раз в interval
+func NewWorker(interval time.Duration) *Worker {
+	return &Worker{interval: interval}
+}
+
+// Start отмечает запуск цикла задачи
+func (w *Worker) Start() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.startedAt = time.Now()
+}
+
+// Done отмечает завершение очередного запуска с результатом err
+func (w *Worker) Done(err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.lastRun = time.Now()
+	w.lastError = err
+}
+
+// Check проверка готовности для Checker. Ошибка последнего запуска попадает
+// в отчет, но сама по себе не делает сервис неготовым.
+func (w *Worker) Check(context.Context) (Details, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	details := Details{"interval_seconds": w.interval.Seconds()}
+	if !w.lastRun.IsZero() {
+		details["last_run"] = w.lastRun.UTC()
+	}
+	if w.lastError != nil {
+		details["last_error"] = w.lastError.Error()
+	}
+
+	if w.startedAt.IsZero() {
+		return details, fmt.Errorf("worker is not running")
+	}
+	seen := w.startedAt
+	if w.lastRun.After(seen) {
+		seen = w.lastRun
+	}
+	if since := time.Since(seen); since > 2*w.interval {
+		return details, fmt.Errorf("no run for %s", since.Round(time.Second))
+	}
+	return details, nil
+}
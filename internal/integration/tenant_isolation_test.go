@@ -0,0 +1,609 @@
+// Package integration проверяет сервис целиком (HTTP v1 и v2, GraphQL, gRPC)
+// на настоящей БД; тесты пропускаются без TEST_DATABASE_URL
+package integration
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Vimp17/pr-reviewer-service/internal/auth"
+	"github.com/Vimp17/pr-reviewer-service/internal/grpcapi"
+	pb "github.com/Vimp17/pr-reviewer-service/internal/grpcapi/prreviewerv1"
+	"github.com/Vimp17/pr-reviewer-service/internal/handlers"
+	"github.com/Vimp17/pr-reviewer-service/internal/models"
+	"github.com/Vimp17/pr-reviewer-service/internal/services"
+	"github.com/Vimp17/pr-reviewer-service/internal/storage/postgres"
+	"github.com/Vimp17/pr-reviewer-service/internal/testdb"
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// app сервисы, HTTP-роутер и gRPC-клиент поверх одной тестовой БД
+type app struct {
+	storage   *postgres.Storage
+	prs       *services.PRService
+	teams     *services.TeamService
+	users     *services.UserService
+	orgs      *services.OrgService
+	tokens    *services.AuthService
+	audit     *services.AuditService
+	snapshots *services.SnapshotService
+	router    *gin.Engine
+	grpc      pb.PRReviewerServiceClient
+}
+
+func newApp(t *testing.T) *app {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	storage := testdb.Open(t)
+	a := &app{
+		storage:   storage,
+		prs:       services.NewPRService(storage),
+		teams:     services.NewTeamService(storage),
+		users:     services.NewUserService(storage),
+		orgs:      services.NewOrgService(storage, models.OrgSettings{ReviewersPerPR: 2, AssignmentStrategy: models.AssignmentRandom}),
+		tokens:    services.NewAuthService(storage),
+		audit:     services.NewAuditService(storage),
+		snapshots: services.NewSnapshotService(storage),
+		router:    gin.New(),
+	}
+
+	// В тестовом режиме gin маршруты сверяются со спецификацией OpenAPI
+	h := handlers.NewHandlers(a.prs, a.teams, a.users, a.orgs, a.tokens, a.audit,
+		services.NewIdempotencyService(storage, time.Hour), a.snapshots)
+	h.SetupRoutes(a.router)
+
+	listener := bufconn.Listen(1 << 20)
+	server := grpcapi.NewGRPCServer(grpcapi.NewServer(a.prs, a.teams, a.users), a.tokens)
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	a.grpc = pb.NewPRReviewerServiceClient(conn)
+	return a
+}
+
+// tenantData ID и имена, созданные в организации seed
+type tenantData struct {
+	orgID     string
+	token     string
+	tokenID   int64
+	reviewers []string // ревьюеры pr-1
+}
+
+// seed создает в организации одинаковые в обеих организациях ID (команда
+// backend, пользователи u1..u5, PR pr-1) и собственные ID с префиксом label.
+// Имена пользователей и PR начинаются с Label, чтобы по ним находить утечки.
+func seed(t *testing.T, a *app, orgID, label string) tenantData {
+	t.Helper()
+	ctx := testdb.AdminContext(orgID)
+	title := strings.ToUpper(label[:1]) + label[1:]
+
+	backend := models.Team{TeamName: "backend"}
+	for i := 1; i <= 5; i++ {
+		backend.Members = append(backend.Members, models.User{
+			UserID:   fmt.Sprintf("u%d", i),
+			Username: fmt.Sprintf("%s Person %d", title, i),
+			IsActive: true,
+		})
+	}
+	own := models.Team{TeamName: label + "-team", Members: []models.User{
+		{UserID: label + "-user", Username: title + " Owner", IsActive: true},
+		{UserID: label + "-user2", Username: title + " Reviewer", IsActive: true},
+	}}
+	for _, team := range []models.Team{backend, own} {
+		if _, err := a.teams.CreateTeam(ctx, team); err != nil {
+			t.Fatalf("seed %s team %s: %v", label, team.TeamName, err)
+		}
+	}
+
+	shared, err := a.prs.CreatePR(ctx, models.PullRequest{
+		PullRequestID:   "pr-1",
+		PullRequestName: title + " change 1",
+		AuthorID:        "u1",
+		Status:          "OPEN",
+	})
+	if err != nil {
+		t.Fatalf("seed %s pr-1: %v", label, err)
+	}
+	if len(shared.AssignedReviewers) != 2 {
+		t.Fatalf("seed %s pr-1: reviewers %v, want 2", label, shared.AssignedReviewers)
+	}
+	if _, err := a.prs.CreatePR(ctx, models.PullRequest{
+		PullRequestID:   label + "-pr",
+		PullRequestName: title + " change 2",
+		AuthorID:        label + "-user",
+		Status:          "OPEN",
+	}); err != nil {
+		t.Fatalf("seed %s %s-pr: %v", label, label, err)
+	}
+
+	plain, token, err := a.tokens.CreateToken(ctx, label+"-admin", "", auth.RoleAdmin)
+	if err != nil {
+		t.Fatalf("seed %s token: %v", label, err)
+	}
+	return tenantData{orgID: orgID, token: plain, tokenID: token.TokenID, reviewers: shared.AssignedReviewers}
+}
+
+// seedForeign дополняет организацию B вердиктом, слитым PR и пользователем u6,
+// которого организация A позже создаст у себя
+func seedForeign(t *testing.T, a *app, b tenantData) {
+	t.Helper()
+	ctx := testdb.AdminContext(b.orgID)
+
+	if err := a.prs.SubmitVerdict(ctx, "pr-1", b.reviewers[0], "APPROVED", "Bravo looks good"); err != nil {
+		t.Fatalf("seed verdict: %v", err)
+	}
+	if _, err := a.teams.CreateTeam(ctx, models.Team{TeamName: "bravo-extra", Members: []models.User{
+		{UserID: "u6", Username: "Bravo Person 6", IsActive: true},
+		{UserID: "bravo-user3", Username: "Bravo Person 7", IsActive: true},
+	}}); err != nil {
+		t.Fatalf("seed team bravo-extra: %v", err)
+	}
+	if _, err := a.prs.CreatePR(ctx, models.PullRequest{
+		PullRequestID:   "bravo-pr-2",
+		PullRequestName: "Bravo change 3",
+		AuthorID:        "u6",
+		Status:          "OPEN",
+	}); err != nil {
+		t.Fatalf("seed bravo-pr-2: %v", err)
+	}
+	if _, err := a.prs.MergePR(ctx, "bravo-pr-2"); err != nil {
+		t.Fatalf("seed merge bravo-pr-2: %v", err)
+	}
+}
+
+// foreignMarkers строки, которые встречаются только в данных организации B:
+// имена начинаются с "Bravo ", собственные ID и ID организации — с "bravo-"
+var foreignMarkers = []string{"Bravo ", "bravo-"}
+
+// leaked возвращает маркеры организации B, найденные в ответе. Маркеры,
+// которые вызывающий сам передал в запросе, не проверяются.
+func leaked(request, response string) []string {
+	var found []string
+	for _, marker := range foreignMarkers {
+		if !strings.Contains(request, marker) && strings.Contains(response, marker) {
+			found = append(found, marker)
+		}
+	}
+	return found
+}
+
+// httpCall запрос HTTP API; status 0 означает любой успешный статус
+type httpCall struct {
+	method string
+	path   string
+	body   any
+	status int
+}
+
+func (a *app) serveHTTP(t *testing.T, token string, call httpCall) *httptest.ResponseRecorder {
+	t.Helper()
+	var body []byte
+	if call.body != nil {
+		var err error
+		if body, err = json.Marshal(call.body); err != nil {
+			t.Fatal(err)
+		}
+	}
+	req := httptest.NewRequest(call.method, call.path, bytes.NewReader(body))
+	req.Header.Set("Authorization", "Bearer "+token)
+	if call.body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	w := httptest.NewRecorder()
+	a.router.ServeHTTP(w, req)
+	return w
+}
+
+// checkHTTP выполняет запросы от имени token и проверяет статусы и отсутствие
+// данных организации B в ответах
+func (a *app) checkHTTP(t *testing.T, token string, calls []httpCall) {
+	t.Helper()
+	for _, call := range calls {
+		w := a.serveHTTP(t, token, call)
+		name := call.method + " " + call.path
+
+		switch {
+		case call.status == 0 && (w.Code < 200 || w.Code > 299):
+			t.Errorf("%s: status %d, want 2xx; body %s", name, w.Code, w.Body)
+		case call.status != 0 && w.Code != call.status:
+			t.Errorf("%s: status %d, want %d; body %s", name, w.Code, call.status, w.Body)
+		}
+
+		if call.path == "/graphql" && strings.Contains(w.Body.String(), `"errors"`) {
+			t.Errorf("%s: GraphQL errors: %s", name, w.Body)
+		}
+
+		request, _ := json.Marshal(call.body)
+		if found := leaked(call.path+string(request), w.Body.String()); len(found) > 0 {
+			t.Errorf("%s: response contains data of another organization %q: %s", name, found, w.Body)
+		}
+	}
+}
+
+func graphQL(query string) httpCall {
+	return httpCall{method: http.MethodPost, path: "/graphql", body: map[string]string{"query": query}}
+}
+
+// readCalls чтения, которые не должны возвращать данные организации B
+func readCalls() []httpCall {
+	get := func(path string) httpCall { return httpCall{method: http.MethodGet, path: path} }
+	return []httpCall{
+		// v1
+		get("/auth/whoami"),
+		get("/organization/get"),
+		get("/team/get?team_name=backend"),
+		get("/users/getReview?user_id=u2"),
+		get("/users/getReview?user_id=u2&status=OPEN&status=MERGED"),
+		get("/users/list"),
+		get("/users/list?q=Person"),
+		get("/pullRequest/list"),
+		get("/pullRequest/list?format=csv"),
+		get("/pullRequest/list?format=jsonl"),
+		get("/pullRequest/list?reviewer_id=u2&status=MERGED"),
+		get("/pullRequest/history?pull_request_id=pr-1"),
+		get("/stats"),
+		get("/stats?format=csv&table=reviewers"),
+		get("/stats?format=jsonl&table=teams"),
+		get("/stats/latency"),
+		get("/audit"),
+		get("/export"),
+		get("/export?format=csv"),
+		get("/export?format=jsonl"),
+
+		// GraphQL
+		graphQL(`{ teams { name members { id username openReviews { id name } } } }`),
+		graphQL(`{ team(name: "backend") { name members { id username isActive } } }`),
+		graphQL(`{ user(id: "u2") { id username team { name } openReviews { id name reviewers { id username } } } }`),
+		graphQL(`{ pullRequest(id: "pr-1") { id name status author { id username } reviewers { id username team { name } } } }`),
+
+		// v2
+		get("/api/v2/me"),
+		get("/api/v2/organization"),
+		get("/api/v2/teams"),
+		get("/api/v2/teams/backend"),
+		get("/api/v2/users"),
+		get("/api/v2/users/u1"),
+		get("/api/v2/users/u2/reviews"),
+		get("/api/v2/pull-requests"),
+		get("/api/v2/pull-requests?format=csv"),
+		get("/api/v2/pull-requests/pr-1"),
+		get("/api/v2/pull-requests/pr-1/history"),
+		get("/api/v2/stats"),
+		get("/api/v2/stats?format=csv&table=teams"),
+		get("/api/v2/stats/latency"),
+		get("/api/v2/audit"),
+	}
+}
+
+// foreignCalls обращения к ID, которые есть только в организации B:
+// чтения и изменения должны вести себя так, будто этих записей нет
+func foreignCalls(b tenantData) []httpCall {
+	get := func(path string, status int) httpCall {
+		return httpCall{method: http.MethodGet, path: path, status: status}
+	}
+	post := func(path string, body any, status int) httpCall {
+		return httpCall{method: http.MethodPost, path: path, body: body, status: status}
+	}
+	return []httpCall{
+		// v1
+		get("/team/get?team_name=bravo-team", http.StatusNotFound),
+		get("/users/getReview?user_id=bravo-user2", http.StatusOK),
+		get("/pullRequest/history?pull_request_id=bravo-pr", http.StatusNotFound),
+		get("/pullRequest/list?author_id=bravo-user", http.StatusOK),
+		get("/stats?team_name=bravo-team", http.StatusOK),
+		get("/audit?target_id=bravo-pr", http.StatusOK),
+		post("/users/setIsActive", gin.H{"user_id": "bravo-user", "is_active": false}, http.StatusNotFound),
+		post("/pullRequest/create", gin.H{"pull_request_id": "alpha-new", "pull_request_name": "New", "author_id": "bravo-user"}, http.StatusNotFound),
+		post("/pullRequest/merge", gin.H{"pull_request_id": "bravo-pr"}, http.StatusNotFound),
+		post("/pullRequest/reassign", gin.H{"pull_request_id": "bravo-pr", "old_user_id": "bravo-user2"}, http.StatusNotFound),
+		post("/pullRequest/verdict", gin.H{"pull_request_id": "bravo-pr", "reviewer_id": "bravo-user2", "verdict": "APPROVED"}, http.StatusNotFound),
+		post("/auth/createToken", gin.H{"name": "stolen", "user_id": "bravo-user", "role": "member"}, http.StatusNotFound),
+		post("/auth/revokeToken", gin.H{"token_id": b.tokenID}, http.StatusNotFound),
+		// Организации создает только администратор организации по умолчанию
+		post("/organization/add", gin.H{"org_id": b.orgID, "name": "Takeover"}, http.StatusForbidden),
+
+		// GraphQL: чужие записи разрешаются в null
+		graphQL(`{ team(name: "bravo-team") { name } user(id: "bravo-user") { id } pullRequest(id: "bravo-pr") { id } }`),
+
+		// v2
+		get("/api/v2/teams/bravo-team", http.StatusNotFound),
+		get("/api/v2/users/bravo-user", http.StatusNotFound),
+		get("/api/v2/users/bravo-user2/reviews", http.StatusOK),
+		get("/api/v2/pull-requests/bravo-pr", http.StatusNotFound),
+		get("/api/v2/pull-requests/bravo-pr/history", http.StatusNotFound),
+		{method: http.MethodPatch, path: "/api/v2/users/bravo-user", body: gin.H{"is_active": false}, status: http.StatusNotFound},
+		post("/api/v2/pull-requests", gin.H{"pull_request_id": "alpha-new", "pull_request_name": "New", "author_id": "bravo-user"}, http.StatusNotFound),
+		post("/api/v2/pull-requests/bravo-pr/merge", nil, http.StatusNotFound),
+		post("/api/v2/pull-requests/bravo-pr/reassign", gin.H{"old_user_id": "bravo-user2"}, http.StatusNotFound),
+		post("/api/v2/pull-requests/bravo-pr/verdicts", gin.H{"reviewer_id": "bravo-user2", "verdict": "APPROVED"}, http.StatusNotFound),
+		post("/api/v2/tokens", gin.H{"name": "stolen", "user_id": "bravo-user", "role": "member"}, http.StatusNotFound),
+		{method: http.MethodDelete, path: fmt.Sprintf("/api/v2/tokens/%d", b.tokenID), status: http.StatusNotFound},
+		post("/api/v2/organizations", gin.H{"org_id": b.orgID, "name": "Takeover"}, http.StatusForbidden),
+	}
+}
+
+// sharedMutations изменения записей, ID которых есть в обеих организациях;
+// они должны затронуть только организацию A
+func sharedMutations(a tenantData) []httpCall {
+	post := func(path string, body any) httpCall { return httpCall{method: http.MethodPost, path: path, body: body} }
+	return []httpCall{
+		// v1
+		post("/users/setIsActive", gin.H{"user_id": "u5", "is_active": false}),
+		post("/pullRequest/verdict", gin.H{"pull_request_id": "pr-1", "reviewer_id": a.reviewers[0], "verdict": "APPROVED"}),
+		post("/pullRequest/reassign", gin.H{"pull_request_id": "pr-1", "old_user_id": a.reviewers[1]}),
+		post("/pullRequest/create", gin.H{"pull_request_id": "pr-2", "pull_request_name": "Alpha change 3", "author_id": "u2"}),
+		post("/pullRequest/merge", gin.H{"pull_request_id": "pr-1"}),
+		post("/team/add", gin.H{"team_name": "platform", "members": []gin.H{{"user_id": "u6", "username": "Alpha Person 6", "is_active": true}}}),
+		post("/auth/createToken", gin.H{"name": "alpha-member", "user_id": "u2", "role": "member"}),
+		post("/organization/setSettings", gin.H{"reviewers_per_pr": 1, "assignment_strategy": "least_loaded"}),
+		post("/import", models.Snapshot{
+			Teams: []models.SnapshotTeam{{TeamName: "imported"}},
+			Users: []models.User{{UserID: "u8", Username: "Alpha Person 8", TeamName: "imported", IsActive: true}},
+		}),
+
+		// v2
+		{method: http.MethodPatch, path: "/api/v2/users/u4", body: gin.H{"is_active": false}},
+		post("/api/v2/pull-requests", gin.H{"pull_request_id": "pr-3", "pull_request_name": "Alpha change 4", "author_id": "u3"}),
+		post("/api/v2/pull-requests/pr-3/merge", nil),
+		post("/api/v2/pull-requests/alpha-pr/verdicts", gin.H{"reviewer_id": "alpha-user2", "verdict": "CHANGES_REQUESTED"}),
+		post("/api/v2/teams", gin.H{"team_name": "platform2", "members": []gin.H{{"user_id": "u7", "username": "Alpha Person 7", "is_active": true}}}),
+		post("/api/v2/tokens", gin.H{"name": "alpha-bot", "role": "bot"}),
+		{method: http.MethodPatch, path: "/api/v2/organization/settings", body: gin.H{"reviewers_per_pr": 2, "assignment_strategy": "random"}},
+	}
+}
+
+// tenantState все, что организация B хранит о себе: данные, история PR,
+// журнал аудита и настройки
+func tenantState(t *testing.T, a *app, orgID string) string {
+	t.Helper()
+	ctx := testdb.AdminContext(orgID)
+
+	snapshot, err := a.snapshots.Export(ctx)
+	if err != nil {
+		t.Fatalf("export %s: %v", orgID, err)
+	}
+	histories := map[string][]models.PREvent{}
+	for _, pr := range snapshot.PullRequests {
+		if histories[pr.PullRequestID], err = a.prs.GetPRHistory(ctx, pr.PullRequestID); err != nil {
+			t.Fatalf("history %s: %v", pr.PullRequestID, err)
+		}
+	}
+	_, auditTotal, err := a.audit.ListEntries(ctx, models.AuditFilter{Limit: 1})
+	if err != nil {
+		t.Fatalf("audit %s: %v", orgID, err)
+	}
+	org, err := a.orgs.GetOrganization(ctx, orgID)
+	if err != nil {
+		t.Fatalf("organization %s: %v", orgID, err)
+	}
+
+	state, err := json.Marshal(map[string]any{
+		"snapshot":  snapshot,
+		"histories": histories,
+		"audit":     auditTotal,
+		"org":       org,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(state)
+}
+
+// grpcCall вызов gRPC API; code — ожидаемый статус
+type grpcCall struct {
+	name string
+	code codes.Code
+	call func(ctx context.Context, client pb.PRReviewerServiceClient) (proto.Message, error)
+}
+
+func (a *app) checkGRPC(t *testing.T, token string, calls []grpcCall) {
+	t.Helper()
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+token)
+	for _, c := range calls {
+		resp, err := c.call(ctx, a.grpc)
+		if got := status.Code(err); got != c.code {
+			t.Errorf("gRPC %s: code %s, want %s (%v)", c.name, got, c.code, err)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		body, err := protojson.Marshal(resp)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if found := leaked(c.name, string(body)); len(found) > 0 {
+			t.Errorf("gRPC %s: response contains data of another organization %q: %s", c.name, found, body)
+		}
+	}
+}
+
+func grpcReads() []grpcCall {
+	return []grpcCall{
+		{"ListTeams", codes.OK, func(ctx context.Context, c pb.PRReviewerServiceClient) (proto.Message, error) {
+			return c.ListTeams(ctx, &pb.ListTeamsRequest{})
+		}},
+		{"GetTeam", codes.OK, func(ctx context.Context, c pb.PRReviewerServiceClient) (proto.Message, error) {
+			return c.GetTeam(ctx, &pb.GetTeamRequest{TeamName: "backend"})
+		}},
+		{"GetUser", codes.OK, func(ctx context.Context, c pb.PRReviewerServiceClient) (proto.Message, error) {
+			return c.GetUser(ctx, &pb.GetUserRequest{UserId: "u1"})
+		}},
+		{"ListUserReviews", codes.OK, func(ctx context.Context, c pb.PRReviewerServiceClient) (proto.Message, error) {
+			return c.ListUserReviews(ctx, &pb.ListUserReviewsRequest{UserId: "u2", Statuses: []string{"OPEN", "MERGED"}})
+		}},
+		{"GetPullRequest", codes.OK, func(ctx context.Context, c pb.PRReviewerServiceClient) (proto.Message, error) {
+			return c.GetPullRequest(ctx, &pb.GetPullRequestRequest{PullRequestId: "pr-1"})
+		}},
+		{"GetPullRequestHistory", codes.OK, func(ctx context.Context, c pb.PRReviewerServiceClient) (proto.Message, error) {
+			return c.GetPullRequestHistory(ctx, &pb.GetPullRequestHistoryRequest{PullRequestId: "pr-1"})
+		}},
+		{"GetStats", codes.OK, func(ctx context.Context, c pb.PRReviewerServiceClient) (proto.Message, error) {
+			return c.GetStats(ctx, &pb.GetStatsRequest{})
+		}},
+		{"GetLatencyStats", codes.OK, func(ctx context.Context, c pb.PRReviewerServiceClient) (proto.Message, error) {
+			return c.GetLatencyStats(ctx, &pb.GetLatencyStatsRequest{})
+		}},
+	}
+}
+
+func grpcForeign() []grpcCall {
+	return []grpcCall{
+		{"GetTeam bravo-team", codes.NotFound, func(ctx context.Context, c pb.PRReviewerServiceClient) (proto.Message, error) {
+			return c.GetTeam(ctx, &pb.GetTeamRequest{TeamName: "bravo-team"})
+		}},
+		{"GetUser bravo-user", codes.NotFound, func(ctx context.Context, c pb.PRReviewerServiceClient) (proto.Message, error) {
+			return c.GetUser(ctx, &pb.GetUserRequest{UserId: "bravo-user"})
+		}},
+		{"ListUserReviews bravo-user2", codes.OK, func(ctx context.Context, c pb.PRReviewerServiceClient) (proto.Message, error) {
+			return c.ListUserReviews(ctx, &pb.ListUserReviewsRequest{UserId: "bravo-user2"})
+		}},
+		{"SetUserActive bravo-user", codes.NotFound, func(ctx context.Context, c pb.PRReviewerServiceClient) (proto.Message, error) {
+			return c.SetUserActive(ctx, &pb.SetUserActiveRequest{UserId: "bravo-user"})
+		}},
+		{"GetPullRequest bravo-pr", codes.NotFound, func(ctx context.Context, c pb.PRReviewerServiceClient) (proto.Message, error) {
+			return c.GetPullRequest(ctx, &pb.GetPullRequestRequest{PullRequestId: "bravo-pr"})
+		}},
+		{"GetPullRequestHistory bravo-pr", codes.NotFound, func(ctx context.Context, c pb.PRReviewerServiceClient) (proto.Message, error) {
+			return c.GetPullRequestHistory(ctx, &pb.GetPullRequestHistoryRequest{PullRequestId: "bravo-pr"})
+		}},
+		{"CreatePullRequest by bravo-user", codes.NotFound, func(ctx context.Context, c pb.PRReviewerServiceClient) (proto.Message, error) {
+			return c.CreatePullRequest(ctx, &pb.CreatePullRequestRequest{PullRequestId: "alpha-new", PullRequestName: "New", AuthorId: "bravo-user"})
+		}},
+		{"MergePullRequest bravo-pr", codes.NotFound, func(ctx context.Context, c pb.PRReviewerServiceClient) (proto.Message, error) {
+			return c.MergePullRequest(ctx, &pb.MergePullRequestRequest{PullRequestId: "bravo-pr"})
+		}},
+		{"ReassignReviewer bravo-pr", codes.NotFound, func(ctx context.Context, c pb.PRReviewerServiceClient) (proto.Message, error) {
+			return c.ReassignReviewer(ctx, &pb.ReassignReviewerRequest{PullRequestId: "bravo-pr", OldUserId: "bravo-user2"})
+		}},
+		{"SubmitVerdict bravo-pr", codes.NotFound, func(ctx context.Context, c pb.PRReviewerServiceClient) (proto.Message, error) {
+			return c.SubmitVerdict(ctx, &pb.SubmitVerdictRequest{PullRequestId: "bravo-pr", ReviewerId: "bravo-user2", Verdict: "APPROVED"})
+		}},
+	}
+}
+
+func grpcMutations() []grpcCall {
+	return []grpcCall{
+		{"CreateTeam", codes.OK, func(ctx context.Context, c pb.PRReviewerServiceClient) (proto.Message, error) {
+			return c.CreateTeam(ctx, &pb.CreateTeamRequest{Team: &pb.Team{
+				TeamName: "grpc-team",
+				Members:  []*pb.User{{UserId: "u9", Username: "Alpha Person 9", IsActive: true}},
+			}})
+		}},
+		{"SetUserActive", codes.OK, func(ctx context.Context, c pb.PRReviewerServiceClient) (proto.Message, error) {
+			return c.SetUserActive(ctx, &pb.SetUserActiveRequest{UserId: "u4", IsActive: true})
+		}},
+		{"CreatePullRequest", codes.OK, func(ctx context.Context, c pb.PRReviewerServiceClient) (proto.Message, error) {
+			return c.CreatePullRequest(ctx, &pb.CreatePullRequestRequest{PullRequestId: "pr-4", PullRequestName: "Alpha change 5", AuthorId: "u1"})
+		}},
+		{"SubmitVerdict", codes.OK, func(ctx context.Context, c pb.PRReviewerServiceClient) (proto.Message, error) {
+			return c.SubmitVerdict(ctx, &pb.SubmitVerdictRequest{PullRequestId: "alpha-pr", ReviewerId: "alpha-user2", Verdict: "APPROVED"})
+		}},
+		{"MergePullRequest", codes.OK, func(ctx context.Context, c pb.PRReviewerServiceClient) (proto.Message, error) {
+			return c.MergePullRequest(ctx, &pb.MergePullRequestRequest{PullRequestId: "pr-4"})
+		}},
+	}
+}
+
+// TestTenantIsolation проверяет, что вызывающий из организации A ни через
+// один API не видит и не меняет данные организации B, даже когда ID команд,
+// пользователей и PR в организациях совпадают
+func TestTenantIsolation(t *testing.T) {
+	a := newApp(t)
+
+	alpha := seed(t, a, testdb.NewOrg(t, a.storage, "alpha"), "alpha")
+	bravo := seed(t, a, testdb.NewOrg(t, a.storage, "bravo"), "bravo")
+	seedForeign(t, a, bravo)
+	before := tenantState(t, a, bravo.orgID)
+
+	t.Run("reads", func(t *testing.T) {
+		a.checkHTTP(t, alpha.token, readCalls())
+		a.checkGRPC(t, alpha.token, grpcReads())
+	})
+
+	t.Run("counts", func(t *testing.T) {
+		// В статистике и списках только два PR организации A
+		w := a.serveHTTP(t, alpha.token, httpCall{method: http.MethodGet, path: "/stats"})
+		var stats models.Stats
+		if err := json.Unmarshal(w.Body.Bytes(), &stats); err != nil {
+			t.Fatalf("decode stats: %v; body %s", err, w.Body)
+		}
+		if stats.PullRequests != 2 {
+			t.Errorf("stats pull_requests = %d, want 2", stats.PullRequests)
+		}
+
+		w = a.serveHTTP(t, alpha.token, httpCall{method: http.MethodGet, path: "/api/v2/pull-requests"})
+		var list struct {
+			Data []models.PullRequest `json:"data"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &list); err != nil {
+			t.Fatalf("decode list: %v; body %s", err, w.Body)
+		}
+		if len(list.Data) != 2 {
+			t.Errorf("v2 pull-requests returned %d PRs, want 2: %s", len(list.Data), w.Body)
+		}
+	})
+
+	t.Run("foreign ids", func(t *testing.T) {
+		a.checkHTTP(t, alpha.token, foreignCalls(bravo))
+		a.checkGRPC(t, alpha.token, grpcForeign())
+
+		w := a.serveHTTP(t, alpha.token, graphQL(`{ team(name: "bravo-team") { name } user(id: "bravo-user") { id } pullRequest(id: "bravo-pr") { id } }`))
+		if got, want := strings.TrimSpace(w.Body.String()), `{"data":{"pullRequest":null,"team":null,"user":null}}`; got != want {
+			t.Errorf("graphql foreign lookups = %s, want %s", got, want)
+		}
+	})
+
+	t.Run("mutations", func(t *testing.T) {
+		a.checkHTTP(t, alpha.token, sharedMutations(alpha))
+		a.checkGRPC(t, alpha.token, grpcMutations())
+	})
+
+	t.Run("reads after mutations", func(t *testing.T) {
+		a.checkHTTP(t, alpha.token, readCalls())
+		a.checkGRPC(t, alpha.token, grpcReads())
+	})
+
+	if after := tenantState(t, a, bravo.orgID); after != before {
+		t.Errorf("organization B changed by calls from organization A:\nbefore %s\nafter  %s", before, after)
+	}
+	if _, err := a.tokens.Authenticate(context.Background(), bravo.token); err != nil {
+		t.Errorf("organization B token no longer authenticates: %v", err)
+	}
+
+	// Изменения A действительно применились в A
+	pr, err := a.prs.GetPR(testdb.AdminContext(alpha.orgID), "pr-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pr.Status != "MERGED" {
+		t.Errorf("organization A pr-1 status = %s, want MERGED", pr.Status)
+	}
+}
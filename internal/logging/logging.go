@@ -0,0 +1,61 @@
+// Package logging настраивает структурированный журнал slog в формате JSON.
+// Записи с контекстом (slog.InfoContext и т.п.) дополняются идентификатором
+// запроса, трассировки, организацией и вызывающим.
+package logging
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"strings"
+
+	"github.com/Vimp17/pr-reviewer-service/internal/auth"
+	"github.com/Vimp17/pr-reviewer-service/internal/requestid"
+	"github.com/Vimp17/pr-reviewer-service/internal/tenant"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// New создает JSON-логгер с указанным уровнем
+func New(w io.Writer, level slog.Leveler) *slog.Logger {
+	return slog.New(contextHandler{slog.NewJSONHandler(w, &slog.HandlerOptions{Level: level})})
+}
+
+// ParseLevel разбирает уровень debug, info, warn или error; пустая строка — info
+func ParseLevel(s string) (slog.Level, error) {
+	var level slog.Level
+	if s == "" {
+		return slog.LevelInfo, nil
+	}
+	err := level.UnmarshalText([]byte(strings.ToUpper(s)))
+	return level, err
+}
+
+// contextHandler добавляет к записи атрибуты запроса из контекста
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if id := requestid.FromContext(ctx); id != "" {
+		r.AddAttrs(slog.String("request_id", id))
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		r.AddAttrs(slog.String("trace_id", sc.TraceID().String()), slog.String("span_id", sc.SpanID().String()))
+	}
+	if identity, ok := auth.FromContext(ctx); ok {
+		orgID, ok := tenant.FromContext(ctx)
+		if !ok {
+			orgID = identity.OrgID
+		}
+		r.AddAttrs(slog.String("org_id", orgID), slog.String("actor", identity.Actor()))
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
@@ -0,0 +1,124 @@
+package metrics
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/Vimp17/pr-reviewer-service/internal/models"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// scrapeTimeout ограничивает запросы к БД при сборе метрик
+const scrapeTimeout = 5 * time.Second
+
+// poolCollector отдает статистику пула соединений pgx
+type poolCollector struct {
+	stat func() *pgxpool.Stat
+
+	acquired, idle, constructing, total, max  *prometheus.Desc
+	acquires, emptyAcquires, canceledAcquires *prometheus.Desc
+	acquireDuration                           *prometheus.Desc
+}
+
+// NewPoolCollector создает коллектор статистики пула; stat вызывается при каждом сборе
+func NewPoolCollector(stat func() *pgxpool.Stat) prometheus.Collector {
+	desc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(namespace, "db_pool", name), help, nil, nil)
+	}
+	return &poolCollector{
+		stat:             stat,
+		acquired:         desc("acquired_conns", "Connections currently in use."),
+		idle:             desc("idle_conns", "Idle connections."),
+		constructing:     desc("constructing_conns", "Connections being established."),
+		total:            desc("total_conns", "Total connections in the pool."),
+		max:              desc("max_conns", "Maximum pool size."),
+		acquires:         desc("acquires_total", "Successful connection acquisitions."),
+		emptyAcquires:    desc("empty_acquires_total", "Acquisitions that had to wait for a connection."),
+		canceledAcquires: desc("canceled_acquires_total", "Acquisitions canceled by context."),
+		acquireDuration:  desc("acquire_duration_seconds_total", "Total time spent acquiring connections."),
+	}
+}
+
+func (c *poolCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, d := range []*prometheus.Desc{
+		c.acquired, c.idle, c.constructing, c.total, c.max,
+		c.acquires, c.emptyAcquires, c.canceledAcquires, c.acquireDuration,
+	} {
+		ch <- d
+	}
+}
+
+func (c *poolCollector) Collect(ch chan<- prometheus.Metric) {
+	s := c.stat()
+	ch <- prometheus.MustNewConstMetric(c.acquired, prometheus.GaugeValue, float64(s.AcquiredConns()))
+	ch <- prometheus.MustNewConstMetric(c.idle, prometheus.GaugeValue, float64(s.IdleConns()))
+	ch <- prometheus.MustNewConstMetric(c.constructing, prometheus.GaugeValue, float64(s.ConstructingConns()))
+	ch <- prometheus.MustNewConstMetric(c.total, prometheus.GaugeValue, float64(s.TotalConns()))
+	ch <- prometheus.MustNewConstMetric(c.max, prometheus.GaugeValue, float64(s.MaxConns()))
+	ch <- prometheus.MustNewConstMetric(c.acquires, prometheus.CounterValue, float64(s.AcquireCount()))
+	ch <- prometheus.MustNewConstMetric(c.emptyAcquires, prometheus.CounterValue, float64(s.EmptyAcquireCount()))
+	ch <- prometheus.MustNewConstMetric(c.canceledAcquires, prometheus.CounterValue, float64(s.CanceledAcquireCount()))
+	ch <- prometheus.MustNewConstMetric(c.acquireDuration, prometheus.CounterValue, s.AcquireDuration().Seconds())
+}
+
+// openReviewsCollector отдает число открытых ревью по ревьюерам и командам,
+// считая его в БД при каждом сборе
+type openReviewsCollector struct {
+	load func(ctx context.Context) ([]models.ReviewLoad, error)
+
+	reviewer, team, scrapeError *prometheus.Desc
+}
+
+// NewOpenReviewsCollector создает коллектор открытых ревью; load возвращает
+// нагрузку ревьюеров всех организаций
+func NewOpenReviewsCollector(load func(ctx context.Context) ([]models.ReviewLoad, error)) prometheus.Collector {
+	return &openReviewsCollector{
+		load: load,
+		reviewer: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "open_reviews"),
+			"Open pull requests assigned to the reviewer.",
+			[]string{"org_id", "team", "reviewer"}, nil,
+		),
+		team: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "team_open_reviews"),
+			"Open review assignments of the team members.",
+			[]string{"org_id", "team"}, nil,
+		),
+		scrapeError: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "open_reviews_scrape_error"),
+			"Whether loading open reviews for this scrape failed (1) or not (0).",
+			nil, nil,
+		),
+	}
+}
+
+func (c *openReviewsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.reviewer
+	ch <- c.team
+	ch <- c.scrapeError
+}
+
+func (c *openReviewsCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), scrapeTimeout)
+	defer cancel()
+
+	loads, err := c.load(ctx)
+	if err != nil {
+		slog.Error("failed to load open reviews for metrics", "error", err)
+		ch <- prometheus.MustNewConstMetric(c.scrapeError, prometheus.GaugeValue, 1)
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(c.scrapeError, prometheus.GaugeValue, 0)
+
+	type teamKey struct{ org, team string }
+	teams := make(map[teamKey]int)
+	for _, l := range loads {
+		ch <- prometheus.MustNewConstMetric(c.reviewer, prometheus.GaugeValue, float64(l.Open), l.OrgID, l.TeamName, l.UserID)
+		teams[teamKey{l.OrgID, l.TeamName}] += l.Open
+	}
+	for k, open := range teams {
+		ch <- prometheus.MustNewConstMetric(c.team, prometheus.GaugeValue, float64(open), k.org, k.team)
+	}
+}
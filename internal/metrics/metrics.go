@@ -0,0 +1,144 @@
+// Package metrics содержит метрики Prometheus сервиса и обработчик /metrics
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Vimp17/pr-reviewer-service/internal/tenant"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "pr_reviewer"
+
+// Registry реестр метрик сервиса; коллекторы БД регистрируются при запуске
+var Registry = prometheus.NewRegistry()
+
+var (
+	httpRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "requests_total",
+		Help:      "HTTP requests by method, route and status.",
+	}, []string{"method", "route", "status"})
+
+	httpDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "HTTP request latency by method, route and status.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	prCreated = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "pull_requests_created_total",
+		Help:      "Pull requests created.",
+	}, []string{"org_id"})
+
+	prMerged = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "pull_requests_merged_total",
+		Help:      "Pull requests merged.",
+	}, []string{"org_id"})
+
+	prReassigned = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "reviewer_reassignments_total",
+		Help:      "Reviewer reassignments.",
+	}, []string{"org_id"})
+
+	noCandidate = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "no_candidate_total",
+		Help:      "Reassignments rejected with NO_CANDIDATE by reviewer team.",
+	}, []string{"org_id", "team"})
+
+	jobRuns = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "job",
+		Name:      "runs_total",
+		Help:      "Background job runs by result (success or error).",
+	}, []string{"job", "result"})
+
+	jobLastRun = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "job",
+		Name:      "last_run_timestamp_seconds",
+		Help:      "Unix time of the last background job run.",
+	}, []string{"job"})
+
+	jobLastSuccess = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "job",
+		Name:      "last_success_timestamp_seconds",
+		Help:      "Unix time of the last successful background job run.",
+	}, []string{"job"})
+
+	jobUp = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "job",
+		Name:      "up",
+		Help:      "Whether the last background job run succeeded (1) or failed (0).",
+	}, []string{"job"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		httpRequests, httpDuration,
+		prCreated, prMerged, prReassigned, noCandidate,
+		jobRuns, jobLastRun, jobLastSuccess, jobUp,
+	)
+}
+
+// Handler отдает метрики из Registry в формате Prometheus
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
+
+// ObserveHTTP учитывает обработанный HTTP-запрос
+func ObserveHTTP(method, route string, status int, elapsed time.Duration) {
+	code := strconv.Itoa(status)
+	httpRequests.WithLabelValues(method, route, code).Inc()
+	httpDuration.WithLabelValues(method, route, code).Observe(elapsed.Seconds())
+}
+
+// PRCreated учитывает созданный PR в организации из контекста
+func PRCreated(ctx context.Context) {
+	prCreated.WithLabelValues(tenant.MustOrgID(ctx)).Inc()
+}
+
+// PRMerged учитывает слитый PR
+func PRMerged(ctx context.Context) {
+	prMerged.WithLabelValues(tenant.MustOrgID(ctx)).Inc()
+}
+
+// ReviewerReassigned учитывает переназначение ревьюера
+func ReviewerReassigned(ctx context.Context) {
+	prReassigned.WithLabelValues(tenant.MustOrgID(ctx)).Inc()
+}
+
+// NoCandidate учитывает отказ в переназначении из-за отсутствия замены в команде
+func NoCandidate(ctx context.Context, team string) {
+	noCandidate.WithLabelValues(tenant.MustOrgID(ctx), team).Inc()
+}
+
+// JobRun учитывает запуск фоновой задачи и его результат
+func JobRun(job string, err error) {
+	now := float64(time.Now().Unix())
+	jobLastRun.WithLabelValues(job).Set(now)
+	if err != nil {
+		jobRuns.WithLabelValues(job, "error").Inc()
+		jobUp.WithLabelValues(job).Set(0)
+		return
+	}
+	jobRuns.WithLabelValues(job, "success").Inc()
+	jobLastSuccess.WithLabelValues(job).Set(now)
+	jobUp.WithLabelValues(job).Set(1)
+}
@@ -1,6 +1,9 @@
 package models
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
 type User struct {
 	UserID   string `json:"user_id"`
@@ -20,6 +23,7 @@ type PullRequest struct {
 	AuthorID          string     `json:"author_id"`
 	Status            string     `json:"status"` // OPEN | MERGED
 	AssignedReviewers []string   `json:"assigned_reviewers"`
+	Version           int        `json:"version"`
 	CreatedAt         *time.Time `json:"createdAt,omitempty"`
 	MergedAt          *time.Time `json:"mergedAt,omitempty"`
 }
@@ -30,3 +34,298 @@ type PullRequestShort struct {
 	AuthorID        string `json:"author_id"`
 	Status          string `json:"status"`
 }
+
+// ReviewerPR PR в списке ревью пользователя: возраст и последний вердикт этого ревьюера
+type ReviewerPR struct {
+	PullRequestShort
+	CreatedAt  *time.Time `json:"createdAt,omitempty"`
+	AgeSeconds int64      `json:"age_seconds"` // до слияния или до текущего момента
+	Verdict    string     `json:"verdict,omitempty"`
+}
+
+// ReviewFilter фильтр и страница списка ревью пользователя
+type ReviewFilter struct {
+	ReviewerID string
+	Statuses   []string // OPEN | MERGED
+	After      *ListCursor
+	Limit      int
+}
+
+// Стратегии назначения ревьюеров
+const (
+	AssignmentRandom      = "random"
+	AssignmentLeastLoaded = "least_loaded"
+)
+
+type OrgSettings struct {
+	ReviewersPerPR     int    `json:"reviewers_per_pr"`
+	AssignmentStrategy string `json:"assignment_strategy"` // random | least_loaded
+}
+
+type Organization struct {
+	OrgID    string      `json:"org_id"`
+	Name     string      `json:"name"`
+	Settings OrgSettings `json:"settings"`
+}
+
+type APIToken struct {
+	TokenID   int64      `json:"token_id"`
+	Name      string     `json:"name"`
+	UserID    string     `json:"user_id,omitempty"`
+	Role      string     `json:"role"` // admin | team-lead | member | bot
+	CreatedAt *time.Time `json:"createdAt,omitempty"`
+	RevokedAt *time.Time `json:"revokedAt,omitempty"`
+}
+
+type AuditEntry struct {
+	ID         int64           `json:"id"`
+	Actor      string          `json:"actor"`
+	Action     string          `json:"action"`
+	TargetType string          `json:"target_type"`
+	TargetID   string          `json:"target_id"`
+	Before     json.RawMessage `json:"before,omitempty"`
+	After      json.RawMessage `json:"after,omitempty"`
+	RequestID  string          `json:"request_id,omitempty"`
+	CreatedAt  *time.Time      `json:"createdAt,omitempty"`
+}
+
+type AuditFilter struct {
+	Actor      string
+	Action     string
+	TargetType string
+	TargetID   string
+	From       *time.Time
+	To         *time.Time
+	Limit      int
+	Offset     int
+}
+
+// ListCursor позиция в списке для курсорной пагинации: значение поля
+// сортировки и ID последней записи страницы
+type ListCursor struct {
+	Sort  string `json:"s"`
+	Value string `json:"v"`
+	ID    string `json:"id"`
+}
+
+// PRListFilter фильтр, сортировка и страница списка PR
+type PRListFilter struct {
+	Status       string
+	AuthorID     string
+	ReviewerID   string
+	TeamName     string // команда автора
+	NameContains string
+	CreatedFrom  *time.Time
+	CreatedTo    *time.Time
+	MergedFrom   *time.Time
+	MergedTo     *time.Time
+	Sort         string // created_at | pull_request_name | pull_request_id
+	Desc         bool
+	After        *ListCursor
+	Limit        int
+}
+
+// UserListFilter фильтр, сортировка и страница списка пользователей
+type UserListFilter struct {
+	TeamName string
+	IsActive *bool
+	Query    string // подстрока user_id или username
+	Sort     string // user_id | username
+	Desc     bool
+	After    *ListCursor
+	Limit    int
+}
+
+// StatsFilter охват статистики: PR команды автора, созданные в [From, To)
+type StatsFilter struct {
+	TeamName string     `json:"team_name,omitempty"`
+	From     *time.Time `json:"from,omitempty"`
+	To       *time.Time `json:"to,omitempty"`
+}
+
+// ReviewerStats нагрузка ревьюера в охвате статистики
+type ReviewerStats struct {
+	UserID   string `json:"user_id"`
+	TeamName string `json:"team_name"`
+	Open     int    `json:"open"`
+	Merged   int    `json:"merged"`
+	Total    int    `json:"total"`
+}
+
+// TeamStats итоги по PR, авторы которых состоят в команде
+type TeamStats struct {
+	TeamName     string `json:"team_name"`
+	PullRequests int    `json:"pull_requests"`
+	Open         int    `json:"open"`
+	Merged       int    `json:"merged"`
+	Assignments  int    `json:"assignments"`
+}
+
+// DurationPercentiles перцентили длительности в секундах; nil, если выборка пуста
+type DurationPercentiles struct {
+	Count int      `json:"count"`
+	P50   *float64 `json:"p50"`
+	P90   *float64 `json:"p90"`
+	P99   *float64 `json:"p99"`
+}
+
+// Stats статистика назначений и слияний
+type Stats struct {
+	Filter                StatsFilter         `json:"filter"`
+	PullRequests          int                 `json:"pull_requests"`
+	Reviewers             []ReviewerStats     `json:"reviewers"`
+	Teams                 []TeamStats         `json:"teams"`
+	TimeToMerge           DurationPercentiles `json:"time_to_merge"`
+	Reassignments         int                 `json:"reassignments"`
+	AvgReassignmentsPerPR float64             `json:"avg_reassignments_per_pr"`
+	// LoadGini коэффициент Джини по числу назначений ревьюеров: 0 — нагрузка
+	// распределена поровну, ближе к 1 — сосредоточена на немногих
+	LoadGini float64 `json:"load_gini"`
+}
+
+// ReviewLoad число открытых PR на ревью у пользователя
+type ReviewLoad struct {
+	OrgID    string
+	UserID   string
+	TeamName string
+	Open     int
+}
+
+// Latency задержки ревью и слияния. Для PR, команды и недели первое ревью —
+// первый вердикт любого ревьюера после создания PR; для ревьюера — его первый
+// вердикт после назначения.
+type Latency struct {
+	TimeToFirstReview DurationPercentiles `json:"time_to_first_review"`
+	TimeToMerge       DurationPercentiles `json:"time_to_merge"`
+}
+
+// TeamLatency задержки по PR, авторы которых состоят в команде
+type TeamLatency struct {
+	TeamName string `json:"team_name"`
+	Latency
+}
+
+// ReviewerLatency задержки по назначениям ревьюера
+type ReviewerLatency struct {
+	UserID   string `json:"user_id"`
+	TeamName string `json:"team_name"`
+	Latency
+}
+
+// WeeklyLatency задержки по PR, созданным за неделю (с понедельника, UTC)
+type WeeklyLatency struct {
+	WeekStart    time.Time `json:"week_start"`
+	PullRequests int       `json:"pull_requests"`
+	Latency
+}
+
+// LatencyStats аналитика задержек; Weekly — непрерывный ряд недель периода
+type LatencyStats struct {
+	Filter    StatsFilter       `json:"filter"`
+	Overall   Latency           `json:"overall"`
+	Teams     []TeamLatency     `json:"teams"`
+	Reviewers []ReviewerLatency `json:"reviewers"`
+	Weekly    []WeeklyLatency   `json:"weekly"`
+}
+
+// IntegrityIssue результат одной проверки целостности данных
+type IntegrityIssue struct {
+	Check       string   `json:"check"`
+	Description string   `json:"description"`
+	Count       int      `json:"count"`
+	Examples    []string `json:"examples"` // до 10 ссылок вида org_id/id
+	Repairable  bool     `json:"repairable"`
+	Repaired    int      `json:"repaired"`
+	// Constraint ограничение схемы, которое защищает от нарушения, и проверено
+	// ли оно для существующих строк (VALIDATE CONSTRAINT)
+	Constraint string `json:"constraint,omitempty"`
+	Validated  bool   `json:"validated"`
+}
+
+// Виды записей выгрузки организации
+const (
+	RecordSettings    = "settings"
+	RecordTeam        = "team"
+	RecordUser        = "user"
+	RecordPullRequest = "pull_request"
+	RecordPREvent     = "pr_event"
+)
+
+type SnapshotTeam struct {
+	TeamName string `json:"team_name"`
+}
+
+// Snapshot полная выгрузка данных организации для резервного копирования
+// и переноса между экземплярами сервиса: настройки назначения, команды,
+// пользователи, PR и их история, включая вердикты
+type Snapshot struct {
+	Settings     *OrgSettings   `json:"settings,omitempty"` // nil при импорте — настройки не меняются
+	Teams        []SnapshotTeam `json:"teams"`
+	Users        []User         `json:"users"`
+	PullRequests []PullRequest  `json:"pull_requests"`
+	PREvents     []PREvent      `json:"pr_events"`
+}
+
+// ImportCounts число созданных и обновленных записей одного вида
+type ImportCounts struct {
+	Created int `json:"created"`
+	Updated int `json:"updated"`
+}
+
+// ImportError ошибка проверки одной записи импорта
+type ImportError struct {
+	Kind     string `json:"kind"`
+	Index    int    `json:"index"`              // номер записи этого вида, с нуля
+	Position string `json:"position,omitempty"` // место во входных данных: line 12, users[3]
+	ID       string `json:"id,omitempty"`
+	Code     string `json:"code"`
+	Message  string `json:"message"`
+}
+
+// ImportReport итог импорта. Записи применяются, только если ни одна не
+// содержит ошибок и это не пробный запуск.
+type ImportReport struct {
+	DryRun       bool          `json:"dry_run"`
+	Applied      bool          `json:"applied"`
+	Teams        ImportCounts  `json:"teams"`
+	Users        ImportCounts  `json:"users"`
+	PullRequests ImportCounts  `json:"pull_requests"`
+	PREvents     ImportCounts  `json:"pr_events"`
+	Settings     ImportCounts  `json:"settings"`
+	Errors       []ImportError `json:"errors"`
+}
+
+// Типы событий в истории PR
+const (
+	PREventCreated    = "CREATED"
+	PREventAssigned   = "ASSIGNED"
+	PREventReassigned = "REASSIGNED"
+	PREventUnassigned = "UNASSIGNED" // ревьюер снят без замены (integrity -repair)
+	PREventVerdict    = "VERDICT"
+	PREventMerged     = "MERGED"
+)
+
+// Вердикты ревьюера
+const (
+	VerdictApproved         = "APPROVED"
+	VerdictChangesRequested = "CHANGES_REQUESTED"
+)
+
+type PREvent struct {
+	ID            int64      `json:"id"`
+	PullRequestID string     `json:"pull_request_id"`
+	EventType     string     `json:"event_type"` // CREATED | ASSIGNED | REASSIGNED | UNASSIGNED | VERDICT | MERGED
+	ReviewerID    string     `json:"reviewer_id,omitempty"`
+	OldReviewerID string     `json:"old_reviewer_id,omitempty"`
+	Verdict       string     `json:"verdict,omitempty"` // APPROVED | CHANGES_REQUESTED
+	Actor         string     `json:"actor"`
+	Reason        string     `json:"reason,omitempty"`
+	CreatedAt     *time.Time `json:"createdAt,omitempty"`
+}
+
+type IdempotentResponse struct {
+	RequestHash string
+	StatusCode  int // 0, пока первый запрос выполняется
+	ContentType string
+	Body        []byte
+}
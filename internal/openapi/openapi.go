@@ -0,0 +1,53 @@
+// Package openapi встраивает спецификацию OpenAPI 3 сервиса
+package openapi
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"sync"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+//go:embed openapi.yaml
+var specYAML []byte
+
+var (
+	loadOnce sync.Once
+	spec     *openapi3.T
+	specJSON []byte
+	loadErr  error
+)
+
+// Spec возвращает разобранную и проверенную спецификацию
+func Spec() (*openapi3.T, error) {
+	loadOnce.Do(load)
+	return spec, loadErr
+}
+
+// JSON возвращает спецификацию в формате JSON
+func JSON() ([]byte, error) {
+	loadOnce.Do(load)
+	return specJSON, loadErr
+}
+
+func load() {
+	loader := openapi3.NewLoader()
+	doc, err := loader.LoadFromData(specYAML)
+	if err != nil {
+		loadErr = err
+		return
+	}
+	if err := doc.Validate(context.Background()); err != nil {
+		loadErr = err
+		return
+	}
+
+	data, err := json.Marshal(doc)
+	if err != nil {
+		loadErr = err
+		return
+	}
+	spec, specJSON = doc, data
+}
@@ -0,0 +1,96 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/Vimp17/pr-reviewer-service/internal/auth"
+	"github.com/Vimp17/pr-reviewer-service/internal/models"
+	"github.com/Vimp17/pr-reviewer-service/internal/requestid"
+	"github.com/Vimp17/pr-reviewer-service/internal/storage/postgres"
+)
+
+// Действия, записываемые в журнал аудита
+const (
+	AuditTeamCreate    = "team.create"
+	AuditUserSetActive = "user.set_active"
+	AuditPRCreate      = "pr.create"
+	AuditPRMerge       = "pr.merge"
+	AuditPRReassign    = "pr.reassign"
+	AuditPRVerdict     = "pr.verdict"
+	AuditOrgCreate     = "organization.create"
+	AuditOrgSettings   = "organization.update_settings"
+	AuditDataImport    = "organization.import"
+	AuditTokenCreate   = "token.create"
+	AuditTokenRevoke   = "token.revoke"
+)
+
+const (
+	auditActorSystem     = "system"
+	defaultAuditPageSize = 50
+	maxAuditPageSize     = 500
+)
+
+// AuditService предоставляет доступ к журналу аудита
+type AuditService struct {
+	storage *postgres.Storage
+}
+
+// NewAuditService создает новый сервис журнала аудита
+func NewAuditService(storage *postgres.Storage) *AuditService {
+	return &AuditService{storage: storage}
+}
+
+// ListEntries возвращает страницу записей журнала и общее число записей по фильтру
+func (s *AuditService) ListEntries(ctx context.Context, filter models.AuditFilter) ([]models.AuditEntry, int, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = defaultAuditPageSize
+	}
+	if filter.Limit > maxAuditPageSize {
+		filter.Limit = maxAuditPageSize
+	}
+	if filter.Offset < 0 {
+		filter.Offset = 0
+	}
+	return s.storage.ListAuditEntries(ctx, filter)
+}
+
+// recordAudit записывает изменяющую операцию в журнал аудита. Вызывается в
+// транзакции storage.InTx вместе с самим изменением: если запись не удалась,
+// изменение откатывается и запрос завершается ошибкой.
+func recordAudit(ctx context.Context, storage *postgres.Storage, action, targetType, targetID string, before, after any) error {
+	entry := models.AuditEntry{
+		Actor:      callerActor(ctx),
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   targetID,
+		Before:     marshalAuditState(before),
+		After:      marshalAuditState(after),
+		RequestID:  requestid.FromContext(ctx),
+	}
+
+	if err := storage.InsertAuditEntry(ctx, entry); err != nil {
+		return fmt.Errorf("write audit entry %s %s/%s: %w", action, targetType, targetID, err)
+	}
+	return nil
+}
+
+// callerActor возвращает идентификатор вызывающего для журналов и истории
+func callerActor(ctx context.Context) string {
+	if identity, ok := auth.FromContext(ctx); ok {
+		return identity.Actor()
+	}
+	return auditActorSystem
+}
+
+func marshalAuditState(state any) json.RawMessage {
+	if state == nil {
+		return nil
+	}
+	data, err := json.Marshal(state)
+	if err != nil {
+		return nil
+	}
+	return data
+}
@@ -0,0 +1,105 @@
+package services
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/Vimp17/pr-reviewer-service/internal/models"
+	"github.com/Vimp17/pr-reviewer-service/internal/requestid"
+	"github.com/Vimp17/pr-reviewer-service/internal/testdb"
+	"github.com/jackc/pgx/v5"
+)
+
+func TestAuditWrittenWithMutation(t *testing.T) {
+	storage := testdb.Open(t)
+	orgID := testdb.NewOrg(t, storage, "audit")
+	ctx := testdb.AdminContext(orgID)
+
+	teams := NewTeamService(storage)
+	users := NewUserService(storage)
+	audit := NewAuditService(storage)
+
+	if _, err := teams.CreateTeam(ctx, models.Team{
+		TeamName: "backend",
+		Members:  []models.User{{UserID: "u1", Username: "Alice", IsActive: true}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := users.SetUserActiveStatus(ctx, "u1", false); err != nil {
+		t.Fatal(err)
+	}
+
+	// Запись аудита не удалась (request_id длиннее столбца): изменение откатывается
+	failing := requestid.WithRequestID(ctx, strings.Repeat("x", 300))
+	if _, err := users.SetUserActiveStatus(failing, "u1", true); err == nil {
+		t.Fatal("SetUserActiveStatus succeeded without audit entry")
+	}
+	user, err := storage.GetUser(ctx, "u1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.IsActive {
+		t.Error("user activated although audit entry was not written")
+	}
+	if _, err := teams.CreateTeam(failing, models.Team{
+		TeamName: "frontend",
+		Members:  []models.User{{UserID: "u2", Username: "Bob", IsActive: true}},
+	}); err == nil {
+		t.Fatal("CreateTeam succeeded without audit entry")
+	}
+	if exists, err := storage.CheckTeamExists(ctx, "frontend"); err != nil || exists {
+		t.Errorf("team created without audit entry: exists %v, error %v", exists, err)
+	}
+
+	entries, total, err := audit.ListEntries(ctx, models.AuditFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 2 {
+		t.Fatalf("audit entries = %d, want 2", total)
+	}
+	if entries[0].Action != AuditUserSetActive || entries[1].Action != AuditTeamCreate {
+		t.Errorf("audit actions = %s, %s", entries[0].Action, entries[1].Action)
+	}
+}
+
+func TestAuditLogIsAppendOnly(t *testing.T) {
+	storage := testdb.Open(t)
+	orgID := testdb.NewOrg(t, storage, "audit")
+	if _, err := NewTeamService(storage).CreateTeam(testdb.AdminContext(orgID), models.Team{
+		TeamName: "backend",
+		Members:  []models.User{{UserID: "u1", Username: "Alice", IsActive: true}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	conn, err := pgx.Connect(context.Background(), os.Getenv(testdb.EnvDSN))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close(context.Background())
+
+	statements := map[string]string{
+		"update":       "UPDATE audit_log SET actor = 'x' WHERE org_id = $1",
+		"delete":       "DELETE FROM audit_log WHERE org_id = $1",
+		"delete org":   "DELETE FROM organizations WHERE org_id = $1",
+		"truncate":     "TRUNCATE audit_log",
+		"truncate org": "TRUNCATE organizations CASCADE",
+	}
+	for name, sql := range statements {
+		tx, err := conn.Begin(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		var args []any
+		if strings.Contains(sql, "$1") {
+			args = append(args, orgID)
+		}
+		if _, err := tx.Exec(context.Background(), sql, args...); err == nil {
+			t.Errorf("%s: audit_log entries removed or changed", name)
+		}
+		tx.Rollback(context.Background())
+	}
+}
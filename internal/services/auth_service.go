@@ -0,0 +1,192 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strconv"
+
+	"github.com/Vimp17/pr-reviewer-service/internal/auth"
+	"github.com/Vimp17/pr-reviewer-service/internal/models"
+	"github.com/Vimp17/pr-reviewer-service/internal/storage/postgres"
+	"github.com/Vimp17/pr-reviewer-service/internal/tenant"
+)
+
+var (
+	ErrForbidden    = errors.New("FORBIDDEN")
+	ErrInvalidRole  = errors.New("INVALID_ROLE")
+	ErrUserNotFound = errors.New("USER_NOT_FOUND")
+)
+
+// AuthService выпускает и проверяет API-токены
+type AuthService struct {
+	storage *postgres.Storage
+}
+
+// NewAuthService создает новый сервис аутентификации
+func NewAuthService(storage *postgres.Storage) *AuthService {
+	return &AuthService{storage: storage}
+}
+
+// Authenticate проверяет API-токен и возвращает личность вызывающего
+func (s *AuthService) Authenticate(ctx context.Context, token string) (*auth.Identity, error) {
+	if token == "" {
+		return nil, auth.ErrUnauthenticated
+	}
+
+	stored, orgID, err := s.storage.GetAPITokenByHash(ctx, auth.HashToken(token))
+	if err != nil {
+		if errors.Is(err, postgres.ErrNotFound) {
+			return nil, auth.ErrUnauthenticated
+		}
+		return nil, err
+	}
+
+	return &auth.Identity{
+		OrgID:  orgID,
+		UserID: stored.UserID,
+		Name:   stored.Name,
+		Roles:  []auth.Role{auth.Role(stored.Role)},
+	}, nil
+}
+
+// CreateToken выпускает новый токен в организации из контекста.
+// Открытое значение токена возвращается только один раз.
+func (s *AuthService) CreateToken(ctx context.Context, name, userID string, role auth.Role) (string, *models.APIToken, error) {
+	if !auth.ValidRole(role) {
+		return "", nil, ErrInvalidRole
+	}
+
+	// Токен, привязанный к пользователю, должен ссылаться на пользователя своей организации
+	if userID != "" {
+		if _, err := s.storage.GetUser(ctx, userID); err != nil {
+			if errors.Is(err, postgres.ErrNotFound) {
+				return "", nil, ErrUserNotFound
+			}
+			return "", nil, err
+		}
+	}
+
+	plain, err := auth.GenerateToken()
+	if err != nil {
+		return "", nil, err
+	}
+
+	var token *models.APIToken
+	err = s.storage.InTx(ctx, func(ctx context.Context) error {
+		var err error
+		token, err = s.storage.CreateAPIToken(ctx, auth.HashToken(plain), models.APIToken{
+			Name:   name,
+			UserID: userID,
+			Role:   string(role),
+		})
+		if err != nil {
+			return err
+		}
+		return recordAudit(ctx, s.storage, AuditTokenCreate, "api_token", strconv.FormatInt(token.TokenID, 10), nil, token)
+	})
+	if err != nil {
+		return "", nil, err
+	}
+
+	return plain, token, nil
+}
+
+// RevokeToken отзывает токен организации из контекста
+func (s *AuthService) RevokeToken(ctx context.Context, tokenID int64) error {
+	err := s.storage.InTx(ctx, func(ctx context.Context) error {
+		if err := s.storage.RevokeAPIToken(ctx, tokenID); err != nil {
+			return err
+		}
+		return recordAudit(ctx, s.storage, AuditTokenRevoke, "api_token", strconv.FormatInt(tokenID, 10), nil, nil)
+	})
+	if errors.Is(err, postgres.ErrNotFound) {
+		return ErrNotFound
+	}
+	return err
+}
+
+// EnsureBootstrapToken регистрирует заранее известный токен администратора
+// организации по умолчанию, чтобы можно было выпустить остальные токены
+func (s *AuthService) EnsureBootstrapToken(ctx context.Context, token string) error {
+	ctx = tenant.WithOrgID(ctx, tenant.DefaultOrgID)
+	return s.storage.EnsureAPIToken(ctx, auth.HashToken(token), models.APIToken{
+		Name: "bootstrap-admin",
+		Role: string(auth.RoleAdmin),
+	})
+}
+
+// Проверки прав доступа.
+// Вызовы без личности в контексте отклоняются с auth.ErrUnauthenticated:
+// HTTP и gRPC устанавливают личность при аутентификации, административные
+// команды и фоновые задачи — через auth.SystemIdentity.
+
+// callerIdentity возвращает личность вызывающего или auth.ErrUnauthenticated
+func callerIdentity(ctx context.Context) (*auth.Identity, error) {
+	identity, ok := auth.FromContext(ctx)
+	if !ok {
+		return nil, auth.ErrUnauthenticated
+	}
+	return identity, nil
+}
+
+// authorizeOperator разрешает управление организациями сервиса внутреннему
+// вызывающему (auth.SystemIdentity) и администратору организации по умолчанию;
+// администратор любой другой организации получает ErrForbidden
+func authorizeOperator(ctx context.Context) error {
+	identity, err := callerIdentity(ctx)
+	if err != nil {
+		return err
+	}
+	if identity.System || identity.HasRole(auth.RoleAdmin) && identity.OrgID == tenant.DefaultOrgID {
+		return nil
+	}
+	return ErrForbidden
+}
+
+// authorizeTeam разрешает действие администратору или лиду указанной команды
+func authorizeTeam(ctx context.Context, storage *postgres.Storage, teamName string) error {
+	identity, err := callerIdentity(ctx)
+	if err != nil {
+		return err
+	}
+	if identity.HasRole(auth.RoleAdmin) {
+		return nil
+	}
+	if identity.HasRole(auth.RoleTeamLead) && identity.UserID != "" {
+		lead, err := storage.GetUser(ctx, identity.UserID)
+		if err != nil {
+			if errors.Is(err, postgres.ErrNotFound) {
+				return ErrForbidden
+			}
+			return err
+		}
+		if lead.TeamName == teamName {
+			return nil
+		}
+	}
+	return ErrForbidden
+}
+
+// authorizePR разрешает действие над PR автору, лиду команды автора,
+// администратору, а также указанным дополнительным ролям
+func authorizePR(ctx context.Context, storage *postgres.Storage, pr *models.PullRequest, extra ...auth.Role) error {
+	identity, err := callerIdentity(ctx)
+	if err != nil {
+		return err
+	}
+	if identity.HasRole(auth.RoleAdmin) || identity.HasRole(extra...) {
+		return nil
+	}
+	if identity.UserID != "" && identity.UserID == pr.AuthorID {
+		return nil
+	}
+
+	author, err := storage.GetUser(ctx, pr.AuthorID)
+	if err != nil {
+		if errors.Is(err, postgres.ErrNotFound) {
+			return ErrForbidden
+		}
+		return err
+	}
+	return authorizeTeam(ctx, storage, author.TeamName)
+}
@@ -0,0 +1,68 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Vimp17/pr-reviewer-service/internal/auth"
+	"github.com/Vimp17/pr-reviewer-service/internal/models"
+)
+
+// Без личности в контексте проверки прав отказывают до обращения к БД
+func TestAuthorizationWithoutIdentity(t *testing.T) {
+	ctx := context.Background()
+	prs := NewPRService(nil)
+
+	checks := map[string]error{
+		"authorizeTeam": authorizeTeam(ctx, nil, "backend"),
+		"authorizePR":   authorizePR(ctx, nil, &models.PullRequest{PullRequestID: "pr-1", AuthorID: "u1"}),
+		"SubmitVerdict": prs.SubmitVerdict(ctx, "pr-1", "u2", models.VerdictApproved, ""),
+	}
+	_, err := prs.CreatePR(ctx, models.PullRequest{PullRequestID: "pr-1", PullRequestName: "Fix", AuthorID: "u1"})
+	checks["CreatePR"] = err
+
+	for name, err := range checks {
+		if !errors.Is(err, auth.ErrUnauthenticated) {
+			t.Errorf("%s without identity: error %v, want %v", name, err, auth.ErrUnauthenticated)
+		}
+	}
+}
+
+func TestAuthorizationSystemIdentity(t *testing.T) {
+	ctx := auth.WithIdentity(context.Background(), auth.SystemIdentity("default", "cli"))
+
+	if err := authorizeTeam(ctx, nil, "backend"); err != nil {
+		t.Errorf("authorizeTeam with system identity: %v", err)
+	}
+	if err := authorizePR(ctx, nil, &models.PullRequest{PullRequestID: "pr-1", AuthorID: "u1"}); err != nil {
+		t.Errorf("authorizePR with system identity: %v", err)
+	}
+	if actor := callerActor(ctx); actor != "cli" {
+		t.Errorf("callerActor = %q, want cli", actor)
+	}
+}
+
+// Организациями управляют внутренний вызывающий и администратор организации по
+// умолчанию, но не администратор другой организации
+func TestAuthorizeOperator(t *testing.T) {
+	tests := []struct {
+		name     string
+		identity *auth.Identity
+		want     error
+	}{
+		{"system identity of another org", auth.SystemIdentity("acme", "cli"), nil},
+		{"default org admin", &auth.Identity{OrgID: "default", Name: "ops", Roles: []auth.Role{auth.RoleAdmin}}, nil},
+		{"default org member", &auth.Identity{OrgID: "default", UserID: "u1", Roles: []auth.Role{auth.RoleMember}}, ErrForbidden},
+		{"another org admin", &auth.Identity{OrgID: "acme", Name: "acme-admin", Roles: []auth.Role{auth.RoleAdmin}}, ErrForbidden},
+	}
+	for _, tt := range tests {
+		ctx := auth.WithIdentity(context.Background(), tt.identity)
+		if err := authorizeOperator(ctx); !errors.Is(err, tt.want) {
+			t.Errorf("%s: error %v, want %v", tt.name, err, tt.want)
+		}
+	}
+	if err := authorizeOperator(context.Background()); !errors.Is(err, auth.ErrUnauthenticated) {
+		t.Errorf("without identity: error %v, want %v", err, auth.ErrUnauthenticated)
+	}
+}
@@ -0,0 +1,70 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Vimp17/pr-reviewer-service/internal/models"
+	"github.com/Vimp17/pr-reviewer-service/internal/storage/postgres"
+)
+
+var (
+	ErrIdempotencyMismatch   = errors.New("IDEMPOTENCY_KEY_REUSED")
+	ErrIdempotencyInProgress = errors.New("IDEMPOTENCY_IN_PROGRESS")
+)
+
+// DefaultIdempotencyTTL время хранения ответа для повторов с тем же ключом
+const DefaultIdempotencyTTL = 24 * time.Hour
+
+// IdempotencyService хранит ответы на запросы с ключом идемпотентности.
+// Ключи принадлежат вызывающему из контекста: другой вызывающий с тем же ключом
+// выполняет свой запрос, а не получает чужой ответ.
+type IdempotencyService struct {
+	storage *postgres.Storage
+	ttl     time.Duration
+}
+
+// NewIdempotencyService создает новый сервис идемпотентности
+func NewIdempotencyService(storage *postgres.Storage, ttl time.Duration) *IdempotencyService {
+	if ttl <= 0 {
+		ttl = DefaultIdempotencyTTL
+	}
+	return &IdempotencyService{storage: storage, ttl: ttl}
+}
+
+// Begin резервирует ключ за запросом с указанным хэшем.
+// Возвращает сохраненный ответ, если запрос с тем же ключом и телом уже выполнен;
+// nil означает, что запрос нужно выполнить и затем вызвать Complete или Release.
+func (s *IdempotencyService) Begin(ctx context.Context, key, requestHash string) (*models.IdempotentResponse, error) {
+	reserved, stored, err := s.storage.ReserveIdempotencyKey(ctx, callerActor(ctx), key, requestHash, time.Now().Add(s.ttl))
+	if err != nil {
+		return nil, err
+	}
+	if reserved {
+		return nil, nil
+	}
+
+	if stored.RequestHash != requestHash {
+		return nil, ErrIdempotencyMismatch
+	}
+	if stored.StatusCode == 0 {
+		return nil, ErrIdempotencyInProgress
+	}
+	return stored, nil
+}
+
+// Complete сохраняет ответ для последующих повторов
+func (s *IdempotencyService) Complete(ctx context.Context, key string, response models.IdempotentResponse) error {
+	return s.storage.SaveIdempotentResponse(ctx, callerActor(ctx), key, response)
+}
+
+// Release снимает резерв, чтобы повтор запроса выполнился заново
+func (s *IdempotencyService) Release(ctx context.Context, key string) error {
+	return s.storage.ReleaseIdempotencyKey(ctx, callerActor(ctx), key)
+}
+
+// PurgeExpired удаляет просроченные ключи
+func (s *IdempotencyService) PurgeExpired(ctx context.Context) (int64, error) {
+	return s.storage.DeleteExpiredIdempotencyKeys(ctx)
+}
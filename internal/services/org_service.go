@@ -0,0 +1,139 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strconv"
+
+	"github.com/Vimp17/pr-reviewer-service/internal/auth"
+	"github.com/Vimp17/pr-reviewer-service/internal/models"
+	"github.com/Vimp17/pr-reviewer-service/internal/storage/postgres"
+	"github.com/Vimp17/pr-reviewer-service/internal/tenant"
+)
+
+var (
+	ErrOrgExists       = errors.New("ORG_EXISTS")
+	ErrOrgNotFound     = errors.New("ORG_NOT_FOUND")
+	ErrInvalidSettings = errors.New("INVALID_SETTINGS")
+)
+
+// OrgService управляет организациями и их настройками
+type OrgService struct {
+	storage  *postgres.Storage
+	defaults models.OrgSettings
+}
+
+// NewOrgService создает новый сервис для работы с организациями;
+// defaults — настройки назначения новых организаций
+func NewOrgService(storage *postgres.Storage, defaults models.OrgSettings) *OrgService {
+	return &OrgService{storage: storage, defaults: defaults}
+}
+
+// initialAdminTokenName имя первого токена администратора новой организации
+const initialAdminTokenName = "initial-admin"
+
+// CreateOrganization создает организацию с настройками по умолчанию и в той же
+// транзакции — первый токен ее администратора; открытое значение токена
+// возвращается только один раз. Создавать организации может только оператор
+// сервиса (authorizeOperator).
+func (s *OrgService) CreateOrganization(ctx context.Context, orgID, name string) (*models.Organization, string, error) {
+	if err := authorizeOperator(ctx); err != nil {
+		return nil, "", err
+	}
+
+	org := models.Organization{
+		OrgID:    orgID,
+		Name:     name,
+		Settings: s.defaults,
+	}
+	plain, err := auth.GenerateToken()
+	if err != nil {
+		return nil, "", err
+	}
+
+	err = s.storage.InTx(ctx, func(ctx context.Context) error {
+		if err := s.storage.CreateOrganization(ctx, org); err != nil {
+			return err
+		}
+		if err := recordAudit(ctx, s.storage, AuditOrgCreate, "organization", orgID, nil, org); err != nil {
+			return err
+		}
+
+		// Токен и запись о нем — в новой организации
+		orgCtx := tenant.WithOrgID(ctx, orgID)
+		token, err := s.storage.CreateAPIToken(orgCtx, auth.HashToken(plain), models.APIToken{
+			Name: initialAdminTokenName,
+			Role: string(auth.RoleAdmin),
+		})
+		if err != nil {
+			return err
+		}
+		return recordAudit(orgCtx, s.storage, AuditTokenCreate, "api_token", strconv.FormatInt(token.TokenID, 10), nil, token)
+	})
+	if err != nil {
+		if errors.Is(err, postgres.ErrAlreadyExists) {
+			return nil, "", ErrOrgExists
+		}
+		return nil, "", err
+	}
+
+	return &org, plain, nil
+}
+
+// GetOrganization получает организацию по ID
+func (s *OrgService) GetOrganization(ctx context.Context, orgID string) (*models.Organization, error) {
+	org, err := s.storage.GetOrganization(ctx, orgID)
+	if err != nil {
+		if errors.Is(err, postgres.ErrNotFound) {
+			return nil, ErrOrgNotFound
+		}
+		return nil, err
+	}
+	return org, nil
+}
+
+// OrganizationExists проверяет, что организация существует
+func (s *OrgService) OrganizationExists(ctx context.Context, orgID string) (bool, error) {
+	_, err := s.GetOrganization(ctx, orgID)
+	if errors.Is(err, ErrOrgNotFound) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// UpdateSettings обновляет настройки назначения текущей организации
+func (s *OrgService) UpdateSettings(ctx context.Context, settings models.OrgSettings) (*models.Organization, error) {
+	if settings.ReviewersPerPR < 0 || settings.ReviewersPerPR > 2 {
+		return nil, ErrInvalidSettings
+	}
+	switch settings.AssignmentStrategy {
+	case models.AssignmentRandom, models.AssignmentLeastLoaded:
+	default:
+		return nil, ErrInvalidSettings
+	}
+
+	before, err := s.storage.GetOrgSettings(ctx)
+	if err != nil {
+		if errors.Is(err, postgres.ErrNotFound) {
+			return nil, ErrOrgNotFound
+		}
+		return nil, err
+	}
+
+	var org *models.Organization
+	err = s.storage.InTx(ctx, func(ctx context.Context) error {
+		var err error
+		if org, err = s.storage.UpdateOrgSettings(ctx, settings); err != nil {
+			return err
+		}
+		return recordAudit(ctx, s.storage, AuditOrgSettings, "organization", org.OrgID, before, org.Settings)
+	})
+	if err != nil {
+		if errors.Is(err, postgres.ErrNotFound) {
+			return nil, ErrOrgNotFound
+		}
+		return nil, err
+	}
+
+	return org, nil
+}
@@ -0,0 +1,53 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Vimp17/pr-reviewer-service/internal/auth"
+	"github.com/Vimp17/pr-reviewer-service/internal/models"
+	"github.com/Vimp17/pr-reviewer-service/internal/testdb"
+)
+
+// Новая организация создается вместе с токеном ее администратора; администратор
+// другой организации создать организацию не может
+func TestCreateOrganization(t *testing.T) {
+	storage := testdb.Open(t)
+	orgs := NewOrgService(storage, models.OrgSettings{ReviewersPerPR: 2, AssignmentStrategy: models.AssignmentRandom})
+	tokens := NewAuthService(storage)
+	orgID := testdb.NewOrg(t, storage, "created") + "-new"
+
+	foreign := testdb.IdentityContext(&auth.Identity{OrgID: testdb.NewOrg(t, storage, "foreign"), Name: "admin", Roles: []auth.Role{auth.RoleAdmin}})
+	if _, _, err := orgs.CreateOrganization(foreign, orgID, "New"); !errors.Is(err, ErrForbidden) {
+		t.Fatalf("foreign admin: error %v, want %v", err, ErrForbidden)
+	}
+
+	operator := testdb.IdentityContext(&auth.Identity{OrgID: "default", Name: "ops", Roles: []auth.Role{auth.RoleAdmin}})
+	org, plain, err := orgs.CreateOrganization(operator, orgID, "New")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if org.OrgID != orgID || plain == "" {
+		t.Fatalf("created %+v, token %q", org, plain)
+	}
+
+	identity, err := tokens.Authenticate(operator, plain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if identity.OrgID != orgID || !identity.HasRole(auth.RoleAdmin) {
+		t.Errorf("initial token identity %+v", identity)
+	}
+
+	entries, _, err := NewAuditService(storage).ListEntries(testdb.AdminContext(orgID), models.AuditFilter{Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Action != AuditTokenCreate || entries[0].Actor != "ops" {
+		t.Errorf("audit of new organization: %+v", entries)
+	}
+
+	if _, _, err := orgs.CreateOrganization(operator, orgID, "Again"); !errors.Is(err, ErrOrgExists) {
+		t.Errorf("duplicate: error %v, want %v", err, ErrOrgExists)
+	}
+}
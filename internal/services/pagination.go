@@ -0,0 +1,75 @@
+package services
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"github.com/Vimp17/pr-reviewer-service/internal/models"
+)
+
+var (
+	ErrInvalidCursor = errors.New("INVALID_CURSOR")
+	ErrInvalidSort   = errors.New("INVALID_SORT")
+)
+
+const (
+	defaultListPageSize = 50
+	maxListPageSize     = 200
+)
+
+// listLimit приводит размер страницы к допустимому диапазону
+func listLimit(limit int) int {
+	if limit <= 0 {
+		return defaultListPageSize
+	}
+	if limit > maxListPageSize {
+		return maxListPageSize
+	}
+	return limit
+}
+
+// parseSort разбирает параметр сортировки вида "field" или "-field" (по убыванию)
+func parseSort(sort, fallback string, allowed ...string) (field string, desc bool, err error) {
+	if sort == "" {
+		sort = fallback
+	}
+	field = strings.TrimPrefix(sort, "-")
+	for _, a := range allowed {
+		if field == a {
+			return field, strings.HasPrefix(sort, "-"), nil
+		}
+	}
+	return "", false, ErrInvalidSort
+}
+
+// encodeCursor упаковывает позицию в непрозрачную строку
+func encodeCursor(cursor models.ListCursor) string {
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeCursor распаковывает курсор и проверяет, что он выдан для той же сортировки
+func decodeCursor(s, sort string) (*models.ListCursor, error) {
+	if s == "" {
+		return nil, nil
+	}
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var cursor models.ListCursor
+	if err := json.Unmarshal(data, &cursor); err != nil || cursor.Sort != sort || cursor.ID == "" {
+		return nil, ErrInvalidCursor
+	}
+	return &cursor, nil
+}
+
+// sortKey каноническое обозначение сортировки, сохраняемое в курсоре
+func sortKey(field string, desc bool) string {
+	if desc {
+		return "-" + field
+	}
+	return field
+}
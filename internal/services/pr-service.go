@@ -4,10 +4,14 @@ import (
 	"context"
 	"errors"
 	"math/rand"
+	"sort"
 	"time"
 
+	"github.com/Vimp17/pr-reviewer-service/internal/auth"
+	"github.com/Vimp17/pr-reviewer-service/internal/metrics"
 	"github.com/Vimp17/pr-reviewer-service/internal/models"
 	"github.com/Vimp17/pr-reviewer-service/internal/storage/postgres"
+	"github.com/Vimp17/pr-reviewer-service/internal/tracing"
 )
 
 var (
@@ -17,8 +21,18 @@ var (
 	ErrNotAssigned    = errors.New("NOT_ASSIGNED")
 	ErrNoCandidate    = errors.New("NO_CANDIDATE")
 	ErrNotFound       = errors.New("NOT_FOUND")
+	ErrInvalidVerdict = errors.New("INVALID_VERDICT")
+	ErrConflict       = errors.New("CONFLICT")
 )
 
+// maxReassignAttempts ограничивает число попыток переназначения и записи
+// вердикта при конкурентных изменениях PR
+const maxReassignAttempts = 3
+
+// reassignBackoff базовая пауза перед повтором переназначения; удваивается с
+// каждой попыткой, случайная составляющая разводит конкурирующие запросы
+const reassignBackoff = 10 * time.Millisecond
+
 // PRService управляет бизнес-логикой для Pull Requests
 type PRService struct {
 	storage *postgres.Storage
@@ -31,6 +45,18 @@ func NewPRService(storage *postgres.Storage) *PRService {
 
 // CreatePR создает новый PR и назначает ревьюеров
 func (s *PRService) CreatePR(ctx context.Context, pr models.PullRequest) (*models.PullRequest, error) {
+	ctx, span := tracing.Start(ctx, "PRService.CreatePR")
+	defer span.End()
+
+	// Участник может создавать PR только от своего имени
+	identity, err := callerIdentity(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !identity.HasRole(auth.RoleAdmin, auth.RoleTeamLead, auth.RoleBot) && identity.UserID != pr.AuthorID {
+		return nil, ErrForbidden
+	}
+
 	// Проверяем существование PR
 	exists, err := s.storage.CheckPRExists(ctx, pr.PullRequestID)
 	if err != nil {
@@ -55,24 +81,82 @@ func (s *PRService) CreatePR(ctx context.Context, pr models.PullRequest) (*model
 		return nil, err
 	}
 
-	// Назначаем до 2 ревьюеров
-	reviewers := selectReviewers(candidates)
+	// Назначаем ревьюеров согласно настройкам организации
+	settings, err := s.storage.GetOrgSettings(ctx)
+	if err != nil {
+		return nil, err
+	}
+	reviewers, err := s.selectReviewers(ctx, candidates, settings.ReviewersPerPR, settings.AssignmentStrategy)
+	if err != nil {
+		return nil, err
+	}
 	pr.AssignedReviewers = reviewers
 	pr.Status = "OPEN"
+	pr.Version = 1
 
-	// Сохраняем в БД
-	if err := s.storage.CreatePR(ctx, pr); err != nil {
+	// Сохраняем в БД вместе с начальными событиями истории
+	actor := callerActor(ctx)
+	events := []models.PREvent{{
+		PullRequestID: pr.PullRequestID,
+		EventType:     models.PREventCreated,
+		Actor:         actor,
+	}}
+	for _, reviewerID := range reviewers {
+		events = append(events, models.PREvent{
+			PullRequestID: pr.PullRequestID,
+			EventType:     models.PREventAssigned,
+			ReviewerID:    reviewerID,
+			Actor:         actor,
+		})
+	}
+	err = s.storage.InTx(ctx, func(ctx context.Context) error {
+		if err := s.storage.CreatePR(ctx, pr, events...); err != nil {
+			return err
+		}
+		return recordAudit(ctx, s.storage, AuditPRCreate, "pull_request", pr.PullRequestID, nil, pr)
+	})
+	if err != nil {
+		if errors.Is(err, postgres.ErrAlreadyExists) {
+			return nil, ErrPRExists
+		}
 		return nil, err
 	}
+	metrics.PRCreated(ctx)
 
 	return &pr, nil
 }
 
+// GetPR получает PR по ID
+func (s *PRService) GetPR(ctx context.Context, prID string) (*models.PullRequest, error) {
+	ctx, span := tracing.Start(ctx, "PRService.GetPR")
+	defer span.End()
+
+	pr, err := s.storage.GetPR(ctx, prID)
+	if err != nil {
+		if errors.Is(err, postgres.ErrNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return pr, nil
+}
+
 // MergePR помечает PR как MERGED
 func (s *PRService) MergePR(ctx context.Context, prID string) (*models.PullRequest, error) {
+	ctx, span := tracing.Start(ctx, "PRService.MergePR")
+	defer span.End()
+
 	// Получаем текущий статус PR
 	pr, err := s.storage.GetPR(ctx, prID)
 	if err != nil {
+		if errors.Is(err, postgres.ErrNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+
+	// Сливать PR могут автор, лид команды, администратор и боты
+	if err := authorizePR(ctx, s.storage, pr, auth.RoleBot); err != nil {
 		return nil, err
 	}
 
@@ -81,23 +165,81 @@ func (s *PRService) MergePR(ctx context.Context, prID string) (*models.PullReque
 		return pr, nil
 	}
 
-	// Обновляем статус
-	mergedPR, err := s.storage.MergePR(ctx, prID)
+	// Обновляем статус вместе с записью аудита
+	var mergedPR *models.PullRequest
+	err = s.storage.InTx(ctx, func(ctx context.Context) error {
+		var err error
+		mergedPR, err = s.storage.MergePR(ctx, prID, models.PREvent{
+			PullRequestID: prID,
+			EventType:     models.PREventMerged,
+			Actor:         callerActor(ctx),
+		})
+		if err != nil {
+			return err
+		}
+		return recordAudit(ctx, s.storage, AuditPRMerge, "pull_request", prID, pr, mergedPR)
+	})
+	if errors.Is(err, postgres.ErrConflict) {
+		// PR слили параллельно: слияние идемпотентно, возвращаем текущее состояние
+		return s.storage.GetPR(ctx, prID)
+	}
 	if err != nil {
 		return nil, err
 	}
+	metrics.PRMerged(ctx)
 
 	return mergedPR, nil
 }
 
-// ReassignReviewer заменяет одного ревьюера на другого из его команды
+// ReassignReviewer заменяет одного ревьюера на другого из его команды.
+// При конкурентном изменении PR операция повторяется с перечитыванием состояния.
 func (s *PRService) ReassignReviewer(
 	ctx context.Context,
-	prID, oldUserID string,
+	prID, oldUserID, reason string,
+) (*models.PullRequest, string, error) {
+	ctx, span := tracing.Start(ctx, "PRService.ReassignReviewer")
+	defer span.End()
+
+	for attempt := 1; ; attempt++ {
+		pr, newReviewer, err := s.reassignOnce(ctx, prID, oldUserID, reason)
+		if errors.Is(err, postgres.ErrConflict) {
+			if attempt < maxReassignAttempts {
+				select {
+				case <-ctx.Done():
+					return nil, "", ctx.Err()
+				case <-time.After(retryDelay(attempt)):
+				}
+				continue
+			}
+			return nil, "", ErrConflict
+		}
+		return pr, newReviewer, err
+	}
+}
+
+// retryDelay возвращает паузу перед повтором после attempt-й попытки:
+// случайную в пределах [base/2, base*3/2), где base = reassignBackoff*2^(attempt-1)
+func retryDelay(attempt int) time.Duration {
+	base := reassignBackoff << (attempt - 1)
+	return base/2 + time.Duration(rand.Int63n(int64(base)))
+}
+
+// reassignOnce выполняет одну попытку переназначения по прочитанной версии PR
+func (s *PRService) reassignOnce(
+	ctx context.Context,
+	prID, oldUserID, reason string,
 ) (*models.PullRequest, string, error) {
 	// Получаем PR
 	pr, err := s.storage.GetPR(ctx, prID)
 	if err != nil {
+		if errors.Is(err, postgres.ErrNotFound) {
+			return nil, "", ErrNotFound
+		}
+		return nil, "", err
+	}
+
+	// Переназначать могут только автор, лид команды и администратор
+	if err := authorizePR(ctx, s.storage, pr); err != nil {
 		return nil, "", err
 	}
 
@@ -117,39 +259,324 @@ func (s *PRService) ReassignReviewer(
 		return nil, "", err
 	}
 
-	// Ищем замену в его команде
-	candidates, err := s.storage.GetActiveTeamMembers(ctx, reviewer.TeamName, oldUserID)
+	// Ищем замену в его команде среди тех, кто еще не участвует в PR
+	members, err := s.storage.GetActiveTeamMembers(ctx, reviewer.TeamName, oldUserID)
 	if err != nil {
 		return nil, "", err
 	}
+	candidates := make([]string, 0, len(members))
+	for _, id := range members {
+		if id != pr.AuthorID && !contains(pr.AssignedReviewers, id) {
+			candidates = append(candidates, id)
+		}
+	}
 
 	if len(candidates) == 0 {
+		metrics.NoCandidate(ctx, reviewer.TeamName)
 		return nil, "", ErrNoCandidate
 	}
 
-	// Случайный выбор
-	rand.Seed(time.Now().UnixNano())
-	newReviewer := candidates[rand.Intn(len(candidates))]
+	// Выбираем замену согласно стратегии организации
+	settings, err := s.storage.GetOrgSettings(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+	selected, err := s.selectReviewers(ctx, candidates, 1, settings.AssignmentStrategy)
+	if err != nil {
+		return nil, "", err
+	}
+	newReviewer := selected[0]
 
-	// Обновляем назначения
+	// Обновляем назначения, если PR не изменился с момента чтения
+	before := *pr
+	before.AssignedReviewers = append([]string(nil), pr.AssignedReviewers...)
 	pr.AssignedReviewers = replaceReviewer(pr.AssignedReviewers, oldUserID, newReviewer)
-	if err := s.storage.UpdatePRReviewers(ctx, prID, pr.AssignedReviewers); err != nil {
+	event := models.PREvent{
+		PullRequestID: prID,
+		EventType:     models.PREventReassigned,
+		ReviewerID:    newReviewer,
+		OldReviewerID: oldUserID,
+		Actor:         callerActor(ctx),
+		Reason:        reason,
+	}
+	err = s.storage.InTx(ctx, func(ctx context.Context) error {
+		if err := s.storage.UpdatePRReviewers(ctx, prID, pr.Version, pr.AssignedReviewers, event); err != nil {
+			return err
+		}
+		after := *pr
+		after.Version++
+		return recordAudit(ctx, s.storage, AuditPRReassign, "pull_request", prID, before, after)
+	})
+	if err != nil {
 		return nil, "", err
 	}
+	pr.Version++
+	metrics.ReviewerReassigned(ctx)
 
 	return pr, newReviewer, nil
 }
 
-// GetAssignmentStats возвращает статистику по назначениям
-func (s *PRService) GetAssignmentStats(ctx context.Context) (map[string]int, error) {
-	return s.storage.GetAssignmentStats(ctx)
+// SubmitVerdict записывает вердикт назначенного ревьюера. Вердикт пишется
+// вместе с записью аудита по прочитанной версии PR; если PR тем временем
+// изменился, проверки повторяются, и слитый PR или снятый ревьюер дают
+// ErrPRMerged или ErrNotAssigned, а исчерпанные попытки — ErrConflict.
+func (s *PRService) SubmitVerdict(ctx context.Context, prID, reviewerID, verdict, comment string) error {
+	ctx, span := tracing.Start(ctx, "PRService.SubmitVerdict")
+	defer span.End()
+
+	if verdict != models.VerdictApproved && verdict != models.VerdictChangesRequested {
+		return ErrInvalidVerdict
+	}
+
+	// Вердикт выносит сам ревьюер; боты и администраторы могут передать его за ревьюера
+	identity, err := callerIdentity(ctx)
+	if err != nil {
+		return err
+	}
+	if !identity.HasRole(auth.RoleAdmin, auth.RoleBot) && identity.UserID != reviewerID {
+		return ErrForbidden
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := s.submitVerdictOnce(ctx, prID, reviewerID, verdict, comment)
+		if errors.Is(err, postgres.ErrConflict) {
+			if attempt < maxReassignAttempts {
+				select {
+				case <-ctx.Done():
+					return ctx.Err()
+				case <-time.After(retryDelay(attempt)):
+				}
+				continue
+			}
+			return ErrConflict
+		}
+		return err
+	}
+}
+
+// submitVerdictOnce выполняет одну попытку записи вердикта по прочитанной версии PR
+func (s *PRService) submitVerdictOnce(ctx context.Context, prID, reviewerID, verdict, comment string) error {
+	pr, err := s.storage.GetPR(ctx, prID)
+	if err != nil {
+		if errors.Is(err, postgres.ErrNotFound) {
+			return ErrNotFound
+		}
+		return err
+	}
+	if pr.Status == "MERGED" {
+		return ErrPRMerged
+	}
+	if !contains(pr.AssignedReviewers, reviewerID) {
+		return ErrNotAssigned
+	}
+
+	event := models.PREvent{
+		PullRequestID: prID,
+		EventType:     models.PREventVerdict,
+		ReviewerID:    reviewerID,
+		Verdict:       verdict,
+		Actor:         callerActor(ctx),
+		Reason:        comment,
+	}
+	return s.storage.InTx(ctx, func(ctx context.Context) error {
+		if err := s.storage.AddVerdict(ctx, prID, pr.Version, event); err != nil {
+			return err
+		}
+		return recordAudit(ctx, s.storage, AuditPRVerdict, "pull_request", prID, nil, event)
+	})
+}
+
+// GetPRHistory возвращает хронологию назначений, вердиктов и слияния PR
+func (s *PRService) GetPRHistory(ctx context.Context, prID string) ([]models.PREvent, error) {
+	ctx, span := tracing.Start(ctx, "PRService.GetPRHistory")
+	defer span.End()
+
+	exists, err := s.storage.CheckPRExists(ctx, prID)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrNotFound
+	}
+	return s.storage.GetPREvents(ctx, prID)
+}
+
+// GetStats возвращает статистику назначений и слияний по PR команды и периода
+// из фильтра, среднее число переназначений на PR и коэффициент Джини нагрузки
+func (s *PRService) GetStats(ctx context.Context, filter models.StatsFilter) (*models.Stats, error) {
+	ctx, span := tracing.Start(ctx, "PRService.GetStats")
+	defer span.End()
+
+	if err := s.checkStatsTeam(ctx, filter); err != nil {
+		return nil, err
+	}
+
+	stats, err := s.storage.GetStats(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	if stats.PullRequests > 0 {
+		stats.AvgReassignmentsPerPR = float64(stats.Reassignments) / float64(stats.PullRequests)
+	}
+	loads := make([]int, len(stats.Reviewers))
+	for i, r := range stats.Reviewers {
+		loads[i] = r.Total
+	}
+	stats.LoadGini = gini(loads)
+	return stats, nil
+}
+
+// StreamReviewerStats передает fn нагрузку ревьюеров из GetStats по одной строке
+func (s *PRService) StreamReviewerStats(ctx context.Context, filter models.StatsFilter, fn func(models.ReviewerStats) error) error {
+	ctx, span := tracing.Start(ctx, "PRService.StreamReviewerStats")
+	defer span.End()
+
+	if err := s.checkStatsTeam(ctx, filter); err != nil {
+		return err
+	}
+	return s.storage.StreamReviewerStats(ctx, filter, fn)
+}
+
+// StreamTeamStats передает fn итоги по командам из GetStats по одной строке
+func (s *PRService) StreamTeamStats(ctx context.Context, filter models.StatsFilter, fn func(models.TeamStats) error) error {
+	ctx, span := tracing.Start(ctx, "PRService.StreamTeamStats")
+	defer span.End()
+
+	if err := s.checkStatsTeam(ctx, filter); err != nil {
+		return err
+	}
+	return s.storage.StreamTeamStats(ctx, filter, fn)
+}
+
+// GetLatencyStats возвращает перцентили времени до первого ревью и до слияния
+// в целом, по командам, ревьюерам и неделям для PR команды и периода из фильтра
+func (s *PRService) GetLatencyStats(ctx context.Context, filter models.StatsFilter) (*models.LatencyStats, error) {
+	ctx, span := tracing.Start(ctx, "PRService.GetLatencyStats")
+	defer span.End()
+
+	if err := s.checkStatsTeam(ctx, filter); err != nil {
+		return nil, err
+	}
+	return s.storage.GetLatencyStats(ctx, filter)
+}
+
+// checkStatsTeam проверяет, что команда из фильтра статистики существует
+func (s *PRService) checkStatsTeam(ctx context.Context, filter models.StatsFilter) error {
+	if filter.TeamName == "" {
+		return nil
+	}
+	exists, err := s.storage.CheckTeamExists(ctx, filter.TeamName)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// gini вычисляет коэффициент Джини: 0 при равной нагрузке (или ее отсутствии),
+// (n-1)/n, если вся нагрузка у одного из n
+func gini(values []int) float64 {
+	sorted := append([]int(nil), values...)
+	sort.Ints(sorted)
+
+	var sum, weighted float64
+	for i, v := range sorted {
+		sum += float64(v)
+		weighted += float64(i+1) * float64(v)
+	}
+	if sum == 0 {
+		return 0
+	}
+	n := float64(len(sorted))
+	return 2*weighted/(n*sum) - (n+1)/n
+}
+
+// GetOpenPRsForReviewers возвращает открытые PR, где ревьюером назначен
+// любой из указанных пользователей
+func (s *PRService) GetOpenPRsForReviewers(ctx context.Context, reviewerIDs []string) ([]models.PullRequest, error) {
+	ctx, span := tracing.Start(ctx, "PRService.GetOpenPRsForReviewers")
+	defer span.End()
+
+	return s.storage.GetOpenPRsByReviewers(ctx, reviewerIDs)
+}
+
+// ListPRs возвращает страницу PR по фильтру и курсор следующей страницы
+// (пустой, если страница последняя). Сортировка по умолчанию — новые первыми.
+func (s *PRService) ListPRs(ctx context.Context, filter models.PRListFilter, sortBy, cursor string) ([]models.PullRequest, string, error) {
+	ctx, span := tracing.Start(ctx, "PRService.ListPRs")
+	defer span.End()
+
+	if err := preparePRList(&filter, sortBy, cursor); err != nil {
+		return nil, "", err
+	}
+	field, key := filter.Sort, sortKey(filter.Sort, filter.Desc)
+
+	limit := listLimit(filter.Limit)
+	filter.Limit = limit + 1
+	prs, err := s.storage.ListPRs(ctx, filter)
+	if err != nil {
+		return nil, "", err
+	}
+	if len(prs) <= limit {
+		return prs, "", nil
+	}
+
+	prs = prs[:limit]
+	last := prs[limit-1]
+	value := last.PullRequestID
+	switch field {
+	case "created_at":
+		value = last.CreatedAt.Format(time.RFC3339Nano)
+	case "pull_request_name":
+		value = last.PullRequestName
+	}
+	return prs, encodeCursor(models.ListCursor{Sort: key, Value: value, ID: last.PullRequestID}), nil
+}
+
+// StreamPRs передает fn все PR по фильтру в порядке сортировки, начиная с
+// курсора, без разбиения на страницы; filter.Limit > 0 ограничивает их число.
+// Ошибки сортировки и курсора возвращаются до первого вызова fn.
+func (s *PRService) StreamPRs(ctx context.Context, filter models.PRListFilter, sortBy, cursor string, fn func(models.PullRequest) error) error {
+	ctx, span := tracing.Start(ctx, "PRService.StreamPRs")
+	defer span.End()
+
+	if err := preparePRList(&filter, sortBy, cursor); err != nil {
+		return err
+	}
+	if filter.Limit < 0 {
+		filter.Limit = 0
+	}
+	return s.storage.StreamPRs(ctx, filter, fn)
+}
+
+// preparePRList разбирает сортировку и курсор списка PR в фильтр
+func preparePRList(filter *models.PRListFilter, sortBy, cursor string) error {
+	field, desc, err := parseSort(sortBy, "-created_at", "created_at", "pull_request_name", "pull_request_id")
+	if err != nil {
+		return err
+	}
+	if filter.After, err = decodeCursor(cursor, sortKey(field, desc)); err != nil {
+		return err
+	}
+	if filter.After != nil && field == "created_at" {
+		if _, err := time.Parse(time.RFC3339Nano, filter.After.Value); err != nil {
+			return ErrInvalidCursor
+		}
+	}
+	filter.Sort, filter.Desc = field, desc
+	return nil
 }
 
 // Вспомогательные функции
 
-func selectReviewers(candidates []string) []string {
-	if len(candidates) == 0 {
-		return []string{}
+// selectReviewers выбирает до n ревьюеров из кандидатов.
+// При стратегии least_loaded предпочтение отдается кандидатам с наименьшим числом открытых ревью.
+func (s *PRService) selectReviewers(ctx context.Context, candidates []string, n int, strategy string) ([]string, error) {
+	if len(candidates) == 0 || n <= 0 {
+		return []string{}, nil
 	}
 
 	rand.Seed(time.Now().UnixNano())
@@ -157,10 +584,21 @@ func selectReviewers(candidates []string) []string {
 		candidates[i], candidates[j] = candidates[j], candidates[i]
 	})
 
-	if len(candidates) >= 2 {
-		return candidates[:2]
+	if strategy == models.AssignmentLeastLoaded {
+		load, err := s.storage.GetOpenReviewCounts(ctx, candidates)
+		if err != nil {
+			return nil, err
+		}
+		// Стабильная сортировка сохраняет случайный порядок среди равных по нагрузке
+		sort.SliceStable(candidates, func(i, j int) bool {
+			return load[candidates[i]] < load[candidates[j]]
+		})
+	}
+
+	if len(candidates) >= n {
+		return candidates[:n], nil
 	}
-	return candidates
+	return candidates, nil
 }
 
 func contains(s []string, e string) bool {
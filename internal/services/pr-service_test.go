@@ -0,0 +1,311 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Vimp17/pr-reviewer-service/internal/models"
+	"github.com/Vimp17/pr-reviewer-service/internal/testdb"
+)
+
+func TestRetryDelay(t *testing.T) {
+	for attempt := 1; attempt < maxReassignAttempts; attempt++ {
+		base := reassignBackoff << (attempt - 1)
+		for i := 0; i < 100; i++ {
+			if d := retryDelay(attempt); d < base/2 || d >= base*3/2 {
+				t.Fatalf("retryDelay(%d) = %v, want [%v, %v)", attempt, d, base/2, base*3/2)
+			}
+		}
+	}
+}
+
+// Параллельные переназначения и слияние одного PR: каждая успешная операция
+// повышает версию ровно на единицу, а ревьюеры соответствуют истории событий
+func TestConcurrentReassignAndMerge(t *testing.T) {
+	storage := testdb.Open(t)
+	orgID := testdb.NewOrg(t, storage, "race")
+	ctx := testdb.AdminContext(orgID)
+
+	prs := NewPRService(storage)
+	members := make([]models.User, 8)
+	team := make(map[string]bool, len(members))
+	for i := range members {
+		id := fmt.Sprintf("u%d", i+1)
+		members[i] = models.User{UserID: id, Username: "User " + id, IsActive: true}
+		team[id] = true
+	}
+	if _, err := NewTeamService(storage).CreateTeam(ctx, models.Team{TeamName: "backend", Members: members}); err != nil {
+		t.Fatal(err)
+	}
+	created, err := prs.CreatePR(ctx, models.PullRequest{PullRequestID: "pr-1", PullRequestName: "Race", AuthorID: "u1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(created.AssignedReviewers) != 2 {
+		t.Fatalf("initial reviewers = %v", created.AssignedReviewers)
+	}
+
+	const reassigners = 12
+	var (
+		wg        sync.WaitGroup
+		reassigns atomic.Int32
+		merges    atomic.Int32
+		start     = make(chan struct{})
+		errs      = make(chan error, reassigners+1)
+	)
+	for i := 0; i < reassigners; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			<-start
+			// Заменяем одного из исходных ревьюеров: часть запросов столкнется
+			// с изменившейся версией, часть — с уже замененным ревьюером
+			old := created.AssignedReviewers[i%2]
+			_, _, err := prs.ReassignReviewer(ctx, "pr-1", old, "race")
+			switch {
+			case err == nil:
+				reassigns.Add(1)
+			case errors.Is(err, ErrConflict), errors.Is(err, ErrNotAssigned),
+				errors.Is(err, ErrPRMerged), errors.Is(err, ErrNoCandidate):
+			default:
+				errs <- fmt.Errorf("reassign %s: %w", old, err)
+			}
+		}(i)
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		<-start
+		time.Sleep(5 * time.Millisecond)
+		merged, err := prs.MergePR(ctx, "pr-1")
+		if err != nil {
+			errs <- fmt.Errorf("merge: %w", err)
+			return
+		}
+		if merged.Status == "MERGED" {
+			merges.Add(1)
+		}
+	}()
+	close(start)
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+
+	pr, err := prs.GetPR(ctx, "pr-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pr.Status != "MERGED" || merges.Load() != 1 {
+		t.Fatalf("status %s after %d merges, want MERGED after 1", pr.Status, merges.Load())
+	}
+	if want := 1 + int(reassigns.Load()) + 1; pr.Version != want {
+		t.Errorf("version = %d, want %d (created, %d reassigns, merge)", pr.Version, want, reassigns.Load())
+	}
+
+	// История в порядке записи воспроизводит итоговых ревьюеров
+	events, err := prs.GetPRHistory(ctx, "pr-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Slice(events, func(i, j int) bool { return events[i].ID < events[j].ID })
+	var replayed []string
+	reassigned := 0
+	for _, e := range events {
+		switch e.EventType {
+		case models.PREventAssigned:
+			replayed = append(replayed, e.ReviewerID)
+		case models.PREventReassigned:
+			reassigned++
+			if !contains(replayed, e.OldReviewerID) {
+				t.Fatalf("reassign of %s who is not a reviewer (%v)", e.OldReviewerID, replayed)
+			}
+			replayed = replaceReviewer(replayed, e.OldReviewerID, e.ReviewerID)
+		}
+	}
+	if reassigned != int(reassigns.Load()) {
+		t.Errorf("REASSIGNED events = %d, successful reassigns = %d", reassigned, reassigns.Load())
+	}
+	if fmt.Sprint(replayed) != fmt.Sprint(pr.AssignedReviewers) {
+		t.Errorf("reviewers %v, history gives %v", pr.AssignedReviewers, replayed)
+	}
+	if len(pr.AssignedReviewers) != 2 || pr.AssignedReviewers[0] == pr.AssignedReviewers[1] {
+		t.Errorf("reviewers %v, want two distinct", pr.AssignedReviewers)
+	}
+	for _, id := range pr.AssignedReviewers {
+		if id == pr.AuthorID || !team[id] {
+			t.Errorf("reviewer %s is the author or outside the team", id)
+		}
+	}
+}
+
+// Параллельное создание PR с одним ID: проверка существования не защищает от
+// гонки, поэтому проигравшие получают ErrPRExists из ограничения уникальности,
+// а в истории и журнале аудита остается одно создание
+func TestConcurrentCreatePR(t *testing.T) {
+	storage := testdb.Open(t)
+	orgID := testdb.NewOrg(t, storage, "race")
+	ctx := testdb.AdminContext(orgID)
+
+	prs := NewPRService(storage)
+	members := []models.User{
+		{UserID: "u1", Username: "User u1", IsActive: true},
+		{UserID: "u2", Username: "User u2", IsActive: true},
+		{UserID: "u3", Username: "User u3", IsActive: true},
+	}
+	if _, err := NewTeamService(storage).CreateTeam(ctx, models.Team{TeamName: "backend", Members: members}); err != nil {
+		t.Fatal(err)
+	}
+
+	const creators = 10
+	var (
+		wg      sync.WaitGroup
+		created atomic.Int32
+		start   = make(chan struct{})
+		errs    = make(chan error, creators)
+	)
+	for i := 0; i < creators; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			_, err := prs.CreatePR(ctx, models.PullRequest{PullRequestID: "pr-1", PullRequestName: "Race", AuthorID: "u1"})
+			switch {
+			case err == nil:
+				created.Add(1)
+			case errors.Is(err, ErrPRExists):
+			default:
+				errs <- err
+			}
+		}()
+	}
+	close(start)
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Errorf("create: %v", err)
+	}
+	if created.Load() != 1 {
+		t.Fatalf("%d successful creates, want 1", created.Load())
+	}
+
+	events, err := prs.GetPRHistory(ctx, "pr-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	createdEvents := 0
+	for _, e := range events {
+		if e.EventType == models.PREventCreated {
+			createdEvents++
+		}
+	}
+	if createdEvents != 1 {
+		t.Errorf("CREATED events = %d, want 1", createdEvents)
+	}
+	_, total, err := NewAuditService(storage).ListEntries(ctx, models.AuditFilter{Action: AuditPRCreate, TargetID: "pr-1", Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 1 {
+		t.Errorf("%s audit entries = %d, want 1", AuditPRCreate, total)
+	}
+}
+
+// Вердикты, конкурирующие со слиянием, либо записываются до события MERGED,
+// либо получают ErrPRMerged; каждый записанный вердикт попадает в журнал аудита
+func TestConcurrentVerdictAndMerge(t *testing.T) {
+	storage := testdb.Open(t)
+	orgID := testdb.NewOrg(t, storage, "verdict")
+	ctx := testdb.AdminContext(orgID)
+
+	prs := NewPRService(storage)
+	members := []models.User{
+		{UserID: "u1", Username: "User u1", IsActive: true},
+		{UserID: "u2", Username: "User u2", IsActive: true},
+		{UserID: "u3", Username: "User u3", IsActive: true},
+	}
+	if _, err := NewTeamService(storage).CreateTeam(ctx, models.Team{TeamName: "backend", Members: members}); err != nil {
+		t.Fatal(err)
+	}
+	created, err := prs.CreatePR(ctx, models.PullRequest{PullRequestID: "pr-1", PullRequestName: "Race", AuthorID: "u1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(created.AssignedReviewers) != 2 {
+		t.Fatalf("initial reviewers = %v", created.AssignedReviewers)
+	}
+
+	const voters = 10
+	var (
+		wg       sync.WaitGroup
+		verdicts atomic.Int32
+		start    = make(chan struct{})
+		errs     = make(chan error, voters+1)
+	)
+	for i := 0; i < voters; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			<-start
+			reviewer := created.AssignedReviewers[i%2]
+			err := prs.SubmitVerdict(ctx, "pr-1", reviewer, models.VerdictApproved, "")
+			switch {
+			case err == nil:
+				verdicts.Add(1)
+			case errors.Is(err, ErrPRMerged), errors.Is(err, ErrConflict):
+			default:
+				errs <- fmt.Errorf("verdict %s: %w", reviewer, err)
+			}
+		}(i)
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		<-start
+		time.Sleep(2 * time.Millisecond)
+		if _, err := prs.MergePR(ctx, "pr-1"); err != nil {
+			errs <- fmt.Errorf("merge: %w", err)
+		}
+	}()
+	close(start)
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+
+	events, err := prs.GetPRHistory(ctx, "pr-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Slice(events, func(i, j int) bool { return events[i].ID < events[j].ID })
+	written, merged := 0, false
+	for _, e := range events {
+		switch e.EventType {
+		case models.PREventVerdict:
+			written++
+			if merged {
+				t.Errorf("verdict %d of %s recorded after merge", e.ID, e.ReviewerID)
+			}
+		case models.PREventMerged:
+			merged = true
+		}
+	}
+	if !merged || written != int(verdicts.Load()) {
+		t.Errorf("merged %v, VERDICT events = %d, successful verdicts = %d", merged, written, verdicts.Load())
+	}
+
+	entries, _, err := NewAuditService(storage).ListEntries(ctx, models.AuditFilter{Action: AuditPRVerdict, Limit: voters + 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != written {
+		t.Errorf("%d %s audit entries, want %d", len(entries), AuditPRVerdict, written)
+	}
+}
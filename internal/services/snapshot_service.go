@@ -0,0 +1,423 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/Vimp17/pr-reviewer-service/internal/models"
+	"github.com/Vimp17/pr-reviewer-service/internal/storage/postgres"
+	"github.com/Vimp17/pr-reviewer-service/internal/tenant"
+	"github.com/Vimp17/pr-reviewer-service/internal/tracing"
+)
+
+// Коды ошибок проверки записей импорта
+const (
+	ImportFieldRequired    = "FIELD_REQUIRED"
+	ImportDuplicate        = "DUPLICATE"
+	ImportTeamNotFound     = "TEAM_NOT_FOUND"
+	ImportAuthorNotFound   = "AUTHOR_NOT_FOUND"
+	ImportReviewerNotFound = "REVIEWER_NOT_FOUND"
+	ImportInvalidReviewers = "INVALID_REVIEWERS"
+	ImportInvalidStatus    = "INVALID_STATUS"
+	ImportInvalidMergedAt  = "INVALID_MERGED_AT"
+	ImportInvalidSettings  = "INVALID_SETTINGS"
+	ImportPRNotFound       = "PR_NOT_FOUND"
+	ImportInvalidEvent     = "INVALID_EVENT"
+)
+
+// importReason причина в событиях истории, восстановленных при импорте
+const importReason = "import"
+
+// SnapshotService выгружает и загружает данные организации целиком
+type SnapshotService struct {
+	storage *postgres.Storage
+}
+
+// NewSnapshotService создает новый сервис выгрузки и загрузки данных
+func NewSnapshotService(storage *postgres.Storage) *SnapshotService {
+	return &SnapshotService{storage: storage}
+}
+
+// Export возвращает согласованную выгрузку настроек, команд, пользователей,
+// PR и истории PR организации
+func (s *SnapshotService) Export(ctx context.Context) (*models.Snapshot, error) {
+	ctx, span := tracing.Start(ctx, "SnapshotService.Export")
+	defer span.End()
+
+	return s.storage.GetSnapshot(ctx)
+}
+
+// Import проверяет записи выгрузки и, если ошибок нет и это не пробный запуск,
+// применяет их одной транзакцией: настройки перезаписываются, существующие
+// пользователи и PR обновляются, отсутствующие создаются, события истории
+// добавляются, кроме уже сохраненных. Ссылки на команды, пользователей и PR
+// разрешаются как среди уже сохраненных данных, так и среди записей самой
+// выгрузки, поэтому порядок записей не важен. Для PR без событий в выгрузке
+// история восстанавливается по разнице с сохраненным состоянием; ревьюеры не
+// переназначаются. Проверка идет в той же транзакции, что и запись, по
+// заблокированным данным организации.
+func (s *SnapshotService) Import(ctx context.Context, snapshot models.Snapshot, dryRun bool) (*models.ImportReport, error) {
+	ctx, span := tracing.Start(ctx, "SnapshotService.Import")
+	defer span.End()
+
+	var report *models.ImportReport
+	err := s.storage.InTx(ctx, func(ctx context.Context) error {
+		if err := s.storage.LockSnapshot(ctx); err != nil {
+			return err
+		}
+		existing, err := s.storage.GetSnapshot(ctx)
+		if err != nil {
+			return err
+		}
+
+		report = &models.ImportReport{DryRun: dryRun, Errors: []models.ImportError{}}
+		v := newImportValidator(existing, snapshot, report)
+		v.validateSettings(snapshot.Settings)
+		v.validateTeams(snapshot.Teams)
+		v.validateUsers(snapshot.Users)
+		v.validatePullRequests(snapshot.PullRequests)
+		v.validateEvents(snapshot.PREvents)
+		if dryRun || len(report.Errors) > 0 {
+			return nil
+		}
+
+		snapshot.PREvents = v.history(snapshot, callerActor(ctx))
+		report.PREvents.Created = len(snapshot.PREvents)
+		if err := s.storage.ImportSnapshot(ctx, snapshot); err != nil {
+			return err
+		}
+		report.Applied = true
+		return recordAudit(ctx, s.storage, AuditDataImport, "organization", tenant.MustOrgID(ctx), nil, map[string]models.ImportCounts{
+			models.RecordSettings:    report.Settings,
+			models.RecordTeam:        report.Teams,
+			models.RecordUser:        report.Users,
+			models.RecordPullRequest: report.PullRequests,
+			models.RecordPREvent:     report.PREvents,
+		})
+	})
+	if err != nil {
+		if errors.Is(err, postgres.ErrNotFound) {
+			return nil, ErrOrgNotFound
+		}
+		return nil, err
+	}
+
+	return report, nil
+}
+
+// importValidator проверяет записи импорта и считает созданные и обновленные
+type importValidator struct {
+	report *models.ImportReport
+	now    time.Time
+
+	// Сохраненные данные организации
+	teams  map[string]bool
+	users  map[string]bool
+	prs    map[string]models.PullRequest
+	events map[string]bool // ключи eventKey
+
+	// Команды, пользователи и PR, которые появятся после импорта
+	knownTeams map[string]bool
+	knownUsers map[string]bool
+	knownPRs   map[string]bool
+}
+
+func newImportValidator(existing *models.Snapshot, snapshot models.Snapshot, report *models.ImportReport) *importValidator {
+	v := &importValidator{
+		report:     report,
+		now:        time.Now(),
+		teams:      make(map[string]bool),
+		users:      make(map[string]bool),
+		prs:        make(map[string]models.PullRequest),
+		events:     make(map[string]bool),
+		knownTeams: make(map[string]bool),
+		knownUsers: make(map[string]bool),
+		knownPRs:   make(map[string]bool),
+	}
+	for _, team := range existing.Teams {
+		v.teams[team.TeamName] = true
+		v.knownTeams[team.TeamName] = true
+	}
+	for _, user := range existing.Users {
+		v.users[user.UserID] = true
+		v.knownUsers[user.UserID] = true
+	}
+	for _, pr := range existing.PullRequests {
+		v.prs[pr.PullRequestID] = pr
+		v.knownPRs[pr.PullRequestID] = true
+	}
+	for _, event := range existing.PREvents {
+		v.events[eventKey(event)] = true
+	}
+	for _, team := range snapshot.Teams {
+		if team.TeamName != "" {
+			v.knownTeams[team.TeamName] = true
+		}
+	}
+	for _, user := range snapshot.Users {
+		if user.UserID != "" {
+			v.knownUsers[user.UserID] = true
+		}
+	}
+	for _, pr := range snapshot.PullRequests {
+		if pr.PullRequestID != "" {
+			v.knownPRs[pr.PullRequestID] = true
+		}
+	}
+	return v
+}
+
+func (v *importValidator) fail(kind string, index int, id, code, format string, args ...any) {
+	v.report.Errors = append(v.report.Errors, models.ImportError{
+		Kind:    kind,
+		Index:   index,
+		ID:      id,
+		Code:    code,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+// validateSettings проверяет настройки назначения по тем же правилам, что и
+// OrgService.UpdateSettings; nil — настройки не меняются
+func (v *importValidator) validateSettings(settings *models.OrgSettings) {
+	if settings == nil {
+		return
+	}
+	switch {
+	case settings.ReviewersPerPR < 0 || settings.ReviewersPerPR > 2:
+		v.fail(models.RecordSettings, 0, "", ImportInvalidSettings, "reviewers_per_pr must be between 0 and 2")
+	case settings.AssignmentStrategy != models.AssignmentRandom && settings.AssignmentStrategy != models.AssignmentLeastLoaded:
+		v.fail(models.RecordSettings, 0, "", ImportInvalidSettings, "assignment_strategy must be %s or %s",
+			models.AssignmentRandom, models.AssignmentLeastLoaded)
+	default:
+		v.report.Settings.Updated++
+	}
+}
+
+func (v *importValidator) validateTeams(teams []models.SnapshotTeam) {
+	seen := make(map[string]bool, len(teams))
+	for i, team := range teams {
+		switch {
+		case team.TeamName == "":
+			v.fail(models.RecordTeam, i, "", ImportFieldRequired, "team_name is required")
+		case seen[team.TeamName]:
+			v.fail(models.RecordTeam, i, team.TeamName, ImportDuplicate, "team %s is listed more than once", team.TeamName)
+		case v.teams[team.TeamName]:
+			v.report.Teams.Updated++
+		default:
+			v.report.Teams.Created++
+		}
+		seen[team.TeamName] = true
+	}
+}
+
+func (v *importValidator) validateUsers(users []models.User) {
+	seen := make(map[string]bool, len(users))
+	for i, user := range users {
+		switch {
+		case user.UserID == "":
+			v.fail(models.RecordUser, i, "", ImportFieldRequired, "user_id is required")
+		case user.Username == "":
+			v.fail(models.RecordUser, i, user.UserID, ImportFieldRequired, "username is required")
+		case user.TeamName == "":
+			v.fail(models.RecordUser, i, user.UserID, ImportFieldRequired, "team_name is required")
+		case seen[user.UserID]:
+			v.fail(models.RecordUser, i, user.UserID, ImportDuplicate, "user %s is listed more than once", user.UserID)
+		case !v.knownTeams[user.TeamName]:
+			v.fail(models.RecordUser, i, user.UserID, ImportTeamNotFound, "team %s does not exist", user.TeamName)
+		case v.users[user.UserID]:
+			v.report.Users.Updated++
+		default:
+			v.report.Users.Created++
+		}
+		seen[user.UserID] = true
+	}
+}
+
+// validatePullRequests проверяет PR и дополняет значения по умолчанию:
+// пустой статус означает OPEN, у слитого PR без времени слияния оно
+// считается текущим
+func (v *importValidator) validatePullRequests(prs []models.PullRequest) {
+	seen := make(map[string]bool, len(prs))
+	for i := range prs {
+		pr := &prs[i]
+		if pr.Status == "" {
+			pr.Status = "OPEN"
+		}
+		if pr.Status == "MERGED" && pr.MergedAt == nil {
+			mergedAt := v.now
+			pr.MergedAt = &mergedAt
+		}
+
+		switch {
+		case pr.PullRequestID == "":
+			v.fail(models.RecordPullRequest, i, "", ImportFieldRequired, "pull_request_id is required")
+		case pr.PullRequestName == "":
+			v.fail(models.RecordPullRequest, i, pr.PullRequestID, ImportFieldRequired, "pull_request_name is required")
+		case pr.AuthorID == "":
+			v.fail(models.RecordPullRequest, i, pr.PullRequestID, ImportFieldRequired, "author_id is required")
+		case seen[pr.PullRequestID]:
+			v.fail(models.RecordPullRequest, i, pr.PullRequestID, ImportDuplicate, "pull request %s is listed more than once", pr.PullRequestID)
+		case !v.knownUsers[pr.AuthorID]:
+			v.fail(models.RecordPullRequest, i, pr.PullRequestID, ImportAuthorNotFound, "author %s does not exist", pr.AuthorID)
+		case pr.Status != "OPEN" && pr.Status != "MERGED":
+			v.fail(models.RecordPullRequest, i, pr.PullRequestID, ImportInvalidStatus, "status must be OPEN or MERGED, got %s", pr.Status)
+		case pr.Status == "OPEN" && pr.MergedAt != nil:
+			v.fail(models.RecordPullRequest, i, pr.PullRequestID, ImportInvalidMergedAt, "open pull request cannot have mergedAt")
+		case pr.CreatedAt != nil && pr.MergedAt != nil && pr.MergedAt.Before(*pr.CreatedAt):
+			v.fail(models.RecordPullRequest, i, pr.PullRequestID, ImportInvalidMergedAt, "mergedAt is before createdAt")
+		default:
+			if v.validateReviewers(i, pr) {
+				if _, ok := v.prs[pr.PullRequestID]; ok {
+					v.report.PullRequests.Updated++
+				} else {
+					v.report.PullRequests.Created++
+				}
+			}
+		}
+		seen[pr.PullRequestID] = true
+	}
+}
+
+func (v *importValidator) validateReviewers(index int, pr *models.PullRequest) bool {
+	if len(pr.AssignedReviewers) > 2 {
+		v.fail(models.RecordPullRequest, index, pr.PullRequestID, ImportInvalidReviewers, "at most 2 reviewers can be assigned")
+		return false
+	}
+	for i, reviewerID := range pr.AssignedReviewers {
+		switch {
+		case reviewerID == pr.AuthorID:
+			v.fail(models.RecordPullRequest, index, pr.PullRequestID, ImportInvalidReviewers, "author %s cannot review own pull request", reviewerID)
+			return false
+		case i == 1 && reviewerID == pr.AssignedReviewers[0]:
+			v.fail(models.RecordPullRequest, index, pr.PullRequestID, ImportInvalidReviewers, "reviewer %s is assigned twice", reviewerID)
+			return false
+		case !v.knownUsers[reviewerID]:
+			v.fail(models.RecordPullRequest, index, pr.PullRequestID, ImportReviewerNotFound, "reviewer %s does not exist", reviewerID)
+			return false
+		}
+	}
+	return true
+}
+
+// validateEvents проверяет события истории. Ревьюеры событий могут быть уже
+// удалены, поэтому их существование не проверяется; вердикт — только у
+// VERDICT. Число созданных событий считает history.
+func (v *importValidator) validateEvents(events []models.PREvent) {
+	for i, event := range events {
+		id := event.PullRequestID
+		switch {
+		case event.PullRequestID == "":
+			v.fail(models.RecordPREvent, i, "", ImportFieldRequired, "pull_request_id is required")
+		case event.Actor == "":
+			v.fail(models.RecordPREvent, i, id, ImportFieldRequired, "actor is required")
+		case !v.knownPRs[event.PullRequestID]:
+			v.fail(models.RecordPREvent, i, id, ImportPRNotFound, "pull request %s does not exist", event.PullRequestID)
+		default:
+			if message := eventProblem(event); message != "" {
+				v.fail(models.RecordPREvent, i, id, ImportInvalidEvent, "%s", message)
+			}
+		}
+	}
+}
+
+// eventProblem описывает нарушение формы события его типа или возвращает ""
+func eventProblem(event models.PREvent) string {
+	if event.Verdict != "" && event.EventType != models.PREventVerdict {
+		return "verdict is allowed only in VERDICT events"
+	}
+	switch event.EventType {
+	case models.PREventCreated, models.PREventMerged:
+	case models.PREventAssigned:
+		if event.ReviewerID == "" {
+			return "ASSIGNED event requires reviewer_id"
+		}
+	case models.PREventReassigned:
+		if event.ReviewerID == "" || event.OldReviewerID == "" {
+			return "REASSIGNED event requires reviewer_id and old_reviewer_id"
+		}
+	case models.PREventUnassigned:
+		if event.OldReviewerID == "" {
+			return "UNASSIGNED event requires old_reviewer_id"
+		}
+	case models.PREventVerdict:
+		if event.ReviewerID == "" {
+			return "VERDICT event requires reviewer_id"
+		}
+		if event.Verdict != models.VerdictApproved && event.Verdict != models.VerdictChangesRequested {
+			return fmt.Sprintf("verdict must be %s or %s", models.VerdictApproved, models.VerdictChangesRequested)
+		}
+	default:
+		return fmt.Sprintf("unknown event_type %q", event.EventType)
+	}
+	return ""
+}
+
+// history возвращает события для записи: события выгрузки, кроме уже
+// сохраненных, и для PR без событий в выгрузке — восстановленные по разнице
+// с сохраненным состоянием. Вызывается после проверки без ошибок.
+func (v *importValidator) history(snapshot models.Snapshot, actor string) []models.PREvent {
+	events := make([]models.PREvent, 0, len(snapshot.PREvents))
+	withHistory := make(map[string]bool)
+	for _, event := range snapshot.PREvents {
+		withHistory[event.PullRequestID] = true
+		if event.CreatedAt == nil || !v.events[eventKey(event)] {
+			events = append(events, event)
+		}
+	}
+
+	for _, pr := range snapshot.PullRequests {
+		if withHistory[pr.PullRequestID] {
+			continue
+		}
+		event := func(eventType string, at *time.Time) models.PREvent {
+			return models.PREvent{
+				PullRequestID: pr.PullRequestID,
+				EventType:     eventType,
+				Actor:         actor,
+				Reason:        importReason,
+				CreatedAt:     at,
+			}
+		}
+
+		before, stored := v.prs[pr.PullRequestID]
+		if !stored {
+			events = append(events, event(models.PREventCreated, pr.CreatedAt))
+		}
+		for _, reviewerID := range before.AssignedReviewers {
+			if !slices.Contains(pr.AssignedReviewers, reviewerID) {
+				unassigned := event(models.PREventUnassigned, nil)
+				unassigned.OldReviewerID = reviewerID
+				events = append(events, unassigned)
+			}
+		}
+		for _, reviewerID := range pr.AssignedReviewers {
+			if !slices.Contains(before.AssignedReviewers, reviewerID) {
+				at := pr.CreatedAt
+				if stored {
+					at = nil
+				}
+				assigned := event(models.PREventAssigned, at)
+				assigned.ReviewerID = reviewerID
+				events = append(events, assigned)
+			}
+		}
+		if pr.Status == "MERGED" && before.Status != "MERGED" {
+			events = append(events, event(models.PREventMerged, pr.MergedAt))
+		}
+	}
+	return events
+}
+
+// eventKey совпадает у событий, которые при импорте считаются одним и тем же
+func eventKey(event models.PREvent) string {
+	at := ""
+	if event.CreatedAt != nil {
+		at = event.CreatedAt.UTC().Format(time.RFC3339Nano)
+	}
+	return fmt.Sprintf("%s\x00%s\x00%s\x00%s\x00%s\x00%s\x00%s\x00%s", event.PullRequestID, event.EventType,
+		event.ReviewerID, event.OldReviewerID, event.Verdict, event.Actor, event.Reason, at)
+}
@@ -0,0 +1,132 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Vimp17/pr-reviewer-service/internal/models"
+	"github.com/Vimp17/pr-reviewer-service/internal/testdb"
+)
+
+// Выгрузка переносит настройки, PR и их историю вместе с вердиктами;
+// повторный импорт той же выгрузки историю не дублирует
+func TestSnapshotRoundTrip(t *testing.T) {
+	storage := testdb.Open(t)
+	snapshots := NewSnapshotService(storage)
+	source := testdb.AdminContext(testdb.NewOrg(t, storage, "snapshot-source"))
+	target := testdb.AdminContext(testdb.NewOrg(t, storage, "snapshot-target"))
+
+	createdAt := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	report, err := snapshots.Import(source, models.Snapshot{
+		Settings: &models.OrgSettings{ReviewersPerPR: 1, AssignmentStrategy: models.AssignmentLeastLoaded},
+		Teams:    []models.SnapshotTeam{{TeamName: "backend"}},
+		Users: []models.User{
+			{UserID: "u1", Username: "Alice", TeamName: "backend", IsActive: true},
+			{UserID: "u2", Username: "Bob", TeamName: "backend", IsActive: true},
+		},
+		PullRequests: []models.PullRequest{
+			{PullRequestID: "pr-1", PullRequestName: "Add search", AuthorID: "u1", AssignedReviewers: []string{"u2"}, CreatedAt: &createdAt},
+		},
+	}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !report.Applied || report.Settings.Updated != 1 || report.PREvents.Created != 2 {
+		t.Fatalf("source import report %+v", report)
+	}
+	if err := storage.AddPREvent(source, models.PREvent{
+		PullRequestID: "pr-1",
+		EventType:     models.PREventVerdict,
+		ReviewerID:    "u2",
+		Verdict:       models.VerdictApproved,
+		Actor:         "u2",
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	exported, err := snapshots.Export(source)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(exported.PREvents) != 3 || exported.PREvents[0].EventType != models.PREventCreated || !exported.PREvents[0].CreatedAt.Equal(createdAt) {
+		t.Fatalf("exported events %+v", exported.PREvents)
+	}
+
+	for i, want := range []int{3, 0} {
+		report, err := snapshots.Import(target, *exported, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(report.Errors) != 0 || report.PREvents.Created != want {
+			t.Fatalf("target import %d: report %+v, want %d events created", i, report, want)
+		}
+	}
+
+	settings, err := storage.GetOrgSettings(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *settings != *exported.Settings {
+		t.Errorf("target settings %+v, want %+v", settings, exported.Settings)
+	}
+	events, err := storage.GetPREvents(target, "pr-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 3 || events[2].Verdict != models.VerdictApproved {
+		t.Errorf("target history %+v", events)
+	}
+}
+
+func TestImportValidatorEvents(t *testing.T) {
+	existing := &models.Snapshot{
+		Users: []models.User{{UserID: "u1"}, {UserID: "u2"}, {UserID: "u3"}},
+		PullRequests: []models.PullRequest{
+			{PullRequestID: "pr-1", AuthorID: "u1", Status: "OPEN", AssignedReviewers: []string{"u2"}},
+		},
+	}
+	snapshot := models.Snapshot{
+		PullRequests: []models.PullRequest{
+			{PullRequestID: "pr-1", PullRequestName: "One", AuthorID: "u1", Status: "MERGED", AssignedReviewers: []string{"u3"}},
+		},
+		PREvents: []models.PREvent{
+			{PullRequestID: "pr-2", EventType: models.PREventCreated, Actor: "u1"},
+			{PullRequestID: "pr-1", EventType: models.PREventVerdict, ReviewerID: "u3", Verdict: "MAYBE", Actor: "u3"},
+			{PullRequestID: "pr-1", EventType: models.PREventAssigned, Verdict: models.VerdictApproved, Actor: "u1"},
+			{PullRequestID: "pr-1", EventType: models.PREventMerged},
+		},
+	}
+
+	report := &models.ImportReport{}
+	newImportValidator(existing, snapshot, report).validateEvents(snapshot.PREvents)
+	want := []string{ImportPRNotFound, ImportInvalidEvent, ImportInvalidEvent, ImportFieldRequired}
+	if len(report.Errors) != len(want) {
+		t.Fatalf("errors %+v, want codes %v", report.Errors, want)
+	}
+	for i, code := range want {
+		if report.Errors[i].Code != code || report.Errors[i].Index != i {
+			t.Errorf("error %d: %+v, want code %s", i, report.Errors[i], code)
+		}
+	}
+
+	// Без событий в выгрузке история восстанавливается по разнице с сохраненным PR
+	snapshot.PREvents = nil
+	events := newImportValidator(existing, snapshot, &models.ImportReport{}).history(snapshot, "ops")
+	var types []string
+	for _, event := range events {
+		types = append(types, event.EventType+":"+event.ReviewerID+event.OldReviewerID)
+		if event.Actor != "ops" || event.Reason != importReason {
+			t.Errorf("restored event %+v", event)
+		}
+	}
+	wantTypes := []string{"UNASSIGNED:u2", "ASSIGNED:u3", "MERGED:"}
+	if len(types) != len(wantTypes) {
+		t.Fatalf("restored history %v, want %v", types, wantTypes)
+	}
+	for i := range wantTypes {
+		if types[i] != wantTypes[i] {
+			t.Errorf("restored history %v, want %v", types, wantTypes)
+			break
+		}
+	}
+}
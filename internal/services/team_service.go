@@ -6,10 +6,13 @@ import (
 
 	"github.com/Vimp17/pr-reviewer-service/internal/models"
 	"github.com/Vimp17/pr-reviewer-service/internal/storage/postgres"
+	"github.com/Vimp17/pr-reviewer-service/internal/tracing"
 )
 
 var (
-	ErrTeamExists = errors.New("TEAM_EXISTS")
+	ErrTeamExists       = errors.New("TEAM_EXISTS")
+	ErrTeamNameRequired = errors.New("TEAM_NAME_REQUIRED")
+	ErrMembersRequired  = errors.New("MEMBERS_REQUIRED")
 )
 
 // TeamService управляет бизнес-логикой для команд
@@ -24,27 +27,63 @@ func NewTeamService(storage *postgres.Storage) *TeamService {
 
 // CreateTeam создает новую команду с участниками
 func (s *TeamService) CreateTeam(ctx context.Context, team models.Team) (*models.Team, error) {
+	ctx, span := tracing.Start(ctx, "TeamService.CreateTeam")
+	defer span.End()
+
 	// Проверяем валидность данных
 	if team.TeamName == "" {
-		return nil, errors.New("TEAM_NAME_REQUIRED")
+		return nil, ErrTeamNameRequired
 	}
 	if len(team.Members) == 0 {
-		return nil, errors.New("MEMBERS_REQUIRED")
+		return nil, ErrMembersRequired
 	}
 
-	// Создаем команду в хранилище
-	if err := s.storage.CreateTeam(ctx, team.TeamName, team.Members); err != nil {
-		if err.Error() == "TEAM_EXISTS" {
+	// Создаем команду и запись аудита в одной транзакции
+	var created *models.Team
+	err := s.storage.InTx(ctx, func(ctx context.Context) error {
+		if err := s.storage.CreateTeam(ctx, team.TeamName, team.Members); err != nil {
+			return err
+		}
+		var err error
+		if created, err = s.GetTeam(ctx, team.TeamName); err != nil {
+			return err
+		}
+		return recordAudit(ctx, s.storage, AuditTeamCreate, "team", team.TeamName, nil, created)
+	})
+	if err != nil {
+		if errors.Is(err, postgres.ErrAlreadyExists) {
 			return nil, ErrTeamExists
 		}
 		return nil, err
 	}
 
-	// Возвращаем созданную команду
-	return s.GetTeam(ctx, team.TeamName)
+	return created, nil
 }
 
 // GetTeam получает информацию о команде
 func (s *TeamService) GetTeam(ctx context.Context, teamName string) (*models.Team, error) {
-	return s.storage.GetTeam(ctx, teamName)
+	ctx, span := tracing.Start(ctx, "TeamService.GetTeam")
+	defer span.End()
+
+	team, err := s.storage.GetTeam(ctx, teamName)
+	if errors.Is(err, postgres.ErrNotFound) {
+		return nil, ErrNotFound
+	}
+	return team, err
+}
+
+// ListTeams возвращает все команды организации с участниками
+func (s *TeamService) ListTeams(ctx context.Context) ([]models.Team, error) {
+	ctx, span := tracing.Start(ctx, "TeamService.ListTeams")
+	defer span.End()
+
+	return s.storage.ListTeams(ctx)
+}
+
+// GetTeams получает команды по списку имен; несуществующие имена пропускаются
+func (s *TeamService) GetTeams(ctx context.Context, teamNames []string) ([]models.Team, error) {
+	ctx, span := tracing.Start(ctx, "TeamService.GetTeams")
+	defer span.End()
+
+	return s.storage.GetTeamsByNames(ctx, teamNames)
 }
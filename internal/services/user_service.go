@@ -2,9 +2,12 @@ package services
 
 import (
 	"context"
+	"errors"
+	"time"
 
 	"github.com/Vimp17/pr-reviewer-service/internal/models"
 	"github.com/Vimp17/pr-reviewer-service/internal/storage/postgres"
+	"github.com/Vimp17/pr-reviewer-service/internal/tracing"
 )
 
 // UserService управляет бизнес-логикой для пользователей
@@ -19,10 +22,131 @@ func NewUserService(storage *postgres.Storage) *UserService {
 
 // SetUserActiveStatus устанавливает флаг активности пользователя
 func (s *UserService) SetUserActiveStatus(ctx context.Context, userID string, isActive bool) (*models.User, error) {
-	return s.storage.UpdateUserActiveStatus(ctx, userID, isActive)
+	ctx, span := tracing.Start(ctx, "UserService.SetUserActiveStatus")
+	defer span.End()
+
+	user, err := s.storage.GetUser(ctx, userID)
+	if err != nil {
+		if errors.Is(err, postgres.ErrNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+
+	// Менять активность могут администратор и лид команды пользователя
+	if err := authorizeTeam(ctx, s.storage, user.TeamName); err != nil {
+		return nil, err
+	}
+
+	var updated *models.User
+	err = s.storage.InTx(ctx, func(ctx context.Context) error {
+		var err error
+		if updated, err = s.storage.UpdateUserActiveStatus(ctx, userID, isActive); err != nil {
+			return err
+		}
+		return recordAudit(ctx, s.storage, AuditUserSetActive, "user", userID, user, updated)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return updated, nil
+}
+
+// GetUser получает пользователя по ID
+func (s *UserService) GetUser(ctx context.Context, userID string) (*models.User, error) {
+	ctx, span := tracing.Start(ctx, "UserService.GetUser")
+	defer span.End()
+
+	user, err := s.storage.GetUser(ctx, userID)
+	if err != nil {
+		if errors.Is(err, postgres.ErrNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return user, nil
+}
+
+// GetUsers получает пользователей по списку ID; несуществующие ID пропускаются
+func (s *UserService) GetUsers(ctx context.Context, userIDs []string) ([]models.User, error) {
+	ctx, span := tracing.Start(ctx, "UserService.GetUsers")
+	defer span.End()
+
+	return s.storage.GetUsersByIDs(ctx, userIDs)
 }
 
-// GetPRsForReviewer получает PR, где пользователь назначен ревьювером
-func (s *UserService) GetPRsForReviewer(ctx context.Context, userID string) ([]models.PullRequestShort, error) {
-	return s.storage.GetPRsForReviewer(ctx, userID)
+// GetPRsForReviewer получает PR, где пользователь назначен ревьювером, с фильтром
+// по статусам (по умолчанию только открытые), новые первыми, и курсор следующей страницы
+func (s *UserService) GetPRsForReviewer(ctx context.Context, filter models.ReviewFilter, cursor string) ([]models.ReviewerPR, string, error) {
+	ctx, span := tracing.Start(ctx, "UserService.GetPRsForReviewer")
+	defer span.End()
+
+	if len(filter.Statuses) == 0 {
+		filter.Statuses = []string{"OPEN"}
+	}
+
+	const key = "-created_at"
+	after, err := decodeCursor(cursor, key)
+	if err != nil {
+		return nil, "", err
+	}
+	if after != nil {
+		if _, err := time.Parse(time.RFC3339Nano, after.Value); err != nil {
+			return nil, "", ErrInvalidCursor
+		}
+	}
+	filter.After = after
+
+	limit := listLimit(filter.Limit)
+	filter.Limit = limit + 1
+	prs, err := s.storage.GetPRsForReviewer(ctx, filter)
+	if err != nil {
+		return nil, "", err
+	}
+	if len(prs) <= limit {
+		return prs, "", nil
+	}
+
+	prs = prs[:limit]
+	last := prs[limit-1]
+	return prs, encodeCursor(models.ListCursor{
+		Sort:  key,
+		Value: last.CreatedAt.Format(time.RFC3339Nano),
+		ID:    last.PullRequestID,
+	}), nil
+}
+
+// ListUsers возвращает страницу пользователей по фильтру и курсор следующей страницы
+func (s *UserService) ListUsers(ctx context.Context, filter models.UserListFilter, sortBy, cursor string) ([]models.User, string, error) {
+	ctx, span := tracing.Start(ctx, "UserService.ListUsers")
+	defer span.End()
+
+	field, desc, err := parseSort(sortBy, "user_id", "user_id", "username")
+	if err != nil {
+		return nil, "", err
+	}
+	key := sortKey(field, desc)
+	if filter.After, err = decodeCursor(cursor, key); err != nil {
+		return nil, "", err
+	}
+	filter.Sort, filter.Desc = field, desc
+
+	limit := listLimit(filter.Limit)
+	filter.Limit = limit + 1
+	users, err := s.storage.ListUsers(ctx, filter)
+	if err != nil {
+		return nil, "", err
+	}
+	if len(users) <= limit {
+		return users, "", nil
+	}
+
+	users = users[:limit]
+	last := users[limit-1]
+	value := last.UserID
+	if field == "username" {
+		value = last.Username
+	}
+	return users, encodeCursor(models.ListCursor{Sort: key, Value: value, ID: last.UserID}), nil
 }
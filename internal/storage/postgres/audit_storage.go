@@ -0,0 +1,104 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Vimp17/pr-reviewer-service/internal/models"
+	"github.com/Vimp17/pr-reviewer-service/internal/tenant"
+)
+
+// InsertAuditEntry добавляет запись в журнал аудита организации из контекста
+func (s *Storage) InsertAuditEntry(ctx context.Context, entry models.AuditEntry) error {
+	return insertAuditEntry(ctx, s.db(ctx), entry)
+}
+
+// insertAuditEntry записывает запись журнала через q (пул или транзакцию)
+func insertAuditEntry(ctx context.Context, q querier, entry models.AuditEntry) error {
+	_, err := q.Exec(ctx, `
+		INSERT INTO audit_log (
+			org_id, actor, action, target_type, target_id, before, after, request_id
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, NULLIF($8, ''))
+	`,
+		tenant.MustOrgID(ctx),
+		entry.Actor,
+		entry.Action,
+		entry.TargetType,
+		entry.TargetID,
+		entry.Before,
+		entry.After,
+		entry.RequestID,
+	)
+	return err
+}
+
+// ListAuditEntries возвращает записи журнала по фильтру (новые первыми) и их общее количество
+func (s *Storage) ListAuditEntries(ctx context.Context, filter models.AuditFilter) ([]models.AuditEntry, int, error) {
+	conditions := []string{"org_id = $1"}
+	args := []any{tenant.MustOrgID(ctx)}
+
+	addCondition := func(expr string, value any) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(expr, len(args)))
+	}
+	if filter.Actor != "" {
+		addCondition("actor = $%d", filter.Actor)
+	}
+	if filter.Action != "" {
+		addCondition("action = $%d", filter.Action)
+	}
+	if filter.TargetType != "" {
+		addCondition("target_type = $%d", filter.TargetType)
+	}
+	if filter.TargetID != "" {
+		addCondition("target_id = $%d", filter.TargetID)
+	}
+	if filter.From != nil {
+		addCondition("created_at >= $%d", *filter.From)
+	}
+	if filter.To != nil {
+		addCondition("created_at < $%d", *filter.To)
+	}
+	where := strings.Join(conditions, " AND ")
+
+	var total int
+	if err := s.db(ctx).QueryRow(ctx, "SELECT COUNT(*) FROM audit_log WHERE "+where, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	args = append(args, filter.Limit, filter.Offset)
+	rows, err := s.db(ctx).Query(ctx, fmt.Sprintf(`
+		SELECT id, actor, action, target_type, target_id, before, after,
+			COALESCE(request_id, ''), created_at
+		FROM audit_log
+		WHERE %s
+		ORDER BY created_at DESC, id DESC
+		LIMIT $%d OFFSET $%d
+	`, where, len(args)-1, len(args)), args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	entries := make([]models.AuditEntry, 0, filter.Limit)
+	for rows.Next() {
+		var entry models.AuditEntry
+		if err := rows.Scan(
+			&entry.ID,
+			&entry.Actor,
+			&entry.Action,
+			&entry.TargetType,
+			&entry.TargetID,
+			&entry.Before,
+			&entry.After,
+			&entry.RequestID,
+			&entry.CreatedAt,
+		); err != nil {
+			return nil, 0, err
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, total, rows.Err()
+}
@@ -0,0 +1,87 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Vimp17/pr-reviewer-service/internal/models"
+	"github.com/Vimp17/pr-reviewer-service/internal/tenant"
+	"github.com/jackc/pgx/v5"
+)
+
+// ReserveIdempotencyKey резервирует ключ вызывающего caller за текущим запросом.
+// Если действующий ключ уже существует, возвращается сохраненная запись и reserved = false.
+// Просроченный ключ резервируется заново.
+func (s *Storage) ReserveIdempotencyKey(
+	ctx context.Context,
+	caller, key, requestHash string,
+	expiresAt time.Time,
+) (bool, *models.IdempotentResponse, error) {
+	orgID := tenant.MustOrgID(ctx)
+
+	var reserved int
+	err := s.pool.QueryRow(ctx, `
+		INSERT INTO idempotency_keys (org_id, caller, idempotency_key, request_hash, expires_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (org_id, caller, idempotency_key) DO UPDATE
+		SET request_hash = EXCLUDED.request_hash,
+			status_code = 0,
+			content_type = NULL,
+			response_body = NULL,
+			created_at = NOW(),
+			expires_at = EXCLUDED.expires_at
+		WHERE idempotency_keys.expires_at < NOW()
+		RETURNING 1
+	`, orgID, caller, key, requestHash, expiresAt).Scan(&reserved)
+	if err == nil {
+		return true, nil, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return false, nil, err
+	}
+
+	var stored models.IdempotentResponse
+	var contentType *string
+	err = s.pool.QueryRow(ctx, `
+		SELECT request_hash, status_code, content_type, response_body
+		FROM idempotency_keys
+		WHERE org_id = $1 AND caller = $2 AND idempotency_key = $3
+	`, orgID, caller, key).Scan(&stored.RequestHash, &stored.StatusCode, &contentType, &stored.Body)
+	if err != nil {
+		return false, nil, err
+	}
+	if contentType != nil {
+		stored.ContentType = *contentType
+	}
+
+	return false, &stored, nil
+}
+
+// SaveIdempotentResponse сохраняет ответ на запрос с зарезервированным ключом
+func (s *Storage) SaveIdempotentResponse(ctx context.Context, caller, key string, response models.IdempotentResponse) error {
+	_, err := s.pool.Exec(ctx, `
+		UPDATE idempotency_keys
+		SET status_code = $4, content_type = $5, response_body = $6
+		WHERE org_id = $1 AND caller = $2 AND idempotency_key = $3
+	`, tenant.MustOrgID(ctx), caller, key, response.StatusCode, response.ContentType, response.Body)
+	return err
+}
+
+// ReleaseIdempotencyKey снимает резерв с ключа, ответ для которого не сохраняется
+func (s *Storage) ReleaseIdempotencyKey(ctx context.Context, caller, key string) error {
+	_, err := s.pool.Exec(ctx, `
+		DELETE FROM idempotency_keys
+		WHERE org_id = $1 AND caller = $2 AND idempotency_key = $3 AND status_code = 0
+	`, tenant.MustOrgID(ctx), caller, key)
+	return err
+}
+
+// DeleteExpiredIdempotencyKeys удаляет просроченные ключи всех организаций
+func (s *Storage) DeleteExpiredIdempotencyKeys(ctx context.Context) (int64, error) {
+	tag, err := s.pool.Exec(ctx, `DELETE FROM idempotency_keys WHERE expires_at < NOW()`)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
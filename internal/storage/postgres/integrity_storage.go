@@ -0,0 +1,361 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/Vimp17/pr-reviewer-service/internal/models"
+	"github.com/Vimp17/pr-reviewer-service/internal/requestid"
+	"github.com/Vimp17/pr-reviewer-service/internal/tenant"
+	"github.com/jackc/pgx/v5"
+)
+
+// Действия журнала аудита для исправлений; совпадают с действиями services
+const (
+	auditPRRepair    = "pr.repair"
+	auditTokenRevoke = "token.revoke"
+)
+
+// Объекты, которые исправляет integrity
+const (
+	targetPullRequest = "pull_request"
+	targetAPIToken    = "api_token"
+)
+
+// integrityCheck поиск нарушений целостности по всем организациям. find
+// возвращает колонку ref со ссылкой на нарушающую строку; repair, если задан,
+// исправляет все найденные нарушения и возвращает исправленные строки target
+// (для PR — колонки prRepairColumns, для токенов — org_id и token_id).
+type integrityCheck struct {
+	name        string
+	description string
+	find        string
+	repair      string
+	target      string
+	constraint  string
+}
+
+// prRepairColumns исправленный PR: p — строка после исправления, t — до него
+const prRepairColumns = `
+	p.org_id, p.pull_request_id, p.pull_request_name, p.author_id, p.status, p.created_at,
+	t.reviewer1_id, t.reviewer2_id, t.merged_at, t.version,
+	p.reviewer1_id, p.reviewer2_id, p.merged_at, p.version`
+
+// integrityChecks порядок важен. Обновленная строка проверяется всеми
+// ограничениями CHECK, поэтому merged_at исправляется раньше ревьюеров, а строки
+// с недопустимым статусом ревьюеры не исправляют. Второй ревьюер исправляется
+// раньше первого, чтобы на место первого не сдвинулся отсутствующий второй.
+var integrityChecks = []integrityCheck{
+	{
+		name:        "pr_author_missing",
+		description: "pull requests whose author does not exist; recreate the user via /team/add",
+		find: `
+			SELECT p.org_id || '/' || p.pull_request_id AS ref
+			FROM pull_requests p
+			WHERE NOT EXISTS (SELECT 1 FROM users u WHERE u.org_id = p.org_id AND u.user_id = p.author_id)`,
+		constraint: "pull_requests_author_fkey",
+	},
+	{
+		name:        "pr_status_invalid",
+		description: "pull requests with status other than OPEN or MERGED",
+		find: `
+			SELECT org_id || '/' || pull_request_id AS ref
+			FROM pull_requests
+			WHERE status NOT IN ('OPEN', 'MERGED')`,
+		constraint: "pull_requests_status_check",
+	},
+	{
+		name: "pr_merged_at_mismatch",
+		description: "merged pull requests without merged_at or open ones with it; repair takes the time " +
+			"of the MERGED event (or creation) for merged and clears it for open",
+		find: `
+			SELECT org_id || '/' || pull_request_id AS ref
+			FROM pull_requests
+			WHERE status IN ('OPEN', 'MERGED') AND (status = 'MERGED') <> (merged_at IS NOT NULL)`,
+		repair: `
+			WITH t AS (
+				SELECT org_id, pull_request_id, reviewer1_id, reviewer2_id, merged_at, version
+				FROM pull_requests p
+				WHERE p.status IN ('OPEN', 'MERGED') AND (p.status = 'MERGED') <> (p.merged_at IS NOT NULL)
+				FOR UPDATE
+			)
+			UPDATE pull_requests p
+			SET merged_at = CASE WHEN p.status = 'MERGED' THEN COALESCE(
+					(SELECT MAX(e.created_at) FROM pr_events e
+					 WHERE e.org_id = p.org_id AND e.pull_request_id = p.pull_request_id
+						AND e.event_type = 'MERGED'),
+					p.created_at)
+				END,
+				version = p.version + 1
+			FROM t
+			WHERE p.org_id = t.org_id AND p.pull_request_id = t.pull_request_id
+			RETURNING` + prRepairColumns,
+		target:     targetPullRequest,
+		constraint: "pull_requests_merged_at_check",
+	},
+	{
+		name:        "pr_reviewer2_missing",
+		description: "pull requests whose second reviewer does not exist; repair unassigns the reviewer",
+		find: `
+			SELECT p.org_id || '/' || p.pull_request_id AS ref
+			FROM pull_requests p
+			WHERE p.reviewer2_id IS NOT NULL
+				AND NOT EXISTS (SELECT 1 FROM users u WHERE u.org_id = p.org_id AND u.user_id = p.reviewer2_id)`,
+		repair: `
+			WITH t AS (
+				SELECT org_id, pull_request_id, reviewer1_id, reviewer2_id, merged_at, version
+				FROM pull_requests p
+				WHERE p.reviewer2_id IS NOT NULL AND p.status IN ('OPEN', 'MERGED')
+					AND NOT EXISTS (SELECT 1 FROM users u WHERE u.org_id = p.org_id AND u.user_id = p.reviewer2_id)
+				FOR UPDATE
+			)
+			UPDATE pull_requests p
+			SET reviewer2_id = NULL, version = p.version + 1
+			FROM t
+			WHERE p.org_id = t.org_id AND p.pull_request_id = t.pull_request_id
+			RETURNING` + prRepairColumns,
+		target:     targetPullRequest,
+		constraint: "pull_requests_reviewer2_fkey",
+	},
+	{
+		name:        "pr_reviewer1_missing",
+		description: "pull requests whose first reviewer does not exist; repair unassigns the reviewer",
+		find: `
+			SELECT p.org_id || '/' || p.pull_request_id AS ref
+			FROM pull_requests p
+			WHERE p.reviewer1_id IS NOT NULL
+				AND NOT EXISTS (SELECT 1 FROM users u WHERE u.org_id = p.org_id AND u.user_id = p.reviewer1_id)`,
+		repair: `
+			WITH t AS (
+				SELECT org_id, pull_request_id, reviewer1_id, reviewer2_id, merged_at, version
+				FROM pull_requests p
+				WHERE p.reviewer1_id IS NOT NULL AND p.status IN ('OPEN', 'MERGED')
+					AND NOT EXISTS (SELECT 1 FROM users u WHERE u.org_id = p.org_id AND u.user_id = p.reviewer1_id)
+				FOR UPDATE
+			)
+			UPDATE pull_requests p
+			SET reviewer1_id = p.reviewer2_id, reviewer2_id = NULL, version = p.version + 1
+			FROM t
+			WHERE p.org_id = t.org_id AND p.pull_request_id = t.pull_request_id
+			RETURNING` + prRepairColumns,
+		target:     targetPullRequest,
+		constraint: "pull_requests_reviewer1_fkey",
+	},
+	{
+		name:        "api_token_user_missing",
+		description: "active API tokens bound to a user that does not exist; repair revokes them",
+		find: `
+			SELECT t.org_id || '/' || t.token_id AS ref
+			FROM api_tokens t
+			WHERE t.user_id IS NOT NULL AND t.revoked_at IS NULL
+				AND NOT EXISTS (SELECT 1 FROM users u WHERE u.org_id = t.org_id AND u.user_id = t.user_id)`,
+		repair: `
+			UPDATE api_tokens t
+			SET revoked_at = CURRENT_TIMESTAMP
+			WHERE t.user_id IS NOT NULL AND t.revoked_at IS NULL
+				AND NOT EXISTS (SELECT 1 FROM users u WHERE u.org_id = t.org_id AND u.user_id = t.user_id)
+			RETURNING t.org_id, t.token_id`,
+		target: targetAPIToken,
+	},
+}
+
+// CheckIntegrity ищет нарушения целостности данных во всех организациях.
+// С repair исправимые нарушения исправляются в одной транзакции, после чего
+// ограничения схемы без оставшихся нарушений проверяются для существующих строк.
+// Каждое исправление записывается в журнал аудита своей организации от имени
+// actor, а снятие ревьюера — еще и в историю PR событием UNASSIGNED.
+func (s *Storage) CheckIntegrity(ctx context.Context, repair bool, actor string) ([]models.IntegrityIssue, error) {
+	tx, err := s.beginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	issues := make([]models.IntegrityIssue, 0, len(integrityChecks))
+	for _, check := range integrityChecks {
+		issue := models.IntegrityIssue{
+			Check:       check.name,
+			Description: check.description,
+			Repairable:  check.repair != "",
+			Constraint:  check.constraint,
+		}
+		err := tx.QueryRow(ctx, `
+			SELECT COUNT(*), COALESCE((ARRAY_AGG(ref ORDER BY ref))[1:10], '{}')
+			FROM (`+check.find+`) AS found
+		`).Scan(&issue.Count, &issue.Examples)
+		if err != nil {
+			return nil, fmt.Errorf("integrity check %s: %w", check.name, err)
+		}
+
+		if repair && issue.Repairable && issue.Count > 0 {
+			repaired, err := applyRepair(ctx, tx, check, actor)
+			if err != nil {
+				return nil, fmt.Errorf("integrity repair %s: %w", check.name, err)
+			}
+			issue.Repaired = repaired
+		}
+
+		if check.constraint != "" {
+			if err := tx.QueryRow(ctx, `
+				SELECT convalidated FROM pg_constraint
+				WHERE conname = $1 AND conrelid = 'pull_requests'::regclass
+			`, check.constraint).Scan(&issue.Validated); err != nil {
+				return nil, fmt.Errorf("integrity check %s: %w", check.name, err)
+			}
+			if repair && !issue.Validated && issue.Count == issue.Repaired {
+				// Имя ограничения берется из списка выше, а не из ввода
+				if _, err := tx.Exec(ctx, `ALTER TABLE pull_requests VALIDATE CONSTRAINT `+check.constraint); err != nil {
+					return nil, fmt.Errorf("integrity validate %s: %w", check.constraint, err)
+				}
+				issue.Validated = true
+			}
+		}
+
+		issues = append(issues, issue)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return issues, nil
+}
+
+// prRepair PR до и после исправления
+type prRepair struct {
+	orgID         string
+	before, after models.PullRequest
+}
+
+// tokenRepair отозванный токен
+type tokenRepair struct {
+	orgID   string
+	tokenID int64
+}
+
+// applyRepair исправляет нарушения проверки и записывает каждое исправление в
+// журнал аудита и историю PR; возвращает число исправленных строк
+func applyRepair(ctx context.Context, tx pgx.Tx, check integrityCheck, actor string) (int, error) {
+	rows, err := tx.Query(ctx, check.repair)
+	if err != nil {
+		return 0, err
+	}
+
+	var (
+		prs    []prRepair
+		tokens []tokenRepair
+	)
+	for rows.Next() {
+		switch check.target {
+		case targetPullRequest:
+			var (
+				r              prRepair
+				createdAt      time.Time
+				old1, old2     *string
+				new1, new2     *string
+				oldMerged      *time.Time
+				newMerged      *time.Time
+				oldVer, newVer int
+			)
+			if err := rows.Scan(
+				&r.orgID, &r.before.PullRequestID, &r.before.PullRequestName, &r.before.AuthorID, &r.before.Status, &createdAt,
+				&old1, &old2, &oldMerged, &oldVer,
+				&new1, &new2, &newMerged, &newVer,
+			); err != nil {
+				rows.Close()
+				return 0, err
+			}
+			r.before.CreatedAt = &createdAt
+			r.after = r.before
+			r.before.AssignedReviewers, r.before.MergedAt, r.before.Version = reviewerList(old1, old2), oldMerged, oldVer
+			r.after.AssignedReviewers, r.after.MergedAt, r.after.Version = reviewerList(new1, new2), newMerged, newVer
+			prs = append(prs, r)
+		case targetAPIToken:
+			var r tokenRepair
+			if err := rows.Scan(&r.orgID, &r.tokenID); err != nil {
+				rows.Close()
+				return 0, err
+			}
+			tokens = append(tokens, r)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	requestID := requestid.FromContext(ctx)
+	for _, r := range prs {
+		orgCtx := tenant.WithOrgID(ctx, r.orgID)
+
+		var events []models.PREvent
+		for _, reviewer := range r.before.AssignedReviewers {
+			if !containsString(r.after.AssignedReviewers, reviewer) {
+				events = append(events, models.PREvent{
+					PullRequestID: r.before.PullRequestID,
+					EventType:     models.PREventUnassigned,
+					OldReviewerID: reviewer,
+					Actor:         actor,
+					Reason:        "integrity repair: " + check.name,
+				})
+			}
+		}
+		if err := insertPREvents(orgCtx, tx, events); err != nil {
+			return 0, err
+		}
+
+		before, err := json.Marshal(r.before)
+		if err != nil {
+			return 0, err
+		}
+		after, err := json.Marshal(r.after)
+		if err != nil {
+			return 0, err
+		}
+		if err := insertAuditEntry(orgCtx, tx, models.AuditEntry{
+			Actor:      actor,
+			Action:     auditPRRepair,
+			TargetType: targetPullRequest,
+			TargetID:   r.before.PullRequestID,
+			Before:     before,
+			After:      after,
+			RequestID:  requestID,
+		}); err != nil {
+			return 0, err
+		}
+	}
+	for _, r := range tokens {
+		if err := insertAuditEntry(tenant.WithOrgID(ctx, r.orgID), tx, models.AuditEntry{
+			Actor:      actor,
+			Action:     auditTokenRevoke,
+			TargetType: targetAPIToken,
+			TargetID:   strconv.FormatInt(r.tokenID, 10),
+			RequestID:  requestID,
+		}); err != nil {
+			return 0, err
+		}
+	}
+	return len(prs) + len(tokens), nil
+}
+
+// reviewerList собирает назначенных ревьюеров из колонок reviewer1_id и reviewer2_id
+func reviewerList(reviewer1, reviewer2 *string) []string {
+	reviewers := make([]string, 0, 2)
+	for _, r := range []*string{reviewer1, reviewer2} {
+		if r != nil {
+			reviewers = append(reviewers, *r)
+		}
+	}
+	return reviewers
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
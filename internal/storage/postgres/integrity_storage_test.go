@@ -0,0 +1,103 @@
+package postgres_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/Vimp17/pr-reviewer-service/internal/models"
+	"github.com/Vimp17/pr-reviewer-service/internal/requestid"
+	"github.com/Vimp17/pr-reviewer-service/internal/testdb"
+	"github.com/jackc/pgx/v5"
+)
+
+// Исправление integrity снимает удаленных ревьюеров и записывает это в историю
+// PR (UNASSIGNED) и в журнал аудита организации от имени cli
+func TestCheckIntegrityRepairIsRecorded(t *testing.T) {
+	storage := testdb.Open(t)
+	orgID := testdb.NewOrg(t, storage, "integrity")
+	ctx := testdb.AdminContext(orgID)
+
+	snapshot := models.Snapshot{Teams: []models.SnapshotTeam{{TeamName: "backend"}}}
+	for i := 1; i <= 3; i++ {
+		snapshot.Users = append(snapshot.Users, models.User{
+			UserID: fmt.Sprintf("u%d", i), Username: fmt.Sprintf("User %d", i), TeamName: "backend", IsActive: true,
+		})
+	}
+	snapshot.PullRequests = []models.PullRequest{
+		{PullRequestID: "pr-1", PullRequestName: "Both", AuthorID: "u1", Status: "OPEN", AssignedReviewers: []string{"u2", "u3"}},
+		{PullRequestID: "pr-2", PullRequestName: "First", AuthorID: "u1", Status: "OPEN", AssignedReviewers: []string{"u2"}},
+		{PullRequestID: "pr-3", PullRequestName: "Intact", AuthorID: "u1", Status: "OPEN", AssignedReviewers: []string{"u3"}},
+	}
+	if err := storage.ImportSnapshot(ctx, snapshot); err != nil {
+		t.Fatal(err)
+	}
+
+	// Удаляем ревьюера в обход внешних ключей, как в данных до миграции 000011
+	conn, err := pgx.Connect(context.Background(), os.Getenv(testdb.EnvDSN))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close(context.Background())
+	if _, err := conn.Exec(context.Background(), "SET session_replication_role = replica"); err != nil {
+		t.Skipf("cannot bypass foreign keys: %v", err)
+	}
+	if _, err := conn.Exec(context.Background(), "DELETE FROM users WHERE org_id = $1 AND user_id = 'u2'", orgID); err != nil {
+		t.Fatal(err)
+	}
+
+	repairCtx := requestid.WithRequestID(context.Background(), "integrity-test")
+	if _, err := storage.CheckIntegrity(repairCtx, true, "cli"); err != nil {
+		t.Fatal(err)
+	}
+
+	for id, want := range map[string]string{"pr-1": "[u3]", "pr-2": "[]", "pr-3": "[u3]"} {
+		pr, err := storage.GetPR(ctx, id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := fmt.Sprint(pr.AssignedReviewers); got != want {
+			t.Errorf("%s: reviewers %s, want %s", id, got, want)
+		}
+
+		events, err := storage.GetPREvents(ctx, id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		entries, total, err := storage.ListAuditEntries(ctx, models.AuditFilter{TargetType: "pull_request", TargetID: id, Limit: 10})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if id == "pr-3" {
+			if len(events) != 0 || total != 0 || pr.Version != 1 {
+				t.Errorf("pr-3 touched: events %v, audit %d, version %d", events, total, pr.Version)
+			}
+			continue
+		}
+
+		if pr.Version != 2 {
+			t.Errorf("%s: version %d, want 2", id, pr.Version)
+		}
+		if len(events) != 1 || events[0].EventType != models.PREventUnassigned ||
+			events[0].OldReviewerID != "u2" || events[0].ReviewerID != "" || events[0].Actor != "cli" {
+			t.Errorf("%s: events %+v", id, events)
+		}
+		if total != 1 {
+			t.Fatalf("%s: audit entries %d, want 1", id, total)
+		}
+		entry := entries[0]
+		if entry.Actor != "cli" || entry.Action != "pr.repair" || entry.RequestID != "integrity-test" {
+			t.Errorf("%s: audit entry %+v", id, entry)
+		}
+		var before, after models.PullRequest
+		if json.Unmarshal(entry.Before, &before) != nil || json.Unmarshal(entry.After, &after) != nil {
+			t.Fatalf("%s: audit states %s, %s", id, entry.Before, entry.After)
+		}
+		if before.Version != 1 || after.Version != 2 || fmt.Sprint(after.AssignedReviewers) != want {
+			t.Errorf("%s: audit before %+v, after %+v", id, before, after)
+		}
+	}
+}
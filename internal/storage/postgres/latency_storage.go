@@ -0,0 +1,198 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/Vimp17/pr-reviewer-service/internal/models"
+	"github.com/jackc/pgx/v5"
+)
+
+// prLatency дополняет statsScope задержками каждого PR в секундах:
+// first_review — до первого вердикта, merge — до слияния (NULL, если их не было)
+const prLatency = `,
+	pr_latency AS (
+		SELECT
+			sp.pull_request_id,
+			sp.author_team,
+			sp.created_at,
+			EXTRACT(EPOCH FROM fr.first_review_at - sp.created_at)::DOUBLE PRECISION AS first_review,
+			CASE WHEN sp.status = 'MERGED'
+				THEN EXTRACT(EPOCH FROM sp.merged_at - sp.created_at)::DOUBLE PRECISION
+			END AS merge
+		FROM scoped sp
+		LEFT JOIN LATERAL (
+			SELECT MIN(e.created_at) AS first_review_at
+			FROM pr_events e
+			WHERE e.org_id = sp.org_id AND e.pull_request_id = sp.pull_request_id
+				AND e.event_type = 'VERDICT'
+		) fr ON TRUE
+	)`
+
+// latencyColumns агрегаты задержек; percentile_cont пропускает NULL,
+// поэтому PR без ревью или без слияния не попадают в соответствующую выборку
+const latencyColumns = `
+	COUNT(first_review),
+	percentile_cont(0.5) WITHIN GROUP (ORDER BY first_review),
+	percentile_cont(0.9) WITHIN GROUP (ORDER BY first_review),
+	percentile_cont(0.99) WITHIN GROUP (ORDER BY first_review),
+	COUNT(merge),
+	percentile_cont(0.5) WITHIN GROUP (ORDER BY merge),
+	percentile_cont(0.9) WITHIN GROUP (ORDER BY merge),
+	percentile_cont(0.99) WITHIN GROUP (ORDER BY merge)`
+
+// latencyDest возвращает приемники для столбцов latencyColumns
+func latencyDest(l *models.Latency) []any {
+	return []any{
+		&l.TimeToFirstReview.Count,
+		&l.TimeToFirstReview.P50,
+		&l.TimeToFirstReview.P90,
+		&l.TimeToFirstReview.P99,
+		&l.TimeToMerge.Count,
+		&l.TimeToMerge.P50,
+		&l.TimeToMerge.P90,
+		&l.TimeToMerge.P99,
+	}
+}
+
+// GetLatencyStats собирает перцентили задержек ревью и слияния по PR в охвате
+// фильтра: в целом, по командам авторов, по ревьюерам и по неделям создания.
+// Назначения и вердикты ревьюеров берутся из истории PR.
+func (s *Storage) GetLatencyStats(ctx context.Context, filter models.StatsFilter) (*models.LatencyStats, error) {
+	tx, err := s.beginTx(ctx, pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	args := statsArgs(ctx, filter)
+	stats := &models.LatencyStats{Filter: filter}
+
+	if err := teamLatency(ctx, tx, args, stats); err != nil {
+		return nil, err
+	}
+	if stats.Reviewers, err = reviewerLatency(ctx, tx, args); err != nil {
+		return nil, err
+	}
+	if stats.Weekly, err = weeklyLatency(ctx, tx, args); err != nil {
+		return nil, err
+	}
+
+	return stats, tx.Commit(ctx)
+}
+
+// teamLatency заполняет общие задержки и задержки по командам авторов
+func teamLatency(ctx context.Context, tx pgx.Tx, args []any, stats *models.LatencyStats) error {
+	rows, err := tx.Query(ctx, statsScope+prLatency+`
+	SELECT GROUPING(author_team) = 1, COALESCE(author_team, ''),`+latencyColumns+`
+	FROM pr_latency
+	GROUP BY GROUPING SETS ((author_team), ())
+	ORDER BY GROUPING(author_team), author_team
+	`, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	stats.Teams = make([]models.TeamLatency, 0)
+	for rows.Next() {
+		var total bool
+		var team models.TeamLatency
+		dest := append([]any{&total, &team.TeamName}, latencyDest(&team.Latency)...)
+		if err := rows.Scan(dest...); err != nil {
+			return err
+		}
+		if total {
+			stats.Overall = team.Latency
+			continue
+		}
+		stats.Teams = append(stats.Teams, team)
+	}
+
+	return rows.Err()
+}
+
+// reviewerLatency возвращает задержки по ревьюерам: первое ревью отсчитывается
+// от назначения (в том числе переназначения), слияние — от создания PR
+func reviewerLatency(ctx context.Context, tx pgx.Tx, args []any) ([]models.ReviewerLatency, error) {
+	rows, err := tx.Query(ctx, statsScope+`,
+	assignments AS (
+		SELECT
+			e.reviewer_id,
+			EXTRACT(EPOCH FROM v.first_review_at - e.created_at)::DOUBLE PRECISION AS first_review,
+			CASE WHEN sp.status = 'MERGED'
+				THEN EXTRACT(EPOCH FROM sp.merged_at - sp.created_at)::DOUBLE PRECISION
+			END AS merge
+		FROM pr_events e
+		JOIN scoped sp ON sp.org_id = e.org_id AND sp.pull_request_id = e.pull_request_id
+		LEFT JOIN LATERAL (
+			SELECT MIN(ve.created_at) AS first_review_at
+			FROM pr_events ve
+			WHERE ve.org_id = e.org_id AND ve.pull_request_id = e.pull_request_id
+				AND ve.event_type = 'VERDICT' AND ve.reviewer_id = e.reviewer_id
+				AND ve.created_at >= e.created_at
+		) v ON TRUE
+		WHERE e.event_type IN ('ASSIGNED', 'REASSIGNED') AND e.reviewer_id IS NOT NULL
+	)
+	SELECT a.reviewer_id, COALESCE(u.team_name, ''),`+latencyColumns+`
+	FROM assignments a
+	LEFT JOIN users u ON u.org_id = $1 AND u.user_id = a.reviewer_id
+	GROUP BY a.reviewer_id, u.team_name
+	ORDER BY a.reviewer_id
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	reviewers := make([]models.ReviewerLatency, 0)
+	for rows.Next() {
+		var r models.ReviewerLatency
+		dest := append([]any{&r.UserID, &r.TeamName}, latencyDest(&r.Latency)...)
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+		reviewers = append(reviewers, r)
+	}
+
+	return reviewers, rows.Err()
+}
+
+// weeklyLatency возвращает ряд недель от from (или первого PR) до to (или
+// последнего PR) без пропусков: недели без PR имеют пустые выборки. Ряд не
+// выходит за время существования PR организации, чтобы широкий период не
+// порождал тысячи пустых недель.
+func weeklyLatency(ctx context.Context, tx pgx.Tx, args []any) ([]models.WeeklyLatency, error) {
+	rows, err := tx.Query(ctx, statsScope+prLatency+`,
+	weeks AS (
+		SELECT generate_series(
+			date_trunc('week', GREATEST(COALESCE($3, sb.first_at), ob.first_at), 'UTC'),
+			date_trunc('week', LEAST(COALESCE($4 - INTERVAL '1 microsecond', sb.last_at), CURRENT_TIMESTAMP), 'UTC'),
+			INTERVAL '1 week'
+		) AS week_start
+		FROM (SELECT MIN(created_at) AS first_at FROM pull_requests WHERE org_id = $1) ob,
+			(SELECT MIN(created_at) AS first_at, MAX(created_at) AS last_at FROM scoped) sb
+		WHERE ob.first_at IS NOT NULL
+	)
+	SELECT w.week_start, COUNT(pl.pull_request_id),`+latencyColumns+`
+	FROM weeks w
+	LEFT JOIN pr_latency pl ON date_trunc('week', pl.created_at, 'UTC') = w.week_start
+	GROUP BY w.week_start
+	ORDER BY w.week_start
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	weeks := make([]models.WeeklyLatency, 0)
+	for rows.Next() {
+		var w models.WeeklyLatency
+		dest := append([]any{&w.WeekStart, &w.PullRequests}, latencyDest(&w.Latency)...)
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+		weeks = append(weeks, w)
+	}
+
+	return weeks, rows.Err()
+}
@@ -0,0 +1,233 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Vimp17/pr-reviewer-service/internal/models"
+	"github.com/Vimp17/pr-reviewer-service/internal/tenant"
+)
+
+// Допустимые поля сортировки списков и соответствующие им колонки
+var (
+	prSortColumns = map[string]string{
+		"created_at":        "created_at",
+		"pull_request_name": "pull_request_name",
+		"pull_request_id":   "pull_request_id",
+	}
+	userSortColumns = map[string]string{
+		"user_id":  "user_id",
+		"username": "username",
+	}
+)
+
+// listQuery накапливает условия WHERE и аргументы динамического запроса
+type listQuery struct {
+	conditions []string
+	args       []any
+}
+
+func newListQuery(ctx context.Context) *listQuery {
+	return &listQuery{conditions: []string{"org_id = $1"}, args: []any{tenant.MustOrgID(ctx)}}
+}
+
+// add добавляет условие; каждый %[1]d в expr заменяется номером аргумента value
+func (q *listQuery) add(expr string, value any) {
+	q.args = append(q.args, value)
+	q.conditions = append(q.conditions, fmt.Sprintf(expr, len(q.args)))
+}
+
+// keyset добавляет условие курсора (column, idColumn) > (value, id) или < при обратной сортировке
+func (q *listQuery) keyset(column, idColumn string, desc bool, value any, id string) {
+	op := ">"
+	if desc {
+		op = "<"
+	}
+	q.args = append(q.args, value, id)
+	q.conditions = append(q.conditions, fmt.Sprintf("(%s, %s) %s ($%d, $%d)",
+		column, idColumn, op, len(q.args)-1, len(q.args)))
+}
+
+func (q *listQuery) where() string {
+	return strings.Join(q.conditions, " AND ")
+}
+
+// orderBy возвращает ORDER BY с ID как вторым ключом для устойчивого порядка
+func orderBy(column, idColumn string, desc bool) string {
+	direction := "ASC"
+	if desc {
+		direction = "DESC"
+	}
+	if column == idColumn {
+		return fmt.Sprintf("%s %s", idColumn, direction)
+	}
+	return fmt.Sprintf("%s %s, %s %s", column, direction, idColumn, direction)
+}
+
+// containsPattern строит шаблон ILIKE для поиска подстроки, экранируя спецсимволы
+func containsPattern(s string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+	return "%" + replacer.Replace(s) + "%"
+}
+
+// ListPRs возвращает страницу PR по фильтру в порядке сортировки.
+// Курсор filter.After должен быть получен при той же сортировке.
+func (s *Storage) ListPRs(ctx context.Context, filter models.PRListFilter) ([]models.PullRequest, error) {
+	prs := make([]models.PullRequest, 0, filter.Limit)
+	err := s.StreamPRs(ctx, filter, func(pr models.PullRequest) error {
+		prs = append(prs, pr)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return prs, nil
+}
+
+// StreamPRs передает fn PR по фильтру в порядке сортировки по мере чтения из
+// курсора БД, не накапливая их в памяти; filter.Limit 0 — без ограничения.
+// Ошибка fn прерывает чтение и возвращается.
+func (s *Storage) StreamPRs(ctx context.Context, filter models.PRListFilter, fn func(models.PullRequest) error) error {
+	column, ok := prSortColumns[filter.Sort]
+	if !ok {
+		return fmt.Errorf("unsupported PR sort %q", filter.Sort)
+	}
+
+	q := newListQuery(ctx)
+	if filter.Status != "" {
+		q.add("status = $%d", filter.Status)
+	}
+	if filter.AuthorID != "" {
+		q.add("author_id = $%d", filter.AuthorID)
+	}
+	if filter.ReviewerID != "" {
+		q.add("(reviewer1_id = $%[1]d OR reviewer2_id = $%[1]d)", filter.ReviewerID)
+	}
+	if filter.TeamName != "" {
+		q.add("author_id IN (SELECT user_id FROM users WHERE org_id = $1 AND team_name = $%d)", filter.TeamName)
+	}
+	if filter.NameContains != "" {
+		q.add("pull_request_name ILIKE $%d", containsPattern(filter.NameContains))
+	}
+	if filter.CreatedFrom != nil {
+		q.add("created_at >= $%d", *filter.CreatedFrom)
+	}
+	if filter.CreatedTo != nil {
+		q.add("created_at < $%d", *filter.CreatedTo)
+	}
+	if filter.MergedFrom != nil {
+		q.add("merged_at >= $%d", *filter.MergedFrom)
+	}
+	if filter.MergedTo != nil {
+		q.add("merged_at < $%d", *filter.MergedTo)
+	}
+	if filter.After != nil {
+		var value any = filter.After.Value
+		if column == "created_at" {
+			t, err := time.Parse(time.RFC3339Nano, filter.After.Value)
+			if err != nil {
+				return err
+			}
+			value = t
+		}
+		q.keyset(column, "pull_request_id", filter.Desc, value, filter.After.ID)
+	}
+
+	limit := ""
+	if filter.Limit > 0 {
+		q.args = append(q.args, filter.Limit)
+		limit = fmt.Sprintf("LIMIT $%d", len(q.args))
+	}
+	rows, err := s.db(ctx).Query(ctx, fmt.Sprintf(`
+		SELECT
+			pull_request_id, pull_request_name, author_id, status,
+			reviewer1_id, reviewer2_id, version, created_at, merged_at
+		FROM pull_requests
+		WHERE %s
+		ORDER BY %s
+		%s
+	`, q.where(), orderBy(column, "pull_request_id", filter.Desc), limit), q.args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var pr models.PullRequest
+		var reviewer1, reviewer2 *string
+		if err := rows.Scan(
+			&pr.PullRequestID,
+			&pr.PullRequestName,
+			&pr.AuthorID,
+			&pr.Status,
+			&reviewer1,
+			&reviewer2,
+			&pr.Version,
+			&pr.CreatedAt,
+			&pr.MergedAt,
+		); err != nil {
+			return err
+		}
+
+		pr.AssignedReviewers = make([]string, 0, 2)
+		if reviewer1 != nil {
+			pr.AssignedReviewers = append(pr.AssignedReviewers, *reviewer1)
+		}
+		if reviewer2 != nil {
+			pr.AssignedReviewers = append(pr.AssignedReviewers, *reviewer2)
+		}
+		if err := fn(pr); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// ListUsers возвращает страницу пользователей по фильтру в порядке сортировки
+func (s *Storage) ListUsers(ctx context.Context, filter models.UserListFilter) ([]models.User, error) {
+	column, ok := userSortColumns[filter.Sort]
+	if !ok {
+		return nil, fmt.Errorf("unsupported user sort %q", filter.Sort)
+	}
+
+	q := newListQuery(ctx)
+	if filter.TeamName != "" {
+		q.add("team_name = $%d", filter.TeamName)
+	}
+	if filter.IsActive != nil {
+		q.add("is_active = $%d", *filter.IsActive)
+	}
+	if filter.Query != "" {
+		q.add("(user_id ILIKE $%[1]d OR username ILIKE $%[1]d)", containsPattern(filter.Query))
+	}
+	if filter.After != nil {
+		q.keyset(column, "user_id", filter.Desc, filter.After.Value, filter.After.ID)
+	}
+
+	q.args = append(q.args, filter.Limit)
+	rows, err := s.db(ctx).Query(ctx, fmt.Sprintf(`
+		SELECT user_id, username, team_name, is_active
+		FROM users
+		WHERE %s
+		ORDER BY %s
+		LIMIT $%d
+	`, q.where(), orderBy(column, "user_id", filter.Desc), len(q.args)), q.args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := make([]models.User, 0, filter.Limit)
+	for rows.Next() {
+		var user models.User
+		if err := rows.Scan(&user.UserID, &user.Username, &user.TeamName, &user.IsActive); err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+
+	return users, rows.Err()
+}
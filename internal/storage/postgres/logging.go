@@ -0,0 +1,42 @@
+package postgres
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/jackc/pgx/v5/tracelog"
+)
+
+// newQueryLogger пишет запросы pgx в журнал slog с контекстом запроса, поэтому
+// записи содержат request_id. Ошибки запросов пишутся всегда, успешные запросы —
+// только при уровне журнала debug.
+func newQueryLogger() *tracelog.TraceLog {
+	level := tracelog.LogLevelError
+	if slog.Default().Enabled(context.Background(), slog.LevelDebug) {
+		level = tracelog.LogLevelInfo
+	}
+	return &tracelog.TraceLog{Logger: tracelog.LoggerFunc(logQuery), LogLevel: level}
+}
+
+func logQuery(ctx context.Context, level tracelog.LogLevel, msg string, data map[string]any) {
+	attrs := make([]slog.Attr, 0, len(data))
+	for key, value := range data {
+		switch key {
+		case "args":
+			// Аргументы могут содержать персональные данные и хэши токенов
+			continue
+		case "err":
+			key = "error"
+		}
+		attrs = append(attrs, slog.Any(key, value))
+	}
+
+	slevel := slog.LevelDebug
+	switch level {
+	case tracelog.LogLevelError:
+		slevel = slog.LevelError
+	case tracelog.LogLevelWarn:
+		slevel = slog.LevelWarn
+	}
+	slog.LogAttrs(ctx, slevel, "postgres: "+msg, attrs...)
+}
@@ -4,79 +4,91 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
-	"os"
-	"path/filepath"
 
+	"github.com/Vimp17/pr-reviewer-service/migrations"
 	_ "github.com/jackc/pgx/v5/stdlib" // КРИТИЧЕСКИ ВАЖНО: регистрируем драйвер
 	"github.com/pressly/goose/v3"
 )
 
-// ApplyMigrations применяет SQL-миграции из директории migrations
+// migrationLockID ключ advisory-блокировки на время миграций, чтобы
+// одновременно запущенные реплики не применяли их параллельно
+const migrationLockID int64 = 0x70725f7265766965 // "pr_revie"
+
+// ApplyMigrations применяет SQL-миграции, встроенные в бинарный файл
 func (s *Storage) ApplyMigrations(ctx context.Context) error {
-	// Получаем строку подключения из пула
-	connString := s.pool.Config().ConnString()
+	return s.Migrate(ctx, "up")
+}
 
-	// Создаем *sql.DB для goose
-	db, err := sql.Open("pgx", connString)
+// Migrate выполняет команду goose (up, down, status, redo и т. п.) над
+// встроенными миграциями; status пишет отчет в журнал goose. Команда
+// выполняется под advisory-блокировкой: вторая реплика ждет, пока первая
+// закончит, и затем видит уже примененные миграции.
+func (s *Storage) Migrate(ctx context.Context, command string, args ...string) error {
+	// Блокировка сессионная, поэтому держим одно соединение до ее снятия
+	conn, err := s.pool.Acquire(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to open database: %w", err)
+		return fmt.Errorf("failed to acquire connection: %w", err)
 	}
-	defer db.Close()
+	defer conn.Release()
 
-	// Проверяем соединение
-	if err := db.Ping(); err != nil {
-		return fmt.Errorf("failed to ping database: %w", err)
+	if _, err := conn.Exec(ctx, `SELECT pg_advisory_lock($1)`, migrationLockID); err != nil {
+		return fmt.Errorf("failed to acquire migration lock: %w", err)
 	}
+	defer func() {
+		// Снимаем блокировку и при отмененном ctx запуска
+		if _, err := conn.Exec(context.WithoutCancel(ctx), `SELECT pg_advisory_unlock($1)`, migrationLockID); err != nil {
+			conn.Conn().Close(context.WithoutCancel(ctx))
+		}
+	}()
 
-	// Определяем путь к миграциям
-	migrationsDir := getMigrationsDir()
-
-	// Применяем миграции
-	if err := goose.SetDialect("postgres"); err != nil {
-		return fmt.Errorf("failed to set dialect: %w", err)
+	// Создаем *sql.DB для goose
+	db, err := sql.Open("pgx", s.pool.Config().ConnString())
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
 	}
+	defer db.Close()
 
-	if err := goose.Up(db, migrationsDir); err != nil {
-		return fmt.Errorf("failed to apply migrations: %w", err)
+	if err := setupGoose(); err != nil {
+		return err
+	}
+	if err := goose.RunContext(ctx, command, db, ".", args...); err != nil {
+		return fmt.Errorf("failed to run migrations %s: %w", command, err)
 	}
 
 	return nil
 }
 
-// getMigrationsDir определяет путь к директории миграций
-func getMigrationsDir() string {
-	// Получаем текущую рабочую директорию
-	cwd, err := os.Getwd()
+// MigrationVersion возвращает версию последней миграции, примененной к БД
+func (s *Storage) MigrationVersion(ctx context.Context) (int64, error) {
+	var version int64
+	err := s.pool.QueryRow(ctx, `SELECT COALESCE(MAX(version_id), 0) FROM goose_db_version WHERE is_applied`).Scan(&version)
 	if err != nil {
-		return "./migrations"
+		return 0, fmt.Errorf("failed to get migration version: %w", err)
 	}
+	return version, nil
+}
 
-	// Проверяем, существует ли директория миграций в текущей рабочей директории
-	migrationsDir := filepath.Join(cwd, "migrations")
-	if _, err := os.Stat(migrationsDir); err == nil {
-		return migrationsDir
+// LatestMigrationVersion возвращает версию последней встроенной миграции
+func LatestMigrationVersion() (int64, error) {
+	if err := setupGoose(); err != nil {
+		return 0, err
 	}
-
-	// Проверяем другие возможные расположения
-	possiblePaths := []string{
-		"./migrations",
-		"../migrations",
-		"../../migrations",
-		"../../../migrations",
-		"../../../../migrations",
+	collected, err := goose.CollectMigrations(".", 0, goose.MaxVersion)
+	if err != nil {
+		return 0, fmt.Errorf("failed to collect migrations: %w", err)
 	}
-
-	for _, path := range possiblePaths {
-		absPath, err := filepath.Abs(path)
-		if err != nil {
-			continue
-		}
-
-		if _, err := os.Stat(absPath); err == nil {
-			return absPath
-		}
+	last, err := collected.Last()
+	if err != nil {
+		return 0, fmt.Errorf("failed to collect migrations: %w", err)
 	}
+	return last.Version, nil
+}
 
-	// Возвращаем относительный путь по умолчанию
-	return "./migrations"
+// setupGoose направляет goose на встроенные миграции PostgreSQL
+func setupGoose() error {
+	goose.SetBaseFS(migrations.FS)
+	if err := goose.SetDialect("postgres"); err != nil {
+		return fmt.Errorf("failed to set dialect: %w", err)
+	}
+	return nil
 }
@@ -0,0 +1,73 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Vimp17/pr-reviewer-service/internal/models"
+	"github.com/Vimp17/pr-reviewer-service/internal/tenant"
+	"github.com/jackc/pgx/v5"
+)
+
+// CreateOrganization создает новую организацию; ErrAlreadyExists, если
+// организация с таким ID уже есть
+func (s *Storage) CreateOrganization(ctx context.Context, org models.Organization) error {
+	_, err := s.db(ctx).Exec(ctx, `
+		INSERT INTO organizations (org_id, name, reviewers_per_pr, assignment_strategy)
+		VALUES ($1, $2, $3, $4)
+	`, org.OrgID, org.Name, org.Settings.ReviewersPerPR, org.Settings.AssignmentStrategy)
+	if isUniqueViolation(err) {
+		return ErrAlreadyExists
+	}
+	return err
+}
+
+// GetOrganization получает организацию по ID
+func (s *Storage) GetOrganization(ctx context.Context, orgID string) (*models.Organization, error) {
+	var org models.Organization
+	err := s.db(ctx).QueryRow(ctx, `
+		SELECT org_id, name, reviewers_per_pr, assignment_strategy
+		FROM organizations
+		WHERE org_id = $1
+	`, orgID).Scan(
+		&org.OrgID,
+		&org.Name,
+		&org.Settings.ReviewersPerPR,
+		&org.Settings.AssignmentStrategy,
+	)
+
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+
+	return &org, nil
+}
+
+// GetOrgSettings возвращает настройки назначения для организации из контекста
+func (s *Storage) GetOrgSettings(ctx context.Context) (*models.OrgSettings, error) {
+	org, err := s.GetOrganization(ctx, tenant.MustOrgID(ctx))
+	if err != nil {
+		return nil, err
+	}
+	return &org.Settings, nil
+}
+
+// UpdateOrgSettings обновляет настройки назначения для организации из контекста
+func (s *Storage) UpdateOrgSettings(ctx context.Context, settings models.OrgSettings) (*models.Organization, error) {
+	tag, err := s.db(ctx).Exec(ctx, `
+		UPDATE organizations
+		SET reviewers_per_pr = $2, assignment_strategy = $3
+		WHERE org_id = $1
+	`, tenant.MustOrgID(ctx), settings.ReviewersPerPR, settings.AssignmentStrategy)
+	if err != nil {
+		return nil, err
+	}
+	if tag.RowsAffected() == 0 {
+		return nil, ErrNotFound
+	}
+
+	return s.GetOrganization(ctx, tenant.MustOrgID(ctx))
+}
@@ -0,0 +1,86 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/Vimp17/pr-reviewer-service/internal/models"
+	"github.com/Vimp17/pr-reviewer-service/internal/tenant"
+	"github.com/jackc/pgx/v5"
+)
+
+// AddPREvent добавляет событие в историю PR
+func (s *Storage) AddPREvent(ctx context.Context, event models.PREvent) error {
+	tx, err := s.beginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := insertPREvents(ctx, tx, []models.PREvent{event}); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// GetPREvents возвращает историю PR в хронологическом порядке
+func (s *Storage) GetPREvents(ctx context.Context, prID string) ([]models.PREvent, error) {
+	rows, err := s.db(ctx).Query(ctx, `
+		SELECT
+			id, pull_request_id, event_type,
+			COALESCE(reviewer_id, ''), COALESCE(old_reviewer_id, ''), COALESCE(verdict, ''),
+			actor, COALESCE(reason, ''), created_at
+		FROM pr_events
+		WHERE org_id = $1 AND pull_request_id = $2
+		ORDER BY created_at, id
+	`, tenant.MustOrgID(ctx), prID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := make([]models.PREvent, 0)
+	for rows.Next() {
+		var event models.PREvent
+		if err := rows.Scan(
+			&event.ID,
+			&event.PullRequestID,
+			&event.EventType,
+			&event.ReviewerID,
+			&event.OldReviewerID,
+			&event.Verdict,
+			&event.Actor,
+			&event.Reason,
+			&event.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+
+	return events, rows.Err()
+}
+
+// insertPREvents записывает события в рамках транзакции изменения PR
+func insertPREvents(ctx context.Context, tx pgx.Tx, events []models.PREvent) error {
+	for _, event := range events {
+		_, err := tx.Exec(ctx, `
+			INSERT INTO pr_events (
+				org_id, pull_request_id, event_type, reviewer_id, old_reviewer_id,
+				verdict, actor, reason
+			) VALUES ($1, $2, $3, NULLIF($4, ''), NULLIF($5, ''), NULLIF($6, ''), $7, NULLIF($8, ''))
+		`,
+			tenant.MustOrgID(ctx),
+			event.PullRequestID,
+			event.EventType,
+			event.ReviewerID,
+			event.OldReviewerID,
+			event.Verdict,
+			event.Actor,
+			event.Reason,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
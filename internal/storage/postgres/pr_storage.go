@@ -5,38 +5,60 @@ import (
 	"errors"
 
 	"github.com/Vimp17/pr-reviewer-service/internal/models"
+	"github.com/Vimp17/pr-reviewer-service/internal/tenant"
 	"github.com/jackc/pgx/v5"
 )
 
 var (
-	ErrNotFound = errors.New("not found")
+	ErrNotFound      = errors.New("not found")
+	ErrConflict      = errors.New("conflict")
+	ErrAlreadyExists = errors.New("already exists")
 )
 
 // CheckPRExists проверяет существование PR
 func (s *Storage) CheckPRExists(ctx context.Context, prID string) (bool, error) {
 	var exists bool
-	err := s.pool.QueryRow(ctx, `
-		SELECT EXISTS(SELECT 1 FROM pull_requests WHERE pull_request_id = $1)
-	`, prID).Scan(&exists)
+	err := s.db(ctx).QueryRow(ctx, `
+		SELECT EXISTS(SELECT 1 FROM pull_requests WHERE org_id = $1 AND pull_request_id = $2)
+	`, tenant.MustOrgID(ctx), prID).Scan(&exists)
 	return exists, err
 }
 
-// CreatePR создает новый PR
-func (s *Storage) CreatePR(ctx context.Context, pr models.PullRequest) error {
-	_, err := s.pool.Exec(ctx, `
+// CreatePR создает новый PR и записывает события его истории
+func (s *Storage) CreatePR(ctx context.Context, pr models.PullRequest, events ...models.PREvent) error {
+	tx, err := s.beginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, `
 		INSERT INTO pull_requests (
-			pull_request_id, pull_request_name, author_id, status, 
-			reviewer1_id, reviewer2_id
-		) VALUES ($1, $2, $3, $4, $5, $6)
+			org_id, pull_request_id, pull_request_name, author_id, status, 
+			reviewer1_id, reviewer2_id, version
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
 	`,
+		tenant.MustOrgID(ctx),
 		pr.PullRequestID,
 		pr.PullRequestName,
 		pr.AuthorID,
 		pr.Status,
 		getReviewer(pr.AssignedReviewers, 0),
 		getReviewer(pr.AssignedReviewers, 1),
+		pr.Version,
 	)
-	return err
+	// PR с тем же ID мог быть создан параллельным запросом после проверки CheckPRExists
+	if isUniqueViolation(err) {
+		return ErrAlreadyExists
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := insertPREvents(ctx, tx, events); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
 }
 
 // GetPR получает информацию о PR
@@ -44,19 +66,20 @@ func (s *Storage) GetPR(ctx context.Context, prID string) (*models.PullRequest,
 	pr := &models.PullRequest{}
 	var reviewer1, reviewer2 *string
 
-	err := s.pool.QueryRow(ctx, `
+	err := s.db(ctx).QueryRow(ctx, `
 		SELECT 
 			pull_request_id, pull_request_name, author_id, status,
-			reviewer1_id, reviewer2_id, created_at, merged_at
+			reviewer1_id, reviewer2_id, version, created_at, merged_at
 		FROM pull_requests
-		WHERE pull_request_id = $1
-	`, prID).Scan(
+		WHERE org_id = $1 AND pull_request_id = $2
+	`, tenant.MustOrgID(ctx), prID).Scan(
 		&pr.PullRequestID,
 		&pr.PullRequestName,
 		&pr.AuthorID,
 		&pr.Status,
 		&reviewer1,
 		&reviewer2,
+		&pr.Version,
 		&pr.CreatedAt,
 		&pr.MergedAt,
 	)
@@ -80,51 +103,121 @@ func (s *Storage) GetPR(ctx context.Context, prID string) (*models.PullRequest,
 	return pr, nil
 }
 
-// UpdatePRReviewers обновляет список ревьюеров для PR
-func (s *Storage) UpdatePRReviewers(ctx context.Context, prID string, reviewers []string) error {
-	_, err := s.pool.Exec(ctx, `
+// UpdatePRReviewers обновляет список ревьюеров открытого PR и записывает события его истории.
+// Обновление выполняется, только если версия PR не изменилась с момента чтения,
+// иначе возвращается ErrConflict.
+func (s *Storage) UpdatePRReviewers(
+	ctx context.Context,
+	prID string,
+	expectedVersion int,
+	reviewers []string,
+	events ...models.PREvent,
+) error {
+	tx, err := s.beginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, `
 		UPDATE pull_requests
-		SET reviewer1_id = $2, reviewer2_id = $3
-		WHERE pull_request_id = $1
+		SET reviewer1_id = $4, reviewer2_id = $5, version = version + 1
+		WHERE org_id = $1 AND pull_request_id = $2 AND version = $3 AND status = 'OPEN'
 	`,
+		tenant.MustOrgID(ctx),
 		prID,
+		expectedVersion,
 		getReviewer(reviewers, 0),
 		getReviewer(reviewers, 1),
 	)
-	return err
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrConflict
+	}
+
+	if err := insertPREvents(ctx, tx, events); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
 }
 
-// MergePR помечает PR как MERGED
-// MergePR помечает PR как MERGED
-func (s *Storage) MergePR(ctx context.Context, prID string) (*models.PullRequest, error) {
+// AddVerdict записывает вердикт ревьюера, только если PR открыт, его версия
+// не изменилась с момента чтения и ревьюер по-прежнему назначен, иначе
+// возвращается ErrConflict. Строка PR блокируется до конца транзакции, так
+// что переназначение и слияние не проходят между проверкой и записью.
+func (s *Storage) AddVerdict(ctx context.Context, prID string, expectedVersion int, event models.PREvent) error {
+	tx, err := s.beginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, `
+		SELECT 1 FROM pull_requests
+		WHERE org_id = $1 AND pull_request_id = $2 AND version = $3 AND status = 'OPEN'
+			AND $4 IN (reviewer1_id, reviewer2_id)
+		FOR SHARE
+	`, tenant.MustOrgID(ctx), prID, expectedVersion, event.ReviewerID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrConflict
+	}
+
+	if err := insertPREvents(ctx, tx, []models.PREvent{event}); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// MergePR помечает открытый PR как MERGED и записывает события его истории.
+// Если PR уже слит или отсутствует, возвращается ErrConflict.
+func (s *Storage) MergePR(ctx context.Context, prID string, events ...models.PREvent) (*models.PullRequest, error) {
 	pr := &models.PullRequest{}
 	var reviewer1, reviewer2 *string
 
-	err := s.pool.QueryRow(ctx, `
+	tx, err := s.beginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	err = tx.QueryRow(ctx, `
         UPDATE pull_requests 
-        SET status = 'MERGED', merged_at = NOW()
-        WHERE pull_request_id = $1
+        SET status = 'MERGED', merged_at = NOW(), version = version + 1
+        WHERE org_id = $1 AND pull_request_id = $2 AND status = 'OPEN'
         RETURNING 
             pull_request_id, pull_request_name, author_id, status,
-            reviewer1_id, reviewer2_id, created_at, merged_at
-    `, prID).Scan(
+            reviewer1_id, reviewer2_id, version, created_at, merged_at
+    `, tenant.MustOrgID(ctx), prID).Scan(
 		&pr.PullRequestID,
 		&pr.PullRequestName,
 		&pr.AuthorID,
 		&pr.Status,
 		&reviewer1,
 		&reviewer2,
+		&pr.Version,
 		&pr.CreatedAt,
 		&pr.MergedAt,
 	)
 
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return nil, ErrNotFound
+			return nil, ErrConflict
 		}
 		return nil, err
 	}
 
+	if err := insertPREvents(ctx, tx, events); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+
 	// Преобразуем указатели в слайс
 	pr.AssignedReviewers = make([]string, 0, 2)
 	if reviewer1 != nil {
@@ -137,35 +230,81 @@ func (s *Storage) MergePR(ctx context.Context, prID string) (*models.PullRequest
 	return pr, nil
 }
 
-// GetAssignmentStats возвращает статистику по назначениям
-func (s *Storage) GetAssignmentStats(ctx context.Context) (map[string]int, error) {
-	query := `
-		SELECT reviewer_id, COUNT(*)
-		FROM (
-			SELECT reviewer1_id AS reviewer_id FROM pull_requests WHERE reviewer1_id IS NOT NULL
-			UNION ALL
-			SELECT reviewer2_id FROM pull_requests WHERE reviewer2_id IS NOT NULL
-		) AS reviewers
-		GROUP BY reviewer_id
-	`
-
-	rows, err := s.pool.Query(ctx, query)
+// GetOpenPRsByReviewers возвращает открытые PR, где ревьюером назначен
+// любой из указанных пользователей, одним запросом
+func (s *Storage) GetOpenPRsByReviewers(ctx context.Context, reviewerIDs []string) ([]models.PullRequest, error) {
+	rows, err := s.db(ctx).Query(ctx, `
+		SELECT
+			pull_request_id, pull_request_name, author_id, status,
+			reviewer1_id, reviewer2_id, version, created_at, merged_at
+		FROM pull_requests
+		WHERE org_id = $1 AND status = 'OPEN'
+			AND (reviewer1_id = ANY($2) OR reviewer2_id = ANY($2))
+		ORDER BY created_at, pull_request_id
+	`, tenant.MustOrgID(ctx), reviewerIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	prs := make([]models.PullRequest, 0)
+	for rows.Next() {
+		var pr models.PullRequest
+		var reviewer1, reviewer2 *string
+		if err := rows.Scan(
+			&pr.PullRequestID,
+			&pr.PullRequestName,
+			&pr.AuthorID,
+			&pr.Status,
+			&reviewer1,
+			&reviewer2,
+			&pr.Version,
+			&pr.CreatedAt,
+			&pr.MergedAt,
+		); err != nil {
+			return nil, err
+		}
+
+		pr.AssignedReviewers = make([]string, 0, 2)
+		if reviewer1 != nil {
+			pr.AssignedReviewers = append(pr.AssignedReviewers, *reviewer1)
+		}
+		if reviewer2 != nil {
+			pr.AssignedReviewers = append(pr.AssignedReviewers, *reviewer2)
+		}
+		prs = append(prs, pr)
+	}
+
+	return prs, rows.Err()
+}
+
+// GetOpenReviewCounts возвращает количество открытых PR на ревью у каждого из пользователей
+func (s *Storage) GetOpenReviewCounts(ctx context.Context, userIDs []string) (map[string]int, error) {
+	rows, err := s.db(ctx).Query(ctx, `
+		SELECT u.user_id, COUNT(pr.pull_request_id)
+		FROM unnest($2::varchar[]) AS u(user_id)
+		LEFT JOIN pull_requests pr
+			ON pr.org_id = $1
+			AND pr.status = 'OPEN'
+			AND (pr.reviewer1_id = u.user_id OR pr.reviewer2_id = u.user_id)
+		GROUP BY u.user_id
+	`, tenant.MustOrgID(ctx), userIDs)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	stats := make(map[string]int)
+	counts := make(map[string]int, len(userIDs))
 	for rows.Next() {
 		var userID string
 		var count int
 		if err := rows.Scan(&userID, &count); err != nil {
 			return nil, err
 		}
-		stats[userID] = count
+		counts[userID] = count
 	}
 
-	return stats, rows.Err()
+	return counts, rows.Err()
 }
 
 // Вспомогательные функции
@@ -0,0 +1,248 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Vimp17/pr-reviewer-service/internal/models"
+	"github.com/Vimp17/pr-reviewer-service/internal/tenant"
+	"github.com/jackc/pgx/v5"
+)
+
+// LockSnapshot блокирует до конца транзакции InTx строку организации и ее
+// команды, пользователей и PR, чтобы выгрузка, проверенная в этой транзакции,
+// не менялась до ее записи
+func (s *Storage) LockSnapshot(ctx context.Context) error {
+	orgID := tenant.MustOrgID(ctx)
+	for _, query := range []string{
+		`SELECT 1 FROM organizations WHERE org_id = $1 FOR UPDATE`,
+		`SELECT 1 FROM teams WHERE org_id = $1 FOR UPDATE`,
+		`SELECT 1 FROM users WHERE org_id = $1 FOR UPDATE`,
+		`SELECT 1 FROM pull_requests WHERE org_id = $1 FOR UPDATE`,
+	} {
+		if _, err := s.db(ctx).Exec(ctx, query, orgID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetSnapshot выгружает настройки, команды, пользователей, PR и историю PR
+// организации в одном снимке данных
+func (s *Storage) GetSnapshot(ctx context.Context) (*models.Snapshot, error) {
+	tx, err := s.beginTx(ctx, pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	orgID := tenant.MustOrgID(ctx)
+	snapshot := &models.Snapshot{Settings: &models.OrgSettings{}}
+
+	err = tx.QueryRow(ctx, `
+		SELECT reviewers_per_pr, assignment_strategy FROM organizations WHERE org_id = $1
+	`, orgID).Scan(&snapshot.Settings.ReviewersPerPR, &snapshot.Settings.AssignmentStrategy)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+
+	rows, err := tx.Query(ctx, `
+		SELECT team_name FROM teams WHERE org_id = $1 ORDER BY team_name
+	`, orgID)
+	if err != nil {
+		return nil, err
+	}
+	snapshot.Teams, err = pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.SnapshotTeam, error) {
+		var team models.SnapshotTeam
+		err := row.Scan(&team.TeamName)
+		return team, err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err = tx.Query(ctx, `
+		SELECT user_id, username, team_name, is_active
+		FROM users
+		WHERE org_id = $1
+		ORDER BY user_id
+	`, orgID)
+	if err != nil {
+		return nil, err
+	}
+	snapshot.Users, err = pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.User, error) {
+		var user models.User
+		err := row.Scan(&user.UserID, &user.Username, &user.TeamName, &user.IsActive)
+		return user, err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err = tx.Query(ctx, `
+		SELECT
+			pull_request_id, pull_request_name, author_id, status,
+			reviewer1_id, reviewer2_id, version, created_at, merged_at
+		FROM pull_requests
+		WHERE org_id = $1
+		ORDER BY created_at, pull_request_id
+	`, orgID)
+	if err != nil {
+		return nil, err
+	}
+	snapshot.PullRequests, err = pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.PullRequest, error) {
+		var pr models.PullRequest
+		var reviewer1, reviewer2 *string
+		err := row.Scan(
+			&pr.PullRequestID,
+			&pr.PullRequestName,
+			&pr.AuthorID,
+			&pr.Status,
+			&reviewer1,
+			&reviewer2,
+			&pr.Version,
+			&pr.CreatedAt,
+			&pr.MergedAt,
+		)
+		pr.AssignedReviewers = make([]string, 0, 2)
+		for _, reviewer := range []*string{reviewer1, reviewer2} {
+			if reviewer != nil {
+				pr.AssignedReviewers = append(pr.AssignedReviewers, *reviewer)
+			}
+		}
+		return pr, err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err = tx.Query(ctx, `
+		SELECT
+			id, pull_request_id, event_type,
+			COALESCE(reviewer_id, ''), COALESCE(old_reviewer_id, ''), COALESCE(verdict, ''),
+			actor, COALESCE(reason, ''), created_at
+		FROM pr_events
+		WHERE org_id = $1
+		ORDER BY created_at, id
+	`, orgID)
+	if err != nil {
+		return nil, err
+	}
+	snapshot.PREvents, err = pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.PREvent, error) {
+		var event models.PREvent
+		err := row.Scan(
+			&event.ID,
+			&event.PullRequestID,
+			&event.EventType,
+			&event.ReviewerID,
+			&event.OldReviewerID,
+			&event.Verdict,
+			&event.Actor,
+			&event.Reason,
+			&event.CreatedAt,
+		)
+		return event, err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return snapshot, tx.Commit(ctx)
+}
+
+// ImportSnapshot сохраняет выгрузку в одной транзакции: настройки
+// перезаписываются, если заданы, команды создаются, если их нет, пользователи
+// и PR создаются или перезаписываются (версия PR при перезаписи
+// увеличивается), события истории добавляются. Записи должны быть проверены
+// заранее.
+func (s *Storage) ImportSnapshot(ctx context.Context, snapshot models.Snapshot) error {
+	tx, err := s.beginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	orgID := tenant.MustOrgID(ctx)
+	batch := &pgx.Batch{}
+
+	if settings := snapshot.Settings; settings != nil {
+		batch.Queue(`
+			UPDATE organizations SET reviewers_per_pr = $2, assignment_strategy = $3
+			WHERE org_id = $1
+		`, orgID, settings.ReviewersPerPR, settings.AssignmentStrategy)
+	}
+
+	// Порядок важен для внешних ключей: команды, пользователи, PR, события
+	for _, team := range snapshot.Teams {
+		batch.Queue(`
+			INSERT INTO teams (org_id, team_name) VALUES ($1, $2)
+			ON CONFLICT (org_id, team_name) DO NOTHING
+		`, orgID, team.TeamName)
+	}
+	for _, user := range snapshot.Users {
+		batch.Queue(`
+			INSERT INTO users (org_id, user_id, username, team_name, is_active)
+			VALUES ($1, $2, $3, $4, $5)
+			ON CONFLICT (org_id, user_id) DO UPDATE SET
+				username = EXCLUDED.username,
+				team_name = EXCLUDED.team_name,
+				is_active = EXCLUDED.is_active
+		`, orgID, user.UserID, user.Username, user.TeamName, user.IsActive)
+	}
+	for _, pr := range snapshot.PullRequests {
+		batch.Queue(`
+			INSERT INTO pull_requests (
+				org_id, pull_request_id, pull_request_name, author_id, status,
+				reviewer1_id, reviewer2_id, version, created_at, merged_at
+			) VALUES ($1, $2, $3, $4, $5, $6, $7, 1, COALESCE($8, NOW()), $9)
+			ON CONFLICT (org_id, pull_request_id) DO UPDATE SET
+				pull_request_name = EXCLUDED.pull_request_name,
+				author_id = EXCLUDED.author_id,
+				status = EXCLUDED.status,
+				reviewer1_id = EXCLUDED.reviewer1_id,
+				reviewer2_id = EXCLUDED.reviewer2_id,
+				created_at = COALESCE($8, pull_requests.created_at),
+				merged_at = EXCLUDED.merged_at,
+				version = pull_requests.version + 1
+		`,
+			orgID,
+			pr.PullRequestID,
+			pr.PullRequestName,
+			pr.AuthorID,
+			pr.Status,
+			getReviewer(pr.AssignedReviewers, 0),
+			getReviewer(pr.AssignedReviewers, 1),
+			pr.CreatedAt,
+			pr.MergedAt,
+		)
+	}
+	for _, event := range snapshot.PREvents {
+		batch.Queue(`
+			INSERT INTO pr_events (
+				org_id, pull_request_id, event_type, reviewer_id, old_reviewer_id,
+				verdict, actor, reason, created_at
+			) VALUES (
+				$1, $2, $3, NULLIF($4, ''), NULLIF($5, ''),
+				NULLIF($6, ''), $7, NULLIF($8, ''), COALESCE($9, CURRENT_TIMESTAMP)
+			)
+		`,
+			orgID,
+			event.PullRequestID,
+			event.EventType,
+			event.ReviewerID,
+			event.OldReviewerID,
+			event.Verdict,
+			event.Actor,
+			event.Reason,
+			event.CreatedAt,
+		)
+	}
+
+	if err := tx.SendBatch(ctx, batch).Close(); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
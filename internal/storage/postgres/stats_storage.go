@@ -0,0 +1,211 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/Vimp17/pr-reviewer-service/internal/models"
+	"github.com/Vimp17/pr-reviewer-service/internal/tenant"
+	"github.com/jackc/pgx/v5"
+)
+
+// statsScope выбирает PR в охвате статистики вместе с командой автора.
+// Параметры: $1 — организация, $2 — команда (пустая строка — все), $3/$4 — период создания.
+const statsScope = `
+	WITH scoped AS (
+		SELECT p.*, COALESCE(a.team_name, '') AS author_team
+		FROM pull_requests p
+		LEFT JOIN users a ON a.org_id = p.org_id AND a.user_id = p.author_id
+		WHERE p.org_id = $1
+			AND ($2 = '' OR a.team_name = $2)
+			AND ($3::timestamptz IS NULL OR p.created_at >= $3)
+			AND ($4::timestamptz IS NULL OR p.created_at < $4)
+	)`
+
+// GetStats собирает статистику по PR в охвате фильтра. Ревьюеры включают всех
+// активных пользователей охвата, в том числе без назначений. Запросы выполняются
+// в одном снимке данных.
+func (s *Storage) GetStats(ctx context.Context, filter models.StatsFilter) (*models.Stats, error) {
+	tx, err := s.beginTx(ctx, pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	args := statsArgs(ctx, filter)
+	stats := &models.Stats{Filter: filter}
+
+	var merged int
+	err = tx.QueryRow(ctx, statsScope+`
+		SELECT
+			COUNT(*),
+			COUNT(*) FILTER (WHERE status = 'MERGED' AND merged_at IS NOT NULL),
+			percentile_cont(0.5) WITHIN GROUP (ORDER BY EXTRACT(EPOCH FROM merged_at - created_at)::DOUBLE PRECISION)
+				FILTER (WHERE status = 'MERGED' AND merged_at IS NOT NULL),
+			percentile_cont(0.9) WITHIN GROUP (ORDER BY EXTRACT(EPOCH FROM merged_at - created_at)::DOUBLE PRECISION)
+				FILTER (WHERE status = 'MERGED' AND merged_at IS NOT NULL),
+			percentile_cont(0.99) WITHIN GROUP (ORDER BY EXTRACT(EPOCH FROM merged_at - created_at)::DOUBLE PRECISION)
+				FILTER (WHERE status = 'MERGED' AND merged_at IS NOT NULL),
+			(SELECT COUNT(*)
+				FROM pr_events e
+				JOIN scoped sp ON sp.org_id = e.org_id AND sp.pull_request_id = e.pull_request_id
+				WHERE e.event_type = 'REASSIGNED')
+		FROM scoped
+	`, args...).Scan(
+		&stats.PullRequests,
+		&merged,
+		&stats.TimeToMerge.P50,
+		&stats.TimeToMerge.P90,
+		&stats.TimeToMerge.P99,
+		&stats.Reassignments,
+	)
+	if err != nil {
+		return nil, err
+	}
+	stats.TimeToMerge.Count = merged
+
+	stats.Reviewers = make([]models.ReviewerStats, 0)
+	err = reviewerStats(ctx, tx, args, func(r models.ReviewerStats) error {
+		stats.Reviewers = append(stats.Reviewers, r)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	stats.Teams = make([]models.TeamStats, 0)
+	err = teamStats(ctx, tx, args, func(t models.TeamStats) error {
+		stats.Teams = append(stats.Teams, t)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return stats, tx.Commit(ctx)
+}
+
+// StreamReviewerStats передает fn нагрузку ревьюеров в охвате фильтра по мере
+// чтения из курсора БД, в порядке и составе GetStats
+func (s *Storage) StreamReviewerStats(ctx context.Context, filter models.StatsFilter, fn func(models.ReviewerStats) error) error {
+	return reviewerStats(ctx, s.db(ctx), statsArgs(ctx, filter), fn)
+}
+
+// StreamTeamStats передает fn итоги по командам авторов в охвате фильтра по
+// мере чтения из курсора БД, в порядке и составе GetStats
+func (s *Storage) StreamTeamStats(ctx context.Context, filter models.StatsFilter, fn func(models.TeamStats) error) error {
+	return teamStats(ctx, s.db(ctx), statsArgs(ctx, filter), fn)
+}
+
+// statsArgs параметры statsScope
+func statsArgs(ctx context.Context, filter models.StatsFilter) []any {
+	return []any{tenant.MustOrgID(ctx), filter.TeamName, filter.From, filter.To}
+}
+
+// reviewerStats передает fn нагрузку ревьюеров, самых загруженных первыми
+func reviewerStats(ctx context.Context, q querier, args []any, fn func(models.ReviewerStats) error) error {
+	rows, err := q.Query(ctx, statsScope+`,
+	assignments AS (
+		SELECT reviewer1_id AS reviewer_id, status FROM scoped WHERE reviewer1_id IS NOT NULL
+		UNION ALL
+		SELECT reviewer2_id, status FROM scoped WHERE reviewer2_id IS NOT NULL
+	),
+	loads AS (
+		SELECT
+			reviewer_id,
+			COUNT(*) FILTER (WHERE status = 'OPEN') AS open_count,
+			COUNT(*) FILTER (WHERE status = 'MERGED') AS merged_count,
+			COUNT(*) AS total_count
+		FROM assignments
+		GROUP BY reviewer_id
+	),
+	candidates AS (
+		SELECT reviewer_id AS user_id FROM loads
+		UNION
+		SELECT user_id FROM users WHERE org_id = $1 AND is_active = true AND ($2 = '' OR team_name = $2)
+	)
+	SELECT
+		c.user_id,
+		COALESCE(u.team_name, ''),
+		COALESCE(l.open_count, 0),
+		COALESCE(l.merged_count, 0),
+		COALESCE(l.total_count, 0)
+	FROM candidates c
+	LEFT JOIN loads l ON l.reviewer_id = c.user_id
+	LEFT JOIN users u ON u.org_id = $1 AND u.user_id = c.user_id
+	ORDER BY COALESCE(l.total_count, 0) DESC, c.user_id
+	`, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var r models.ReviewerStats
+		if err := rows.Scan(&r.UserID, &r.TeamName, &r.Open, &r.Merged, &r.Total); err != nil {
+			return err
+		}
+		if err := fn(r); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// teamStats передает fn итоги по командам авторов PR
+func teamStats(ctx context.Context, q querier, args []any, fn func(models.TeamStats) error) error {
+	rows, err := q.Query(ctx, statsScope+`
+	SELECT
+		author_team,
+		COUNT(*),
+		COUNT(*) FILTER (WHERE status = 'OPEN'),
+		COUNT(*) FILTER (WHERE status = 'MERGED'),
+		COALESCE(SUM((reviewer1_id IS NOT NULL)::INT + (reviewer2_id IS NOT NULL)::INT), 0)
+	FROM scoped
+	GROUP BY author_team
+	ORDER BY author_team
+	`, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var t models.TeamStats
+		if err := rows.Scan(&t.TeamName, &t.PullRequests, &t.Open, &t.Merged, &t.Assignments); err != nil {
+			return err
+		}
+		if err := fn(t); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// GetOpenReviewLoad возвращает число открытых PR у каждого ревьюера всех
+// организаций. Используется для метрик, поэтому не привязан к организации вызывающего.
+func (s *Storage) GetOpenReviewLoad(ctx context.Context) ([]models.ReviewLoad, error) {
+	rows, err := s.db(ctx).Query(ctx, `
+		SELECT p.org_id, r.reviewer_id, COALESCE(u.team_name, ''), COUNT(*)
+		FROM pull_requests p
+		CROSS JOIN LATERAL (VALUES (p.reviewer1_id), (p.reviewer2_id)) AS r(reviewer_id)
+		LEFT JOIN users u ON u.org_id = p.org_id AND u.user_id = r.reviewer_id
+		WHERE p.status = 'OPEN' AND r.reviewer_id IS NOT NULL
+		GROUP BY p.org_id, r.reviewer_id, u.team_name
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	loads := make([]models.ReviewLoad, 0)
+	for rows.Next() {
+		var l models.ReviewLoad
+		if err := rows.Scan(&l.OrgID, &l.UserID, &l.TeamName, &l.Open); err != nil {
+			return nil, err
+		}
+		loads = append(loads, l)
+	}
+
+	return loads, rows.Err()
+}
@@ -2,9 +2,12 @@ package postgres
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 	_ "github.com/jackc/pgx/v5/stdlib" // Регистрируем драйвер для database/sql
 )
@@ -14,18 +17,30 @@ type Storage struct {
 	pool *pgxpool.Pool
 }
 
-// NewStorage создает новое хранилище с указанным DSN
-func NewStorage(ctx context.Context, dsn string) (*Storage, error) {
+// PoolConfig параметры пула соединений (значения по умолчанию — в config.Default)
+type PoolConfig struct {
+	MaxConns          int32
+	MinConns          int32
+	MaxConnLifetime   time.Duration
+	MaxConnIdleTime   time.Duration
+	HealthCheckPeriod time.Duration
+}
+
+// NewStorage создает новое хранилище с указанным DSN и параметрами пула
+func NewStorage(ctx context.Context, dsn string, cfg PoolConfig) (*Storage, error) {
 	poolConfig, err := pgxpool.ParseConfig(dsn)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse config: %w", err)
 	}
 
 	// Настраиваем параметры пула
-	poolConfig.MaxConns = 25
-	poolConfig.HealthCheckPeriod = 5 * time.Minute
-	poolConfig.MaxConnLifetime = 1 * time.Hour
-	poolConfig.MaxConnIdleTime = 30 * time.Minute
+	poolConfig.MaxConns = cfg.MaxConns
+	poolConfig.MinConns = cfg.MinConns
+	poolConfig.HealthCheckPeriod = cfg.HealthCheckPeriod
+	poolConfig.MaxConnLifetime = cfg.MaxConnLifetime
+	poolConfig.MaxConnIdleTime = cfg.MaxConnIdleTime
+	// Каждый запрос получает спан трассировки и пишется в журнал (ошибки — всегда)
+	poolConfig.ConnConfig.Tracer = queryTracers{spanTracer{}, newQueryLogger()}
 
 	pool, err := pgxpool.NewWithConfig(ctx, poolConfig)
 	if err != nil {
@@ -41,7 +56,68 @@ func NewStorage(ctx context.Context, dsn string) (*Storage, error) {
 	return &Storage{pool: pool}, nil
 }
 
+// PoolStat возвращает текущую статистику пула соединений
+func (s *Storage) PoolStat() *pgxpool.Stat {
+	return s.pool.Stat()
+}
+
+// Ping проверяет доступность БД через пул соединений
+func (s *Storage) Ping(ctx context.Context) error {
+	return s.pool.Ping(ctx)
+}
+
 // Close закрывает соединение с базой данных
 func (s *Storage) Close() {
 	s.pool.Close()
 }
+
+// txKey ключ контекста с транзакцией InTx
+type txKey struct{}
+
+// querier выполняет запросы в пуле или в транзакции
+type querier interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// InTx выполняет fn в одной транзакции: методы хранилища, вызванные с
+// контекстом fn, работают в ней, а собственные транзакции методов становятся
+// точками сохранения. Транзакция фиксируется, только если fn не вернула ошибку.
+func (s *Storage) InTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	tx, err := s.beginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := fn(context.WithValue(ctx, txKey{}, tx)); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// db возвращает транзакцию InTx из контекста или пул
+func (s *Storage) db(ctx context.Context) querier {
+	if tx, ok := ctx.Value(txKey{}).(pgx.Tx); ok {
+		return tx
+	}
+	return s.pool
+}
+
+// beginTx начинает транзакцию; внутри InTx — точку сохранения в ее транзакции
+func (s *Storage) beginTx(ctx context.Context, opts pgx.TxOptions) (pgx.Tx, error) {
+	if tx, ok := ctx.Value(txKey{}).(pgx.Tx); ok {
+		return tx.Begin(ctx)
+	}
+	return s.pool.BeginTx(ctx, opts)
+}
+
+// uniqueViolation код ошибки PostgreSQL при нарушении уникальности
+const uniqueViolation = "23505"
+
+// isUniqueViolation сообщает, что запрос нарушил ограничение уникальности
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == uniqueViolation
+}
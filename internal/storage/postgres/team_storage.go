@@ -2,41 +2,43 @@ package postgres
 
 import (
 	"context"
-	"errors"
 
 	"github.com/Vimp17/pr-reviewer-service/internal/models"
+	"github.com/Vimp17/pr-reviewer-service/internal/tenant"
 	"github.com/jackc/pgx/v5"
 )
 
 // CheckTeamExists проверяет существование команды
 func (s *Storage) CheckTeamExists(ctx context.Context, teamName string) (bool, error) {
 	var exists bool
-	err := s.pool.QueryRow(ctx, `
-		SELECT EXISTS(SELECT 1 FROM teams WHERE team_name = $1)
-	`, teamName).Scan(&exists)
+	err := s.db(ctx).QueryRow(ctx, `
+		SELECT EXISTS(SELECT 1 FROM teams WHERE org_id = $1 AND team_name = $2)
+	`, tenant.MustOrgID(ctx), teamName).Scan(&exists)
 	return exists, err
 }
 
 // CreateTeam создает новую команду с участниками
 func (s *Storage) CreateTeam(ctx context.Context, teamName string, members []models.User) error {
+	orgID := tenant.MustOrgID(ctx)
+
 	// Проверяем существование команды
 	exists, err := s.CheckTeamExists(ctx, teamName)
 	if err != nil {
 		return err
 	}
 	if exists {
-		return errors.New("TEAM_EXISTS")
+		return ErrAlreadyExists
 	}
 
 	// Начинаем транзакцию
-	tx, err := s.pool.BeginTx(ctx, pgx.TxOptions{})
+	tx, err := s.beginTx(ctx, pgx.TxOptions{})
 	if err != nil {
 		return err
 	}
 	defer tx.Rollback(ctx)
 
 	// Создаем команду
-	if _, err := tx.Exec(ctx, "INSERT INTO teams (team_name) VALUES ($1)", teamName); err != nil {
+	if _, err := tx.Exec(ctx, "INSERT INTO teams (org_id, team_name) VALUES ($1, $2)", orgID, teamName); err != nil {
 		return err
 	}
 
@@ -44,11 +46,11 @@ func (s *Storage) CreateTeam(ctx context.Context, teamName string, members []mod
 	for _, member := range members {
 		// Обновляем или создаем пользователя
 		_, err := tx.Exec(ctx, `
-			INSERT INTO users (user_id, username, team_name, is_active)
-			VALUES ($1, $2, $3, $4)
-			ON CONFLICT (user_id) 
+			INSERT INTO users (org_id, user_id, username, team_name, is_active)
+			VALUES ($1, $2, $3, $4, $5)
+			ON CONFLICT (org_id, user_id) 
 			DO UPDATE SET team_name = EXCLUDED.team_name, is_active = EXCLUDED.is_active
-		`, member.UserID, member.Username, teamName, member.IsActive)
+		`, orgID, member.UserID, member.Username, teamName, member.IsActive)
 
 		if err != nil {
 			return err
@@ -67,21 +69,21 @@ func (s *Storage) GetTeam(ctx context.Context, teamName string) (*models.Team, e
 		return nil, err
 	}
 	if !exists {
-		return nil, errors.New("NOT_FOUND")
+		return nil, ErrNotFound
 	}
 
 	// Получаем участников
-	rows, err := s.pool.Query(ctx, `
+	rows, err := s.db(ctx).Query(ctx, `
 		SELECT user_id, username, is_active 
 		FROM users 
-		WHERE team_name = $1
-	`, teamName)
+		WHERE org_id = $1 AND team_name = $2
+	`, tenant.MustOrgID(ctx), teamName)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var members []models.User
+	members := make([]models.User, 0)
 	for rows.Next() {
 		var user models.User
 		if err := rows.Scan(&user.UserID, &user.Username, &user.IsActive); err != nil {
@@ -99,3 +101,67 @@ func (s *Storage) GetTeam(ctx context.Context, teamName string) (*models.Team, e
 		Members:  members,
 	}, nil
 }
+
+// ListTeams возвращает все команды организации с участниками
+func (s *Storage) ListTeams(ctx context.Context) ([]models.Team, error) {
+	rows, err := s.db(ctx).Query(ctx, `
+		SELECT t.team_name, u.user_id, u.username, u.is_active
+		FROM teams t
+		LEFT JOIN users u ON u.org_id = t.org_id AND u.team_name = t.team_name
+		WHERE t.org_id = $1
+		ORDER BY t.team_name, u.user_id
+	`, tenant.MustOrgID(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanTeams(rows)
+}
+
+// GetTeamsByNames возвращает команды с участниками одним запросом;
+// несуществующие имена пропускаются
+func (s *Storage) GetTeamsByNames(ctx context.Context, teamNames []string) ([]models.Team, error) {
+	rows, err := s.db(ctx).Query(ctx, `
+		SELECT t.team_name, u.user_id, u.username, u.is_active
+		FROM teams t
+		LEFT JOIN users u ON u.org_id = t.org_id AND u.team_name = t.team_name
+		WHERE t.org_id = $1 AND t.team_name = ANY($2)
+		ORDER BY t.team_name, u.user_id
+	`, tenant.MustOrgID(ctx), teamNames)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanTeams(rows)
+}
+
+// scanTeams собирает команды из строк (team_name, user_id, username, is_active),
+// упорядоченных по team_name
+func scanTeams(rows pgx.Rows) ([]models.Team, error) {
+	teams := make([]models.Team, 0)
+	for rows.Next() {
+		var teamName string
+		var userID, username *string
+		var isActive *bool
+		if err := rows.Scan(&teamName, &userID, &username, &isActive); err != nil {
+			return nil, err
+		}
+
+		if len(teams) == 0 || teams[len(teams)-1].TeamName != teamName {
+			teams = append(teams, models.Team{TeamName: teamName, Members: []models.User{}})
+		}
+		if userID != nil {
+			team := &teams[len(teams)-1]
+			team.Members = append(team.Members, models.User{
+				UserID:   *userID,
+				Username: *username,
+				TeamName: teamName,
+				IsActive: *isActive,
+			})
+		}
+	}
+
+	return teams, rows.Err()
+}
@@ -0,0 +1,86 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Vimp17/pr-reviewer-service/internal/models"
+	"github.com/Vimp17/pr-reviewer-service/internal/tenant"
+	"github.com/jackc/pgx/v5"
+)
+
+// CreateAPIToken сохраняет хэш нового API-токена в организации из контекста
+func (s *Storage) CreateAPIToken(ctx context.Context, tokenHash string, token models.APIToken) (*models.APIToken, error) {
+	err := s.db(ctx).QueryRow(ctx, `
+		INSERT INTO api_tokens (token_hash, org_id, name, user_id, role)
+		VALUES ($1, $2, $3, NULLIF($4, ''), $5)
+		RETURNING token_id, created_at
+	`, tokenHash, tenant.MustOrgID(ctx), token.Name, token.UserID, token.Role).Scan(
+		&token.TokenID,
+		&token.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// EnsureAPIToken сохраняет токен, если токена с таким хэшем еще нет
+func (s *Storage) EnsureAPIToken(ctx context.Context, tokenHash string, token models.APIToken) error {
+	_, err := s.db(ctx).Exec(ctx, `
+		INSERT INTO api_tokens (token_hash, org_id, name, user_id, role)
+		VALUES ($1, $2, $3, NULLIF($4, ''), $5)
+		ON CONFLICT (token_hash) DO NOTHING
+	`, tokenHash, tenant.MustOrgID(ctx), token.Name, token.UserID, token.Role)
+	return err
+}
+
+// GetAPITokenByHash находит действующий токен по хэшу и возвращает его вместе с организацией.
+// Поиск выполняется без фильтра по организации: она определяется самим токеном.
+func (s *Storage) GetAPITokenByHash(ctx context.Context, tokenHash string) (*models.APIToken, string, error) {
+	var token models.APIToken
+	var orgID string
+	var userID *string
+
+	err := s.db(ctx).QueryRow(ctx, `
+		SELECT token_id, org_id, name, user_id, role, created_at
+		FROM api_tokens
+		WHERE token_hash = $1 AND revoked_at IS NULL
+	`, tokenHash).Scan(
+		&token.TokenID,
+		&orgID,
+		&token.Name,
+		&userID,
+		&token.Role,
+		&token.CreatedAt,
+	)
+
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, "", ErrNotFound
+		}
+		return nil, "", err
+	}
+
+	if userID != nil {
+		token.UserID = *userID
+	}
+
+	return &token, orgID, nil
+}
+
+// RevokeAPIToken отзывает токен организации из контекста
+func (s *Storage) RevokeAPIToken(ctx context.Context, tokenID int64) error {
+	tag, err := s.db(ctx).Exec(ctx, `
+		UPDATE api_tokens
+		SET revoked_at = NOW()
+		WHERE org_id = $1 AND token_id = $2 AND revoked_at IS NULL
+	`, tenant.MustOrgID(ctx), tokenID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
@@ -3,29 +3,32 @@ package postgres
 import (
 	"context"
 	"errors"
+	"fmt"
+	"time"
 
 	"github.com/Vimp17/pr-reviewer-service/internal/models"
+	"github.com/Vimp17/pr-reviewer-service/internal/tenant"
 	"github.com/jackc/pgx/v5"
 )
 
 // UpdateUserActiveStatus обновляет статус активности пользователя
 func (s *Storage) UpdateUserActiveStatus(ctx context.Context, userID string, isActive bool) (*models.User, error) {
-	_, err := s.pool.Exec(ctx, `
+	_, err := s.db(ctx).Exec(ctx, `
 		UPDATE users 
 		SET is_active = $1 
-		WHERE user_id = $2
-	`, isActive, userID)
+		WHERE org_id = $2 AND user_id = $3
+	`, isActive, tenant.MustOrgID(ctx), userID)
 	if err != nil {
 		return nil, err
 	}
 
 	// Получаем обновленного пользователя
 	var user models.User
-	err = s.pool.QueryRow(ctx, `
+	err = s.db(ctx).QueryRow(ctx, `
 		SELECT user_id, username, team_name, is_active
 		FROM users
-		WHERE user_id = $1
-	`, userID).Scan(&user.UserID, &user.Username, &user.TeamName, &user.IsActive)
+		WHERE org_id = $1 AND user_id = $2
+	`, tenant.MustOrgID(ctx), userID).Scan(&user.UserID, &user.Username, &user.TeamName, &user.IsActive)
 
 	if err != nil {
 		return nil, err
@@ -37,11 +40,11 @@ func (s *Storage) UpdateUserActiveStatus(ctx context.Context, userID string, isA
 // GetUser получает пользователя по ID
 func (s *Storage) GetUser(ctx context.Context, userID string) (*models.User, error) {
 	var user models.User
-	err := s.pool.QueryRow(ctx, `
+	err := s.db(ctx).QueryRow(ctx, `
 		SELECT user_id, username, team_name, is_active
 		FROM users
-		WHERE user_id = $1
-	`, userID).Scan(&user.UserID, &user.Username, &user.TeamName, &user.IsActive)
+		WHERE org_id = $1 AND user_id = $2
+	`, tenant.MustOrgID(ctx), userID).Scan(&user.UserID, &user.Username, &user.TeamName, &user.IsActive)
 
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
@@ -53,13 +56,38 @@ func (s *Storage) GetUser(ctx context.Context, userID string) (*models.User, err
 	return &user, nil
 }
 
+// GetUsersByIDs возвращает пользователей по списку ID одним запросом;
+// несуществующие ID пропускаются
+func (s *Storage) GetUsersByIDs(ctx context.Context, userIDs []string) ([]models.User, error) {
+	rows, err := s.db(ctx).Query(ctx, `
+		SELECT user_id, username, team_name, is_active
+		FROM users
+		WHERE org_id = $1 AND user_id = ANY($2)
+	`, tenant.MustOrgID(ctx), userIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := make([]models.User, 0, len(userIDs))
+	for rows.Next() {
+		var user models.User
+		if err := rows.Scan(&user.UserID, &user.Username, &user.TeamName, &user.IsActive); err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+
+	return users, rows.Err()
+}
+
 // GetActiveTeamMembers возвращает активных членов команды, исключая указанного пользователя
 func (s *Storage) GetActiveTeamMembers(ctx context.Context, teamName, excludeUserID string) ([]string, error) {
-	rows, err := s.pool.Query(ctx, `
+	rows, err := s.db(ctx).Query(ctx, `
 		SELECT user_id
 		FROM users
-		WHERE team_name = $1 AND is_active = true AND user_id != $2
-	`, teamName, excludeUserID)
+		WHERE org_id = $1 AND team_name = $2 AND is_active = true AND user_id != $3
+	`, tenant.MustOrgID(ctx), teamName, excludeUserID)
 	if err != nil {
 		return nil, err
 	}
@@ -77,31 +105,62 @@ func (s *Storage) GetActiveTeamMembers(ctx context.Context, teamName, excludeUse
 	return users, rows.Err()
 }
 
-// GetPRsForReviewer возвращает PR, где пользователь назначен ревьювером
-func (s *Storage) GetPRsForReviewer(ctx context.Context, userID string) ([]models.PullRequestShort, error) {
-	rows, err := s.pool.Query(ctx, `
-		SELECT 
-			pull_request_id, 
-			pull_request_name, 
-			author_id, 
-			status
-		FROM pull_requests
-		WHERE reviewer1_id = $1 OR reviewer2_id = $1
-		AND status = 'OPEN'
-	`, userID)
+// GetPRsForReviewer возвращает PR с указанными статусами, где пользователь
+// назначен ревьювером, с возрастом PR и последним вердиктом ревьювера (новые первыми)
+func (s *Storage) GetPRsForReviewer(ctx context.Context, filter models.ReviewFilter) ([]models.ReviewerPR, error) {
+	args := []any{tenant.MustOrgID(ctx), filter.ReviewerID, filter.Statuses}
+	keyset := ""
+	if filter.After != nil {
+		after, err := time.Parse(time.RFC3339Nano, filter.After.Value)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, after, filter.After.ID)
+		keyset = "AND (p.created_at, p.pull_request_id) < ($4, $5)"
+	}
+	args = append(args, filter.Limit)
+
+	rows, err := s.db(ctx).Query(ctx, fmt.Sprintf(`
+		SELECT
+			p.pull_request_id,
+			p.pull_request_name,
+			p.author_id,
+			p.status,
+			p.created_at,
+			EXTRACT(EPOCH FROM COALESCE(p.merged_at, CURRENT_TIMESTAMP) - p.created_at)::BIGINT,
+			COALESCE(v.verdict, '')
+		FROM pull_requests p
+		LEFT JOIN LATERAL (
+			SELECT e.verdict
+			FROM pr_events e
+			WHERE e.org_id = p.org_id AND e.pull_request_id = p.pull_request_id
+				AND e.event_type = 'VERDICT' AND e.reviewer_id = $2
+			ORDER BY e.created_at DESC, e.id DESC
+			LIMIT 1
+		) v ON TRUE
+		WHERE p.org_id = $1
+			AND (p.reviewer1_id = $2 OR p.reviewer2_id = $2)
+			AND p.status = ANY($3)
+			%s
+		ORDER BY p.created_at DESC, p.pull_request_id DESC
+		LIMIT $%d
+	`, keyset, len(args)), args...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var prs []models.PullRequestShort
+	prs := make([]models.ReviewerPR, 0, filter.Limit)
 	for rows.Next() {
-		var pr models.PullRequestShort
+		var pr models.ReviewerPR
 		if err := rows.Scan(
 			&pr.PullRequestID,
 			&pr.PullRequestName,
 			&pr.AuthorID,
 			&pr.Status,
+			&pr.CreatedAt,
+			&pr.AgeSeconds,
+			&pr.Verdict,
 		); err != nil {
 			return nil, err
 		}
@@ -0,0 +1,161 @@
+package postgres_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/Vimp17/pr-reviewer-service/internal/models"
+	"github.com/Vimp17/pr-reviewer-service/internal/services"
+	"github.com/Vimp17/pr-reviewer-service/internal/storage/postgres"
+	"github.com/Vimp17/pr-reviewer-service/internal/testdb"
+)
+
+// seedReviews создает PR с заданными датами: три открытых с одинаковым
+// created_at, два слитых и PR, где u2 автор или не участвует
+func seedReviews(t *testing.T, ctx context.Context, storage *postgres.Storage, t0 time.Time) {
+	t.Helper()
+	at := func(d time.Duration) *time.Time {
+		v := t0.Add(d)
+		return &v
+	}
+	pr := func(id, status, author string, created, merged *time.Time, reviewers ...string) models.PullRequest {
+		return models.PullRequest{
+			PullRequestID:     id,
+			PullRequestName:   "PR " + id,
+			AuthorID:          author,
+			Status:            status,
+			AssignedReviewers: reviewers,
+			CreatedAt:         created,
+			MergedAt:          merged,
+		}
+	}
+
+	snapshot := models.Snapshot{Teams: []models.SnapshotTeam{{TeamName: "backend"}}}
+	for i := 1; i <= 4; i++ {
+		snapshot.Users = append(snapshot.Users, models.User{
+			UserID: fmt.Sprintf("u%d", i), Username: fmt.Sprintf("User %d", i), TeamName: "backend", IsActive: true,
+		})
+	}
+	snapshot.PullRequests = []models.PullRequest{
+		pr("pr-a", "OPEN", "u1", at(0), nil, "u2", "u3"),
+		pr("pr-b", "OPEN", "u1", at(0), nil, "u3", "u2"),
+		pr("pr-c", "OPEN", "u1", at(0), nil, "u2"),
+		pr("pr-d", "MERGED", "u1", at(-time.Hour), at(0), "u2", "u3"),
+		pr("pr-e", "MERGED", "u1", at(-2*time.Hour), at(-time.Hour), "u3", "u2"),
+		pr("pr-f", "OPEN", "u1", at(time.Hour), nil, "u3", "u4"),
+		pr("pr-g", "OPEN", "u2", at(time.Hour), nil, "u3"),
+	}
+	if err := storage.ImportSnapshot(ctx, snapshot); err != nil {
+		t.Fatal(err)
+	}
+
+	// У u2 в pr-a последний вердикт CHANGES_REQUESTED; вердикт u3 в pr-b не
+	// относится к u2
+	for _, v := range []struct{ pr, reviewer, verdict string }{
+		{"pr-a", "u2", models.VerdictApproved},
+		{"pr-a", "u2", models.VerdictChangesRequested},
+		{"pr-a", "u3", models.VerdictApproved},
+		{"pr-b", "u3", models.VerdictApproved},
+	} {
+		err := storage.AddPREvent(ctx, models.PREvent{
+			PullRequestID: v.pr,
+			EventType:     models.PREventVerdict,
+			ReviewerID:    v.reviewer,
+			Verdict:       v.verdict,
+			Actor:         v.reviewer,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func reviewIDs(prs []models.ReviewerPR) []string {
+	ids := make([]string, 0, len(prs))
+	for _, pr := range prs {
+		ids = append(ids, pr.PullRequestID)
+	}
+	return ids
+}
+
+func TestGetPRsForReviewer(t *testing.T) {
+	storage := testdb.Open(t)
+	orgID := testdb.NewOrg(t, storage, "reviews")
+	ctx := testdb.AdminContext(orgID)
+	t0 := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	seedReviews(t, ctx, storage, t0)
+
+	both := []string{"OPEN", "MERGED"}
+	tests := []struct {
+		name     string
+		statuses []string
+		want     []string
+	}{
+		// Слитый pr-d, где u2 — первый ревьюер, не попадает в открытые
+		{"open", []string{"OPEN"}, []string{"pr-c", "pr-b", "pr-a"}},
+		{"merged", []string{"MERGED"}, []string{"pr-d", "pr-e"}},
+		{"all", both, []string{"pr-c", "pr-b", "pr-a", "pr-d", "pr-e"}},
+	}
+	for _, tt := range tests {
+		prs, err := storage.GetPRsForReviewer(ctx, models.ReviewFilter{ReviewerID: "u2", Statuses: tt.statuses, Limit: 10})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := fmt.Sprint(reviewIDs(prs)); got != fmt.Sprint(tt.want) {
+			t.Errorf("%s: %s, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	// По умолчанию сервис показывает только открытые PR
+	prs, _, err := services.NewUserService(storage).GetPRsForReviewer(ctx, models.ReviewFilter{ReviewerID: "u2"}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := fmt.Sprint(reviewIDs(prs)); got != "[pr-c pr-b pr-a]" {
+		t.Errorf("default statuses: %s", got)
+	}
+
+	// Страницы по два: ключ (created_at, pull_request_id) не теряет и не
+	// повторяет PR с одинаковым created_at
+	var (
+		pages [][]string
+		after *models.ListCursor
+	)
+	for len(pages) < 5 {
+		page, err := storage.GetPRsForReviewer(ctx, models.ReviewFilter{ReviewerID: "u2", Statuses: both, After: after, Limit: 2})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(page) == 0 {
+			break
+		}
+		pages = append(pages, reviewIDs(page))
+		last := page[len(page)-1]
+		after = &models.ListCursor{Value: last.CreatedAt.Format(time.RFC3339Nano), ID: last.PullRequestID}
+	}
+	if got := fmt.Sprint(pages); got != "[[pr-c pr-b] [pr-a pr-d] [pr-e]]" {
+		t.Errorf("pages %s", got)
+	}
+
+	prs, err = storage.GetPRsForReviewer(ctx, models.ReviewFilter{ReviewerID: "u2", Statuses: both, Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, pr := range prs {
+		want := ""
+		if pr.PullRequestID == "pr-a" {
+			want = models.VerdictChangesRequested
+		}
+		if pr.Verdict != want {
+			t.Errorf("%s: verdict %q, want %q", pr.PullRequestID, pr.Verdict, want)
+		}
+		if !pr.CreatedAt.Equal(t0) && pr.Status == "OPEN" {
+			t.Errorf("%s: created_at %v, want %v", pr.PullRequestID, pr.CreatedAt, t0)
+		}
+		if pr.PullRequestID == "pr-d" && pr.AgeSeconds != int64(time.Hour/time.Second) {
+			t.Errorf("pr-d: age %d, want time to merge", pr.AgeSeconds)
+		}
+	}
+}
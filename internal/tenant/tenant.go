@@ -0,0 +1,31 @@
+package tenant
+
+import "context"
+
+// DefaultOrgID идентификатор организации по умолчанию, создаваемой миграцией
+const DefaultOrgID = "default"
+
+type ctxKey struct{}
+
+// WithOrgID возвращает контекст, привязанный к указанной организации
+func WithOrgID(ctx context.Context, orgID string) context.Context {
+	return context.WithValue(ctx, ctxKey{}, orgID)
+}
+
+// FromContext возвращает идентификатор организации из контекста
+func FromContext(ctx context.Context) (string, bool) {
+	orgID, ok := ctx.Value(ctxKey{}).(string)
+	return orgID, ok && orgID != ""
+}
+
+// MustOrgID возвращает идентификатор организации из контекста. Организацию
+// задают аутентификация HTTP и gRPC, а внутренние вызовы — вместе с
+// auth.SystemIdentity; контекст без нее — ошибка программы, поэтому вызов
+// паникует, а не обращается к данным организации по умолчанию.
+func MustOrgID(ctx context.Context) string {
+	orgID, ok := FromContext(ctx)
+	if !ok {
+		panic("tenant: context has no organization")
+	}
+	return orgID
+}
@@ -0,0 +1,75 @@
+-- +goose Up
+-- SQL in this section is executed when the migration is applied.
+
+CREATE TABLE organizations (
+    org_id VARCHAR(255) PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    reviewers_per_pr INTEGER NOT NULL DEFAULT 2 CHECK (reviewers_per_pr BETWEEN 0 AND 2),
+    assignment_strategy VARCHAR(50) NOT NULL DEFAULT 'random',
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT INTO organizations (org_id, name) VALUES ('default', 'Default organization');
+
+-- Команды: имя уникально только в пределах организации
+ALTER TABLE users DROP CONSTRAINT users_team_name_fkey;
+ALTER TABLE teams ADD COLUMN org_id VARCHAR(255) NOT NULL DEFAULT 'default' REFERENCES organizations(org_id);
+ALTER TABLE teams ALTER COLUMN org_id DROP DEFAULT;
+ALTER TABLE teams DROP CONSTRAINT teams_team_name_key;
+ALTER TABLE teams ADD CONSTRAINT teams_org_team_name_key UNIQUE (org_id, team_name);
+
+-- Пользователи
+ALTER TABLE users ADD COLUMN org_id VARCHAR(255) NOT NULL DEFAULT 'default' REFERENCES organizations(org_id);
+ALTER TABLE users ALTER COLUMN org_id DROP DEFAULT;
+ALTER TABLE users DROP CONSTRAINT users_pkey;
+ALTER TABLE users ADD PRIMARY KEY (org_id, user_id);
+ALTER TABLE users ADD CONSTRAINT users_org_team_fkey
+    FOREIGN KEY (org_id, team_name) REFERENCES teams(org_id, team_name) ON DELETE CASCADE;
+
+DROP INDEX idx_users_team_name;
+CREATE INDEX idx_users_org_team_name ON users(org_id, team_name);
+
+-- Pull Requests
+ALTER TABLE pull_requests ADD COLUMN org_id VARCHAR(255) NOT NULL DEFAULT 'default' REFERENCES organizations(org_id);
+ALTER TABLE pull_requests ALTER COLUMN org_id DROP DEFAULT;
+ALTER TABLE pull_requests DROP CONSTRAINT pull_requests_pkey;
+ALTER TABLE pull_requests ADD PRIMARY KEY (org_id, pull_request_id);
+
+DROP INDEX idx_pull_requests_status;
+DROP INDEX idx_pull_requests_author;
+DROP INDEX idx_pull_requests_reviewer1;
+DROP INDEX idx_pull_requests_reviewer2;
+CREATE INDEX idx_pull_requests_org_status ON pull_requests(org_id, status);
+CREATE INDEX idx_pull_requests_org_author ON pull_requests(org_id, author_id);
+CREATE INDEX idx_pull_requests_org_reviewer1 ON pull_requests(org_id, reviewer1_id);
+CREATE INDEX idx_pull_requests_org_reviewer2 ON pull_requests(org_id, reviewer2_id);
+
+-- +goose Down
+-- SQL in this section is executed when the migration is rolled back.
+
+DROP INDEX idx_pull_requests_org_status;
+DROP INDEX idx_pull_requests_org_author;
+DROP INDEX idx_pull_requests_org_reviewer1;
+DROP INDEX idx_pull_requests_org_reviewer2;
+CREATE INDEX idx_pull_requests_status ON pull_requests(status);
+CREATE INDEX idx_pull_requests_author ON pull_requests(author_id);
+CREATE INDEX idx_pull_requests_reviewer1 ON pull_requests(reviewer1_id);
+CREATE INDEX idx_pull_requests_reviewer2 ON pull_requests(reviewer2_id);
+ALTER TABLE pull_requests DROP CONSTRAINT pull_requests_pkey;
+ALTER TABLE pull_requests ADD PRIMARY KEY (pull_request_id);
+ALTER TABLE pull_requests DROP COLUMN org_id;
+
+DROP INDEX idx_users_org_team_name;
+CREATE INDEX idx_users_team_name ON users(team_name);
+ALTER TABLE users DROP CONSTRAINT users_org_team_fkey;
+ALTER TABLE users DROP CONSTRAINT users_pkey;
+ALTER TABLE users ADD PRIMARY KEY (user_id);
+ALTER TABLE users DROP COLUMN org_id;
+
+ALTER TABLE teams DROP CONSTRAINT teams_org_team_name_key;
+ALTER TABLE teams ADD CONSTRAINT teams_team_name_key UNIQUE (team_name);
+ALTER TABLE teams DROP COLUMN org_id;
+ALTER TABLE users ADD CONSTRAINT users_team_name_fkey
+    FOREIGN KEY (team_name) REFERENCES teams(team_name) ON DELETE CASCADE;
+
+DROP TABLE organizations;
@@ -0,0 +1,20 @@
+-- +goose Up
+-- SQL in this section is executed when the migration is applied.
+
+CREATE TABLE api_tokens (
+    token_id BIGSERIAL PRIMARY KEY,
+    token_hash CHAR(64) NOT NULL UNIQUE,
+    org_id VARCHAR(255) NOT NULL REFERENCES organizations(org_id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    user_id VARCHAR(255),
+    role VARCHAR(50) NOT NULL CHECK (role IN ('admin', 'team-lead', 'member', 'bot')),
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    revoked_at TIMESTAMPTZ
+);
+
+CREATE INDEX idx_api_tokens_org ON api_tokens(org_id);
+
+-- +goose Down
+-- SQL in this section is executed when the migration is rolled back.
+
+DROP TABLE api_tokens;
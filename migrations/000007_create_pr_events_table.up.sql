@@ -0,0 +1,24 @@
+-- +goose Up
+-- SQL in this section is executed when the migration is applied.
+
+CREATE TABLE pr_events (
+    id BIGSERIAL PRIMARY KEY,
+    org_id VARCHAR(255) NOT NULL,
+    pull_request_id VARCHAR(255) NOT NULL,
+    event_type VARCHAR(50) NOT NULL CHECK (event_type IN ('CREATED', 'ASSIGNED', 'REASSIGNED', 'VERDICT', 'MERGED')),
+    reviewer_id VARCHAR(255),
+    old_reviewer_id VARCHAR(255),
+    verdict VARCHAR(50) CHECK (verdict IN ('APPROVED', 'CHANGES_REQUESTED')),
+    actor VARCHAR(255) NOT NULL,
+    reason TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (org_id, pull_request_id) REFERENCES pull_requests(org_id, pull_request_id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_pr_events_pr ON pr_events(org_id, pull_request_id, created_at, id);
+CREATE INDEX idx_pr_events_reviewer ON pr_events(org_id, reviewer_id, event_type);
+
+-- +goose Down
+-- SQL in this section is executed when the migration is rolled back.
+
+DROP TABLE pr_events;
@@ -0,0 +1,29 @@
+-- +goose Up
+-- SQL in this section is executed when the migration is applied.
+
+-- Курсорная пагинация требует непустого ключа сортировки
+UPDATE pull_requests SET created_at = CURRENT_TIMESTAMP WHERE created_at IS NULL;
+ALTER TABLE pull_requests ALTER COLUMN created_at SET NOT NULL;
+
+CREATE INDEX idx_pull_requests_org_created ON pull_requests(org_id, created_at, pull_request_id);
+CREATE INDEX idx_pull_requests_org_name ON pull_requests(org_id, pull_request_name, pull_request_id);
+CREATE INDEX idx_pull_requests_org_merged ON pull_requests(org_id, merged_at) WHERE merged_at IS NOT NULL;
+CREATE INDEX idx_users_org_username ON users(org_id, username, user_id);
+
+-- Поиск подстроки (ILIKE '%...%')
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+CREATE INDEX idx_pull_requests_name_trgm ON pull_requests USING gin (pull_request_name gin_trgm_ops);
+CREATE INDEX idx_users_username_trgm ON users USING gin (username gin_trgm_ops);
+CREATE INDEX idx_users_user_id_trgm ON users USING gin (user_id gin_trgm_ops);
+
+-- +goose Down
+-- SQL in this section is executed when the migration is rolled back.
+
+DROP INDEX idx_users_user_id_trgm;
+DROP INDEX idx_users_username_trgm;
+DROP INDEX idx_pull_requests_name_trgm;
+DROP INDEX idx_users_org_username;
+DROP INDEX idx_pull_requests_org_merged;
+DROP INDEX idx_pull_requests_org_name;
+DROP INDEX idx_pull_requests_org_created;
+ALTER TABLE pull_requests ALTER COLUMN created_at DROP NOT NULL;
@@ -0,0 +1,37 @@
+-- +goose Up
+-- SQL in this section is executed when the migration is applied.
+
+-- Таблица из первой миграции не используется: ревьюеры — это пользователи команд
+DROP TABLE reviewers;
+
+-- Ссылки PR на пользователей и допустимые статусы. Ограничения создаются
+-- NOT VALID: новые и изменяемые строки проверяются сразу, а существующие
+-- нарушения находит и исправляет команда integrity, которая затем
+-- выполняет VALIDATE CONSTRAINT.
+ALTER TABLE pull_requests ADD CONSTRAINT pull_requests_author_fkey
+    FOREIGN KEY (org_id, author_id) REFERENCES users(org_id, user_id) NOT VALID;
+ALTER TABLE pull_requests ADD CONSTRAINT pull_requests_reviewer1_fkey
+    FOREIGN KEY (org_id, reviewer1_id) REFERENCES users(org_id, user_id) NOT VALID;
+ALTER TABLE pull_requests ADD CONSTRAINT pull_requests_reviewer2_fkey
+    FOREIGN KEY (org_id, reviewer2_id) REFERENCES users(org_id, user_id) NOT VALID;
+ALTER TABLE pull_requests ADD CONSTRAINT pull_requests_status_check
+    CHECK (status IN ('OPEN', 'MERGED')) NOT VALID;
+ALTER TABLE pull_requests ADD CONSTRAINT pull_requests_merged_at_check
+    CHECK ((status = 'MERGED') = (merged_at IS NOT NULL)) NOT VALID;
+
+-- +goose Down
+-- SQL in this section is executed when the migration is rolled back.
+
+ALTER TABLE pull_requests DROP CONSTRAINT pull_requests_merged_at_check;
+ALTER TABLE pull_requests DROP CONSTRAINT pull_requests_status_check;
+ALTER TABLE pull_requests DROP CONSTRAINT pull_requests_reviewer2_fkey;
+ALTER TABLE pull_requests DROP CONSTRAINT pull_requests_reviewer1_fkey;
+ALTER TABLE pull_requests DROP CONSTRAINT pull_requests_author_fkey;
+
+CREATE TABLE reviewers (
+    id SERIAL PRIMARY KEY,
+    team_id INTEGER REFERENCES teams(id),
+    github_username VARCHAR(255) NOT NULL UNIQUE,
+    capacity INTEGER DEFAULT 1,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,22 @@
+-- +goose Up
+-- SQL in this section is executed when the migration is applied.
+
+-- Каскадное удаление записей журнала при удалении организации противоречит
+-- запрету на удаление: организацию с записями в журнале удалить нельзя
+ALTER TABLE audit_log DROP CONSTRAINT audit_log_org_id_fkey;
+ALTER TABLE audit_log ADD CONSTRAINT audit_log_org_id_fkey
+    FOREIGN KEY (org_id) REFERENCES organizations(org_id);
+
+-- TRUNCATE не вызывает строчные триггеры, поэтому запрещается отдельно
+CREATE TRIGGER audit_log_no_truncate
+    BEFORE TRUNCATE ON audit_log
+    FOR EACH STATEMENT EXECUTE FUNCTION audit_log_append_only();
+
+-- +goose Down
+-- SQL in this section is executed when the migration is rolled back.
+
+DROP TRIGGER audit_log_no_truncate ON audit_log;
+
+ALTER TABLE audit_log DROP CONSTRAINT audit_log_org_id_fkey;
+ALTER TABLE audit_log ADD CONSTRAINT audit_log_org_id_fkey
+    FOREIGN KEY (org_id) REFERENCES organizations(org_id) ON DELETE CASCADE;
@@ -0,0 +1,18 @@
+-- +goose Up
+-- SQL in this section is executed when the migration is applied.
+
+-- Триграммные индексы из 000010 пересоздаются в 000014, где они необязательны
+-- и пропускаются без pg_trgm. Расширение не удаляется: им могут пользоваться
+-- другие объекты базы.
+DROP INDEX IF EXISTS idx_users_user_id_trgm;
+DROP INDEX IF EXISTS idx_users_username_trgm;
+DROP INDEX IF EXISTS idx_pull_requests_name_trgm;
+
+-- +goose Down
+-- SQL in this section is executed when the migration is rolled back.
+
+-- Откат 000010 удаляет индексы без IF EXISTS, поэтому они должны быть на месте;
+-- pg_trgm к этому моменту уже создан миграцией 000010
+CREATE INDEX IF NOT EXISTS idx_pull_requests_name_trgm ON pull_requests USING gin (pull_request_name gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_users_username_trgm ON users USING gin (username gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_users_user_id_trgm ON users USING gin (user_id gin_trgm_ops);
@@ -0,0 +1,33 @@
+-- +goose Up
+-- SQL in this section is executed when the migration is applied.
+
+-- Триграммные индексы ускоряют поиск подстроки (ILIKE '%...%'), но не
+-- обязательны: без них поиск работает последовательным просмотром. Расширение
+-- pg_trgm создает суперпользователь или владелец базы (с PostgreSQL 13 оно
+-- доверенное); если прав нет или расширение не установлено, индексы
+-- пропускаются с предупреждением. Их можно создать позже теми же командами
+-- после CREATE EXTENSION pg_trgm.
+-- Индексы из 000010 перед этим удаляет 000013.
+-- +goose StatementBegin
+DO $$
+BEGIN
+    BEGIN
+        CREATE EXTENSION IF NOT EXISTS pg_trgm;
+    EXCEPTION WHEN insufficient_privilege OR undefined_file THEN
+        RAISE WARNING 'pg_trgm is not available (%), trigram search indexes are skipped', SQLERRM;
+        RETURN;
+    END;
+
+    CREATE INDEX IF NOT EXISTS idx_pull_requests_name_trgm ON pull_requests USING gin (pull_request_name gin_trgm_ops);
+    CREATE INDEX IF NOT EXISTS idx_users_username_trgm ON users USING gin (username gin_trgm_ops);
+    CREATE INDEX IF NOT EXISTS idx_users_user_id_trgm ON users USING gin (user_id gin_trgm_ops);
+END
+$$;
+-- +goose StatementEnd
+
+-- +goose Down
+-- SQL in this section is executed when the migration is rolled back.
+
+DROP INDEX IF EXISTS idx_users_user_id_trgm;
+DROP INDEX IF EXISTS idx_users_username_trgm;
+DROP INDEX IF EXISTS idx_pull_requests_name_trgm;
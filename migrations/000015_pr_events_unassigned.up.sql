@@ -0,0 +1,15 @@
+-- +goose Up
+-- SQL in this section is executed when the migration is applied.
+
+-- UNASSIGNED: ревьюер снят без замены, например при исправлении integrity
+ALTER TABLE pr_events DROP CONSTRAINT pr_events_event_type_check;
+ALTER TABLE pr_events ADD CONSTRAINT pr_events_event_type_check
+    CHECK (event_type IN ('CREATED', 'ASSIGNED', 'REASSIGNED', 'UNASSIGNED', 'VERDICT', 'MERGED'));
+
+-- +goose Down
+-- SQL in this section is executed when the migration is rolled back.
+
+DELETE FROM pr_events WHERE event_type = 'UNASSIGNED';
+ALTER TABLE pr_events DROP CONSTRAINT pr_events_event_type_check;
+ALTER TABLE pr_events ADD CONSTRAINT pr_events_event_type_check
+    CHECK (event_type IN ('CREATED', 'ASSIGNED', 'REASSIGNED', 'VERDICT', 'MERGED'));
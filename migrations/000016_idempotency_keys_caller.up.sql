@@ -0,0 +1,21 @@
+-- +goose Up
+-- SQL in this section is executed when the migration is applied.
+
+-- Ключ идемпотентности принадлежит вызывающему (UserID или имя токена), а не
+-- всей организации: другой вызывающий с тем же ключом не получает чужой ответ.
+-- Сохраненные ранее ответы удаляются: они могли содержать открытые токены и
+-- не привязаны к вызывающему.
+DELETE FROM idempotency_keys;
+
+ALTER TABLE idempotency_keys ADD COLUMN caller VARCHAR(255) NOT NULL;
+ALTER TABLE idempotency_keys DROP CONSTRAINT idempotency_keys_pkey;
+ALTER TABLE idempotency_keys ADD PRIMARY KEY (org_id, caller, idempotency_key);
+
+-- +goose Down
+-- SQL in this section is executed when the migration is rolled back.
+
+DELETE FROM idempotency_keys;
+
+ALTER TABLE idempotency_keys DROP CONSTRAINT idempotency_keys_pkey;
+ALTER TABLE idempotency_keys DROP COLUMN caller;
+ALTER TABLE idempotency_keys ADD PRIMARY KEY (org_id, idempotency_key);
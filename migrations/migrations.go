@@ -0,0 +1,9 @@
+// Package migrations встраивает SQL-миграции схемы в бинарный файл
+package migrations
+
+import "embed"
+
+// FS SQL-миграции goose (файлы в корне)
+//
+//go:embed *.sql
+var FS embed.FS